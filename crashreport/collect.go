@@ -0,0 +1,41 @@
+package crashreport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Collect returns the paths of the .ips reports in the given directories, which were written after the given time.
+// Missing directories are skipped.
+func Collect(dirs []string, since time.Time) ([]string, error) {
+	var paths []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list crash reports in %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".ips" {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().After(since) {
+				continue
+			}
+
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
@@ -0,0 +1,228 @@
+package crashreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// crashBugType is the bug type of crash reports, other .ips reports (hangs, jetsam events...) are ignored.
+const crashBugType = "309"
+
+// ErrNotCrashReport is returned when parsing an .ips report which is not a crash report.
+var ErrNotCrashReport = errors.New("not a crash report")
+
+// Report is a crash report (.ips) written by the system when a process crashed.
+type Report struct {
+	// Path is the path of the .ips file.
+	Path      string
+	Process   string
+	PID       int
+	BundleID  string
+	ProcPath  string
+	Timestamp string
+	Exception string
+	// Frames are the frames of the crashed thread.
+	Frames []Frame
+
+	images []ipsImage
+}
+
+// Frame is a stack frame of the crashed thread.
+type Frame struct {
+	Image string
+	// Address is the runtime address of the frame, it is 0 if the binary image is unknown.
+	Address uint64
+	// Symbol is the function name, including the source location if the frame is symbolicated.
+	Symbol string
+	// Symbolicated is true if the symbol was resolved against a dSYM.
+	Symbolicated bool
+
+	imageIndex int
+}
+
+type ipsHeader struct {
+	BugType   string `json:"bug_type"`
+	Timestamp string `json:"timestamp"`
+	BundleID  string `json:"bundleID"`
+}
+
+type ipsBody struct {
+	ProcName       string       `json:"procName"`
+	ProcPath       string       `json:"procPath"`
+	PID            int          `json:"pid"`
+	Exception      ipsException `json:"exception"`
+	FaultingThread int          `json:"faultingThread"`
+	Threads        []ipsThread  `json:"threads"`
+	UsedImages     []ipsImage   `json:"usedImages"`
+}
+
+type ipsException struct {
+	Type    string `json:"type"`
+	Signal  string `json:"signal"`
+	Subtype string `json:"subtype"`
+}
+
+type ipsThread struct {
+	Triggered bool       `json:"triggered"`
+	Frames    []ipsFrame `json:"frames"`
+}
+
+type ipsFrame struct {
+	ImageOffset    uint64 `json:"imageOffset"`
+	ImageIndex     int    `json:"imageIndex"`
+	Symbol         string `json:"symbol"`
+	SymbolLocation uint64 `json:"symbolLocation"`
+	SourceFile     string `json:"sourceFile"`
+	SourceLine     int    `json:"sourceLine"`
+}
+
+type ipsImage struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Base uint64 `json:"base"`
+	Arch string `json:"arch"`
+}
+
+/*
+ParseFile parses an .ips crash report.
+
+Since Xcode 13 crash reports are written in the JSON based .ips format: the first line is a JSON header describing the
+report, the rest of the file is a JSON document with the details of the crashed process.
+*/
+func ParseFile(pth string) (Report, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read crash report: %w", err)
+	}
+
+	report, err := parse(content)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to parse crash report (%s): %w", filepath.Base(pth), err)
+	}
+	report.Path = pth
+
+	return report, nil
+}
+
+func parse(content []byte) (Report, error) {
+	headerLine, bodyContent, found := bytes.Cut(content, []byte("\n"))
+	if !found {
+		return Report{}, errors.New("missing report body")
+	}
+
+	var header ipsHeader
+	if err := json.Unmarshal(headerLine, &header); err != nil {
+		return Report{}, fmt.Errorf("invalid report header: %w", err)
+	}
+	if header.BugType != crashBugType {
+		return Report{}, ErrNotCrashReport
+	}
+
+	var body ipsBody
+	if err := json.Unmarshal(bodyContent, &body); err != nil {
+		return Report{}, fmt.Errorf("invalid report body: %w", err)
+	}
+
+	report := Report{
+		Process:   body.ProcName,
+		PID:       body.PID,
+		BundleID:  header.BundleID,
+		ProcPath:  body.ProcPath,
+		Timestamp: header.Timestamp,
+		Exception: body.Exception.description(),
+		images:    body.UsedImages,
+	}
+
+	if thread := body.crashedThread(); thread != nil {
+		for _, ipsFrame := range thread.Frames {
+			report.Frames = append(report.Frames, body.frame(ipsFrame))
+		}
+	}
+
+	return report, nil
+}
+
+func (e ipsException) description() string {
+	description := e.Type
+	if e.Signal != "" {
+		description = fmt.Sprintf("%s (%s)", description, e.Signal)
+	}
+	if e.Subtype != "" {
+		description = fmt.Sprintf("%s: %s", description, e.Subtype)
+	}
+	return description
+}
+
+func (b ipsBody) crashedThread() *ipsThread {
+	for i, thread := range b.Threads {
+		if thread.Triggered {
+			return &b.Threads[i]
+		}
+	}
+
+	if b.FaultingThread >= 0 && b.FaultingThread < len(b.Threads) {
+		return &b.Threads[b.FaultingThread]
+	}
+
+	return nil
+}
+
+func (b ipsBody) frame(ipsFrame ipsFrame) Frame {
+	frame := Frame{Image: "???", imageIndex: -1}
+
+	if ipsFrame.ImageIndex >= 0 && ipsFrame.ImageIndex < len(b.UsedImages) {
+		image := b.UsedImages[ipsFrame.ImageIndex]
+		frame.imageIndex = ipsFrame.ImageIndex
+		frame.Address = image.Base + ipsFrame.ImageOffset
+		if image.Name != "" {
+			frame.Image = image.Name
+		} else if image.Path != "" {
+			frame.Image = filepath.Base(image.Path)
+		}
+	}
+
+	switch {
+	case ipsFrame.Symbol != "" && ipsFrame.SourceFile != "":
+		frame.Symbol = fmt.Sprintf("%s (%s:%d)", ipsFrame.Symbol, ipsFrame.SourceFile, ipsFrame.SourceLine)
+	case ipsFrame.Symbol != "":
+		frame.Symbol = fmt.Sprintf("%s + %d", ipsFrame.Symbol, ipsFrame.SymbolLocation)
+	default:
+		frame.Symbol = fmt.Sprintf("0x%x", frame.Address)
+	}
+
+	return frame
+}
+
+// Identifier is the process name and PID of the crashed process, as it appears in the xcodebuild failure messages.
+func (r Report) Identifier() string {
+	return fmt.Sprintf("%s (%d)", r.Process, r.PID)
+}
+
+// MatchesFailure returns whether the given test failure message refers to the crashed process.
+func (r Report) MatchesFailure(message string) bool {
+	return r.Process != "" && strings.Contains(message, r.Identifier())
+}
+
+// Summary is a human readable description of the crash with the backtrace of the crashed thread.
+func (r Report) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s crashed", r.Identifier())
+	if r.Exception != "" {
+		fmt.Fprintf(&b, ": %s", r.Exception)
+	}
+	if r.Timestamp != "" {
+		fmt.Fprintf(&b, "\nTime: %s", r.Timestamp)
+	}
+	b.WriteString("\nCrashed thread:")
+	for i, frame := range r.Frames {
+		fmt.Fprintf(&b, "\n%-3d %-30s %s", i, frame.Image, frame.Symbol)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package crashreport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenCrashReport_WhenParsed_ThenReturnsTheCrashedThread(t *testing.T) {
+	// When
+	report, err := ParseFile(filepath.Join("testdata", "BullsEye-2024-05-02-101112.ips"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "BullsEye", report.Process)
+	require.Equal(t, 12345, report.PID)
+	require.Equal(t, "io.bitrise.BullsEye", report.BundleID)
+	require.Equal(t, "EXC_BREAKPOINT (SIGTRAP)", report.Exception)
+	require.Contains(t, report.ProcPath, "E8C36A8B-1234-5678-9ABC-DEF012345678")
+	require.Len(t, report.Frames, 4)
+	require.Equal(t, "BullsEye", report.Frames[0].Image)
+	require.Equal(t, uint64(0x10067c010), report.Frames[0].Address)
+	require.Equal(t, "0x10067c010", report.Frames[0].Symbol)
+	require.Equal(t, "UIApplicationMain + 124", report.Frames[2].Symbol)
+}
+
+func Test_GivenNotACrashReport_WhenParsed_ThenReturnsNotCrashReportError(t *testing.T) {
+	// When
+	_, err := ParseFile(filepath.Join("testdata", "BullsEye-2024-05-02-101113.ips"))
+
+	// Then
+	require.ErrorIs(t, err, ErrNotCrashReport)
+}
+
+func Test_GivenFailureMessage_WhenMatched_ThenMatchesTheCrashedProcess(t *testing.T) {
+	report := Report{Process: "BullsEye", PID: 12345}
+
+	require.True(t, report.MatchesFailure("Crash: BullsEye (12345) at ViewController.crash()"))
+	require.False(t, report.MatchesFailure("Crash: BullsEye (54321) at ViewController.crash()"))
+	require.False(t, report.MatchesFailure("XCTAssertEqual failed"))
+}
+
+func Test_GivenReport_WhenSummarized_ThenListsTheCrashedThread(t *testing.T) {
+	report := Report{
+		Process:   "BullsEye",
+		PID:       12345,
+		Exception: "EXC_BREAKPOINT (SIGTRAP)",
+		Frames: []Frame{
+			{Image: "BullsEye", Symbol: "ViewController.crash() (in BullsEye) (ViewController.swift:42)"},
+			{Image: "UIKitCore", Symbol: "UIApplicationMain + 124"},
+		},
+	}
+
+	require.Equal(t, `BullsEye (12345) crashed: EXC_BREAKPOINT (SIGTRAP)
+Crashed thread:
+0   BullsEye                       ViewController.crash() (in BullsEye) (ViewController.swift:42)
+1   UIKitCore                      UIApplicationMain + 124`, report.Summary())
+}
+
+func Test_GivenReportsWrittenBeforeAndAfterTheTestRun_WhenCollected_ThenReturnsTheNewReports(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	since := time.Now()
+
+	oldReport := filepath.Join(dir, "BullsEye-old.ips")
+	require.NoError(t, os.WriteFile(oldReport, []byte("{}"), 0600))
+	require.NoError(t, os.Chtimes(oldReport, since.Add(-time.Hour), since.Add(-time.Hour)))
+
+	newReport := filepath.Join(dir, "BullsEye-new.ips")
+	require.NoError(t, os.WriteFile(newReport, []byte("{}"), 0600))
+	require.NoError(t, os.Chtimes(newReport, since.Add(time.Minute), since.Add(time.Minute)))
+
+	otherFile := filepath.Join(dir, "BullsEye.crash")
+	require.NoError(t, os.WriteFile(otherFile, []byte("{}"), 0600))
+	require.NoError(t, os.Chtimes(otherFile, since.Add(time.Minute), since.Add(time.Minute)))
+
+	// When
+	paths, err := Collect([]string{dir, filepath.Join(dir, "missing")}, since)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{newReport}, paths)
+}
@@ -0,0 +1,156 @@
+package crashreport
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// dwarfdumpUUIDPattern matches the `dwarfdump --uuid` output lines, for example:
+// UUID: 9C4C7E3E-5A0B-3E4F-8E2A-0D1F2C3B4A59 (arm64) /path/BullsEye.app.dSYM/Contents/Resources/DWARF/BullsEye
+var dwarfdumpUUIDPattern = regexp.MustCompile(`^UUID: ([0-9A-Fa-f-]+) \(([^)]+)\) (.+)$`)
+
+// Symbolicator resolves the crashed thread frames of crash reports against dSYMs.
+type Symbolicator interface {
+	Symbolicate(report Report) Report
+}
+
+type symbolicator struct {
+	logger         log.Logger
+	commandFactory command.Factory
+	dsymSearchDirs []string
+
+	// binaries maps the (upper-cased) UUIDs to the DWARF binaries of the dSYMs, it is built on the first use.
+	binaries map[string]string
+}
+
+// NewSymbolicator creates a Symbolicator which uses the dSYMs found in the given directories.
+func NewSymbolicator(logger log.Logger, commandFactory command.Factory, dsymSearchDirs []string) Symbolicator {
+	return &symbolicator{
+		logger:         logger,
+		commandFactory: commandFactory,
+		dsymSearchDirs: dsymSearchDirs,
+	}
+}
+
+/*
+Symbolicate symbolicates the frames of the crashed thread with `atos`, if a dSYM with the UUID of the frame's binary
+image is available. Frames which can not be symbolicated keep the symbol written by the system (if any).
+*/
+func (s *symbolicator) Symbolicate(report Report) Report {
+	if s.binaries == nil {
+		s.binaries = s.indexDSYMs()
+	}
+
+	framesByImage := map[int][]int{}
+	for i, frame := range report.Frames {
+		if frame.imageIndex >= 0 {
+			framesByImage[frame.imageIndex] = append(framesByImage[frame.imageIndex], i)
+		}
+	}
+
+	for imageIndex, frameIndexes := range framesByImage {
+		image := report.images[imageIndex]
+		binary, ok := s.binaries[strings.ToUpper(image.UUID)]
+		if !ok {
+			continue
+		}
+
+		var addresses []uint64
+		for _, i := range frameIndexes {
+			addresses = append(addresses, report.Frames[i].Address)
+		}
+
+		symbols, err := s.atos(binary, image, addresses)
+		if err != nil {
+			s.logger.Warnf("Failed to symbolicate %s frames: %s", image.Name, err)
+			continue
+		}
+
+		for j, i := range frameIndexes {
+			if j < len(symbols) && !strings.HasPrefix(symbols[j], "0x") {
+				report.Frames[i].Symbol = symbols[j]
+				report.Frames[i].Symbolicated = true
+			}
+		}
+	}
+
+	return report
+}
+
+func (s *symbolicator) atos(binary string, image ipsImage, addresses []uint64) ([]string, error) {
+	args := []string{"-o", binary, "-l", fmt.Sprintf("0x%x", image.Base)}
+	if image.Arch != "" {
+		args = append(args, "-arch", image.Arch)
+	}
+	for _, address := range addresses {
+		args = append(args, fmt.Sprintf("0x%x", address))
+	}
+
+	cmd := s.commandFactory.Create("atos", args, nil)
+	s.logger.Debugf("$ %s", cmd.PrintableCommandArgs())
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(out, "\n"), nil
+}
+
+// indexDSYMs finds the dSYMs in the search directories and maps the UUIDs of their binaries to the binary paths.
+func (s *symbolicator) indexDSYMs() map[string]string {
+	binaries := map[string]string{}
+	for _, dsym := range findDSYMs(s.dsymSearchDirs) {
+		cmd := s.commandFactory.Create("dwarfdump", []string{"--uuid", dsym}, nil)
+		out, err := cmd.RunAndReturnTrimmedOutput()
+		if err != nil {
+			s.logger.Debugf("Failed to read dSYM UUIDs (%s): %s", dsym, err)
+			continue
+		}
+
+		for uuid, binary := range parseDwarfdumpUUIDs(out) {
+			binaries[uuid] = binary
+		}
+	}
+
+	s.logger.Debugf("Found %d dSYM binaries for symbolication", len(binaries))
+
+	return binaries
+}
+
+func parseDwarfdumpUUIDs(out string) map[string]string {
+	binaries := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		match := dwarfdumpUUIDPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		binaries[strings.ToUpper(match[1])] = match[3]
+	}
+
+	return binaries
+}
+
+func findDSYMs(dirs []string) []string {
+	var dsyms []string
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(pth string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable and missing directories are skipped.
+				return nil
+			}
+			if entry.IsDir() && filepath.Ext(pth) == ".dSYM" {
+				dsyms = append(dsyms, pth)
+				return fs.SkipDir
+			}
+			return nil
+		})
+	}
+
+	return dsyms
+}
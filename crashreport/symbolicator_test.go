@@ -0,0 +1,46 @@
+package crashreport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-steplib/steps-xcode-test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenMatchingDSYM_WhenSymbolicated_ThenResolvesTheAppFrames(t *testing.T) {
+	// Given
+	productsDir := t.TempDir()
+	dsym := filepath.Join(productsDir, "Debug-iphonesimulator", "BullsEye.app.dSYM")
+	require.NoError(t, os.MkdirAll(filepath.Join(dsym, "Contents", "Resources", "DWARF"), 0700))
+	binary := filepath.Join(dsym, "Contents", "Resources", "DWARF", "BullsEye")
+
+	dwarfdumpCmd := new(mocks.Command)
+	dwarfdumpCmd.On("RunAndReturnTrimmedOutput").Return("UUID: 9C4C7E3E-5A0B-3E4F-8E2A-0D1F2C3B4A59 (arm64) "+binary, nil)
+	atosCmd := new(mocks.Command)
+	atosCmd.On("PrintableCommandArgs").Return("")
+	atosCmd.On("RunAndReturnTrimmedOutput").Return("ViewController.crash() (in BullsEye) (ViewController.swift:42)\n0x10067c0ec", nil)
+
+	commandFactory := new(mocks.CommandFactory)
+	commandFactory.On("Create", "dwarfdump", []string{"--uuid", dsym}, mock.Anything).Return(dwarfdumpCmd)
+	commandFactory.On("Create", "atos", []string{"-o", binary, "-l", "0x100678000", "-arch", "arm64", "0x10067c010", "0x10067c0ec"}, mock.Anything).Return(atosCmd)
+
+	report, err := ParseFile(filepath.Join("testdata", "BullsEye-2024-05-02-101112.ips"))
+	require.NoError(t, err)
+
+	symbolicator := NewSymbolicator(log.NewLogger(), commandFactory, []string{productsDir})
+
+	// When
+	symbolicated := symbolicator.Symbolicate(report)
+
+	// Then
+	require.Equal(t, "ViewController.crash() (in BullsEye) (ViewController.swift:42)", symbolicated.Frames[0].Symbol)
+	require.True(t, symbolicated.Frames[0].Symbolicated)
+	require.Equal(t, "0x10067c0ec", symbolicated.Frames[1].Symbol)
+	require.False(t, symbolicated.Frames[1].Symbolicated)
+	require.Equal(t, "UIApplicationMain + 124", symbolicated.Frames[2].Symbol)
+	commandFactory.AssertExpectations(t)
+}
@@ -17,15 +17,16 @@ import (
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/bitrise-io/go-xcode/v2/destination"
-	"github.com/bitrise-io/go-xcode/v2/simulator"
 	"github.com/bitrise-io/go-xcode/v2/xcconfig"
 	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
 	"github.com/bitrise-io/go-xcode/v2/xcodecommand"
 	"github.com/bitrise-io/go-xcode/v2/xcodeversion"
 	"github.com/bitrise-steplib/steps-xcode-test/output"
+	"github.com/bitrise-steplib/steps-xcode-test/simulator"
 	"github.com/bitrise-steplib/steps-xcode-test/step"
 	"github.com/bitrise-steplib/steps-xcode-test/testaddon"
 	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
 )
 
 func main() {
@@ -47,6 +48,14 @@ func run() int {
 		return 1
 	}
 
+	if config.DryRun {
+		if err := xcodeTestRunner.DryRun(config); err != nil {
+			logger.Errorf(errorutil.FormattedError(fmt.Errorf("Dry run failed: %w", err)))
+			return 1
+		}
+		return 0
+	}
+
 	xcodeTestRunner.InstallDeps()
 
 	res, runErr := xcodeTestRunner.Run(config)
@@ -95,6 +104,7 @@ func createStep(logger log.Logger, logFormatter string) (step.XcodeTestRunner, e
 	testAddonExporter := testaddon.NewExporter(testaddon.NewTestAddon(logger))
 	stepenvRepository := stepenv.NewRepository(envRepository)
 	exporter := output.NewExporter(stepenvRepository, logger, outputExporter, testAddonExporter)
+	xcresultProcessor := xcresult.NewProcessor(logger, commandFactory)
 	utils := step.NewUtils(logger)
 
 	// Only the factory handed to the xcodecommand runner gets wrapped — codesign,
@@ -127,5 +137,5 @@ func createStep(logger log.Logger, logFormatter string) (step.XcodeTestRunner, e
 
 	xcodebuilder := xcodebuild.NewXcodebuild(logger, fileManager, xcconfigWriter, xcodeCommandRunner)
 
-	return step.NewXcodeTestRunner(logger, commandFactory, xcodebuilder, simulatorManager, swiftCache, exporter, pathModifier, pathProvider, utils), nil
+	return step.NewXcodeTestRunner(logger, commandFactory, runnerCmdFactory, xcodebuilder, simulatorManager, swiftCache, exporter, xcresultProcessor, pathModifier, pathProvider, utils), nil
 }
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
+)
+
+const (
+	crashReportsEnvVarKey   = "BITRISE_CRASH_REPORTS_ZIP_PATH"
+	crashReportsZipName     = "crash_reports.zip"
+	crashReportPropertyName = "crash_report"
+)
+
+type crashReportSummary struct {
+	File          string   `json:"file"`
+	Process       string   `json:"process"`
+	PID           int      `json:"pid"`
+	Exception     string   `json:"exception,omitempty"`
+	CrashedThread []string `json:"crashed_thread"`
+}
+
+func (e exporter) ExportCrashReports(deployDir string, crashReportPaths []string) error {
+	zipPath := filepath.Join(deployDir, crashReportsZipName)
+	if err := e.outputExporter.ExportOutputFilesZip(crashReportsEnvVarKey, crashReportPaths, zipPath); err != nil {
+		return fmt.Errorf("failed to export: %s: %w", crashReportsEnvVarKey, err)
+	}
+
+	return nil
+}
+
+// testCaseCrashReports returns the crash reports of the processes referred by the failure messages of the test case runs.
+func testCaseCrashReports(testCase model3.TestCaseWithRetries, crashReports []crashreport.Report) []crashreport.Report {
+	if len(crashReports) == 0 {
+		return nil
+	}
+
+	var messages []string
+	if testCase.Result == model3.TestResultFailed {
+		messages = append(messages, testCase.Message)
+	}
+	for _, retry := range testCase.Retries {
+		if retry.Result == model3.TestResultFailed {
+			messages = append(messages, retry.Message)
+		}
+	}
+
+	var reports []crashreport.Report
+	for _, report := range crashReports {
+		for _, message := range messages {
+			if report.MatchesFailure(message) {
+				reports = append(reports, report)
+				break
+			}
+		}
+	}
+
+	return reports
+}
+
+func createCrashReportSummary(report crashreport.Report) crashReportSummary {
+	summary := crashReportSummary{
+		File:          filepath.Base(report.Path),
+		Process:       report.Process,
+		PID:           report.PID,
+		Exception:     report.Exception,
+		CrashedThread: []string{},
+	}
+	for _, frame := range report.Frames {
+		summary.CrashedThread = append(summary.CrashedThread, fmt.Sprintf("%s %s", frame.Image, frame.Symbol))
+	}
+
+	return summary
+}
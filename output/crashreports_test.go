@@ -0,0 +1,63 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-steputils/v2/testreport"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
+	"github.com/stretchr/testify/require"
+)
+
+var bullsEyeCrash = crashreport.Report{
+	Path:      "/tmp/CrashReports/BullsEye-2024-05-02-101112.ips",
+	Process:   "BullsEye",
+	PID:       12345,
+	Exception: "EXC_BREAKPOINT (SIGTRAP)",
+	Frames: []crashreport.Frame{
+		{Image: "BullsEye", Symbol: "ViewController.crash() (in BullsEye) (ViewController.swift:42)", Symbolicated: true},
+	},
+}
+
+func Test_GivenCrashedTestCase_WhenConvertedToJUnit_ThenAttachesTheCrashReport(t *testing.T) {
+	// Given
+	testCase := model3.TestCaseWithRetries{TestCase: model3.TestCase{
+		Name:      "testCrash()",
+		ClassName: "BullsEyeUITests",
+		Result:    model3.TestResultFailed,
+		Message:   "Crash: BullsEye (12345) at ViewController.crash()",
+	}}
+	otherCrash := crashreport.Report{Path: "/tmp/CrashReports/BullsEye-2024-05-02-101500.ips", Process: "BullsEye", PID: 23456}
+
+	// When
+	junitTestCase := convertTestCase(testCase, nil, testCaseCrashReports(testCase, []crashreport.Report{bullsEyeCrash, otherCrash}))
+
+	// Then
+	require.Equal(t, &testreport.Properties{Property: []testreport.Property{
+		{Name: "crash_report", Value: "BullsEye-2024-05-02-101112.ips"},
+	}}, junitTestCase.Properties)
+	require.Equal(t, bullsEyeCrash.Summary(), junitTestCase.SystemErr.Value)
+}
+
+func Test_GivenCrashOnFirstAttempt_WhenSummarized_ThenAttachesTheCrashReport(t *testing.T) {
+	// Given
+	testCase := model3.TestCaseWithRetries{
+		TestCase: model3.TestCase{Name: "testCrash()", ClassName: "BullsEyeUITests", Result: model3.TestResultPassed},
+		Retries: []model3.TestCase{
+			{Name: "testCrash()", ClassName: "BullsEyeUITests", Result: model3.TestResultFailed, Message: "BullsEye (12345) encountered an error (Early unexpected exit)"},
+			{Name: "testCrash()", ClassName: "BullsEyeUITests", Result: model3.TestResultPassed},
+		},
+	}
+
+	// When
+	summary := createTestCaseSummary(testCase, nil, testCaseCrashReports(testCase, []crashreport.Report{bullsEyeCrash}))
+
+	// Then
+	require.Equal(t, []crashReportSummary{{
+		File:          "BullsEye-2024-05-02-101112.ips",
+		Process:       "BullsEye",
+		PID:           12345,
+		Exception:     "EXC_BREAKPOINT (SIGTRAP)",
+		CrashedThread: []string{"BullsEye ViewController.crash() (in BullsEye) (ViewController.swift:42)"},
+	}}, summary.CrashReports)
+}
@@ -0,0 +1,119 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
+)
+
+type flakyTestCasesReport struct {
+	FlakyTestCases []flakyTestCase `json:"flaky_test_cases"`
+}
+
+type flakyTestCase struct {
+	TestPlan  string `json:"test_plan"`
+	Bundle    string `json:"bundle"`
+	ClassName string `json:"class_name"`
+	Name      string `json:"name"`
+	// Arguments are the arguments of a parameterized Swift Testing test case.
+	Arguments string   `json:"arguments,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	// Attempts is the number of times the test case ran.
+	Attempts int                `json:"attempts"`
+	Runs     []flakyTestCaseRun `json:"runs"`
+}
+
+type flakyTestCaseRun struct {
+	Result         string  `json:"result"`
+	Duration       float64 `json:"duration"`
+	FailureMessage string  `json:"failure_message,omitempty"`
+}
+
+// name is the identifier of the test case in the BITRISE_FLAKY_TEST_CASES env var (<bundle>.<class>.<method>),
+// followed by the arguments of a parameterized Swift Testing test case: <bundle>.<class>.<method> (arguments: <arguments>).
+func (t flakyTestCase) name() string {
+	name := t.Name
+	if len(t.ClassName) > 0 {
+		name = fmt.Sprintf("%s.%s", t.ClassName, t.Name)
+	}
+	if t.Arguments != "" {
+		name = fmt.Sprintf("%s (arguments: %s)", name, t.Arguments)
+	}
+	return t.Bundle + "." + name
+}
+
+// createFlakyTestCasesReport creates the report of the flaky test cases with their Swift Testing tags (by xcresult.TestCaseKey).
+func createFlakyTestCasesReport(flakyTestPlans []model3.TestPlan, testCaseTags map[string][]string) flakyTestCasesReport {
+	report := flakyTestCasesReport{FlakyTestCases: []flakyTestCase{}}
+	for _, testPlan := range flakyTestPlans {
+		for _, testBundle := range testPlan.TestBundles {
+			for _, testSuite := range testBundle.TestSuites {
+				for _, testCase := range testSuite.TestCases {
+					tags := testCaseTags[xcresult.TestCaseKey(testBundle.Name, testCase.ClassName, testCase.Name)]
+					report.FlakyTestCases = append(report.FlakyTestCases, createFlakyTestCase(testPlan.Name, testBundle.Name, testCase, tags))
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+func createFlakyTestCase(testPlan, bundle string, testCase model3.TestCaseWithRetries, tags []string) flakyTestCase {
+	runs := testCase.Retries
+	if len(runs) == 0 {
+		runs = []model3.TestCase{testCase.TestCase}
+	}
+
+	name, arguments := xcresult.SplitTestCaseName(testCase.Name)
+	flaky := flakyTestCase{
+		TestPlan:  testPlan,
+		Bundle:    bundle,
+		ClassName: testCase.ClassName,
+		Name:      name,
+		Arguments: arguments,
+		Tags:      tags,
+		Attempts:  len(runs),
+	}
+	for _, run := range runs {
+		flaky.Runs = append(flaky.Runs, flakyTestCaseRun{
+			Result:         string(run.Result),
+			Duration:       run.Time.Seconds(),
+			FailureMessage: run.Message,
+		})
+	}
+
+	return flaky
+}
+
+/*
+flakyTestCasesPreview lists the flaky test cases (one `- <test case>` per line) up to the env var size limit.
+If not all the test cases fit, the last line points to the report with the rest of the test cases.
+The number of test cases left out of the preview is returned.
+*/
+func flakyTestCasesPreview(testCaseNames []string, reportPath string) (string, int) {
+	reserved := len(flakyTestCasesPreviewNote(len(testCaseNames), reportPath))
+
+	var preview string
+	for i, testCaseName := range testCaseNames {
+		line := fmt.Sprintf("- %s\n", testCaseName)
+
+		limit := flakyTestCasesEnvVarSizeLimitInBytes
+		if i < len(testCaseNames)-1 {
+			limit -= reserved
+		}
+		if len(preview)+len(line) > limit {
+			skipped := len(testCaseNames) - i
+			return preview + flakyTestCasesPreviewNote(skipped, reportPath), skipped
+		}
+
+		preview += line
+	}
+
+	return preview, 0
+}
+
+func flakyTestCasesPreviewNote(skipped int, reportPath string) string {
+	return fmt.Sprintf("... and %d more, see the full report: %s\n", skipped, reportPath)
+}
@@ -0,0 +1,82 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_createFlakyTestCasesReport(t *testing.T) {
+	testPlans := []model3.TestPlan{{Name: "UnitTests", TestBundles: []model3.TestBundle{{Name: "BullsEyeTests", TestSuites: []model3.TestSuite{
+		{
+			Name: "BullsEyeTests",
+			TestCases: []model3.TestCaseWithRetries{
+				{
+					TestCase: model3.TestCase{Name: "testFlakyFeature()", ClassName: "BullsEyeTests", Result: model3.TestResultPassed},
+					Retries: []model3.TestCase{
+						{Name: "testFlakyFeature()", ClassName: "BullsEyeTests", Result: model3.TestResultFailed, Time: 1500 * time.Millisecond, Message: "XCTAssertTrue failed"},
+						{Name: "testFlakyFeature()", ClassName: "BullsEyeTests", Result: model3.TestResultPassed, Time: time.Second},
+					},
+				},
+			},
+		},
+	}}}}}
+
+	report := createFlakyTestCasesReport(testPlans, nil)
+
+	require.Equal(t, flakyTestCasesReport{FlakyTestCases: []flakyTestCase{
+		{
+			TestPlan:  "UnitTests",
+			Bundle:    "BullsEyeTests",
+			ClassName: "BullsEyeTests",
+			Name:      "testFlakyFeature()",
+			Attempts:  2,
+			Runs: []flakyTestCaseRun{
+				{Result: "Failed", Duration: 1.5, FailureMessage: "XCTAssertTrue failed"},
+				{Result: "Passed", Duration: 1},
+			},
+		},
+	}}, report)
+}
+
+func Test_flakyTestCasesPreview(t *testing.T) {
+	var testCaseNames []string
+	for i := 0; i < 100; i++ {
+		testCaseNames = append(testCaseNames, "BullsEyeTests.BullsEyeTests.testFeature()")
+	}
+
+	preview, skipped := flakyTestCasesPreview(testCaseNames, "/deploy/flaky_test_cases.json")
+
+	require.LessOrEqual(t, len(preview), flakyTestCasesEnvVarSizeLimitInBytes)
+	require.Equal(t, 79, skipped)
+	require.Contains(t, preview, "... and 79 more, see the full report: /deploy/flaky_test_cases.json\n")
+}
+
+func Test_GivenParameterizedFlakyTestCase_WhenCreatingReport_ThenReportsArgumentsAndTags(t *testing.T) {
+	testPlans := []model3.TestPlan{{Name: "UnitTests", TestBundles: []model3.TestBundle{{Name: "BullsEyeTests", TestSuites: []model3.TestSuite{
+		{
+			Name: "NumberTests",
+			TestCases: []model3.TestCaseWithRetries{
+				{
+					TestCase: model3.TestCase{Name: "isEven(number:) (arguments: 3)", ClassName: "NumberTests", Result: model3.TestResultPassed},
+					Retries: []model3.TestCase{
+						{Name: "isEven(number:) (arguments: 3)", ClassName: "NumberTests", Result: model3.TestResultFailed},
+						{Name: "isEven(number:) (arguments: 3)", ClassName: "NumberTests", Result: model3.TestResultPassed},
+					},
+				},
+			},
+		},
+	}}}}}
+	testCaseTags := map[string][]string{"BullsEyeTests/NumberTests/isEven(number:) (arguments: 3)": {"critical"}}
+
+	report := createFlakyTestCasesReport(testPlans, testCaseTags)
+
+	require.Len(t, report.FlakyTestCases, 1)
+	flaky := report.FlakyTestCases[0]
+	require.Equal(t, "isEven(number:)", flaky.Name)
+	require.Equal(t, "3", flaky.Arguments)
+	require.Equal(t, []string{"critical"}, flaky.Tags)
+	require.Equal(t, "BullsEyeTests.NumberTests.isEven(number:) (arguments: 3)", flaky.name())
+}
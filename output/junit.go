@@ -0,0 +1,141 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-steputils/v2/testreport"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
+)
+
+const (
+	junitReportEnvVarKey   = "BITRISE_XCODE_TEST_JUNIT_PATH"
+	junitReportFileName    = "xcodebuild_test_junit.xml"
+	retryCountPropertyName = "retry_count"
+	argumentsPropertyName  = "arguments"
+	tagPropertyName        = "tag"
+)
+
+func (e exporter) ExportJUnitReport(deployDir string, testData model3.TestData, testSummary model3.TestSummary, crashReports []crashreport.Report) error {
+	content, err := xml.MarshalIndent(convertToJUnitReport(testSummary, xcresult.TestCaseTags(&testData), crashReports), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit test report: %w", err)
+	}
+
+	reportPth := filepath.Join(deployDir, junitReportFileName)
+	if err := os.WriteFile(reportPth, append([]byte(xml.Header), content...), 0600); err != nil {
+		return fmt.Errorf("failed to write JUnit test report: %w", err)
+	}
+
+	if err := e.envRepository.Set(junitReportEnvVarKey, reportPth); err != nil {
+		e.logger.Warnf("Failed to export: %s: %s", junitReportEnvVarKey, err)
+	}
+
+	return nil
+}
+
+// convertToJUnitReport creates a JUnit test suite for every test bundle of the test summary.
+// The Swift Testing tags (by xcresult.TestCaseKey) are added as properties of the test cases.
+// The crash reports are attached to the failed test cases of the crashed processes.
+func convertToJUnitReport(testSummary model3.TestSummary, testCaseTags map[string][]string, crashReports []crashreport.Report) testreport.TestReport {
+	var report testreport.TestReport
+
+	for _, testPlan := range testSummary.TestPlans {
+		for _, testBundle := range testPlan.TestBundles {
+			report.TestSuites = append(report.TestSuites, convertTestBundle(testBundle, testCaseTags, crashReports))
+		}
+	}
+
+	return report
+}
+
+func convertTestBundle(testBundle model3.TestBundle, testCaseTags map[string][]string, crashReports []crashreport.Report) testreport.TestSuite {
+	testSuite := testreport.TestSuite{Name: testBundle.Name}
+	var totalDuration time.Duration
+
+	for _, suite := range testBundle.TestSuites {
+		for _, testCase := range suite.TestCases {
+			tags := testCaseTags[xcresult.TestCaseKey(testBundle.Name, testCase.ClassName, testCase.Name)]
+			junitTestCase := convertTestCase(testCase, tags, testCaseCrashReports(testCase, crashReports))
+
+			switch {
+			case junitTestCase.Failure != nil:
+				testSuite.Failures++
+			case junitTestCase.Skipped != nil:
+				testSuite.Skipped++
+			}
+
+			totalDuration += testCase.Time
+			testSuite.TestCases = append(testSuite.TestCases, junitTestCase)
+		}
+	}
+
+	testSuite.Tests = len(testSuite.TestCases)
+	testSuite.Time = totalDuration.Seconds()
+
+	return testSuite
+}
+
+/*
+convertTestCase converts a test case to a JUnit test case. Every argument combination of a parameterized Swift Testing
+test case is a separate JUnit test case, with its arguments and the tags of the test case as properties:
+
+	<property name="arguments" value="3"></property>
+	<property name="tag" value="critical"></property>
+*/
+func convertTestCase(testCase model3.TestCaseWithRetries, tags []string, crashReports []crashreport.Report) testreport.TestCase {
+	junitTestCase := testreport.TestCase{
+		Name:      testCase.Name,
+		ClassName: testCase.ClassName,
+		Time:      testCase.Time.Seconds(),
+	}
+
+	switch testCase.Result {
+	case model3.TestResultFailed:
+		junitTestCase.Failure = &testreport.Failure{
+			Message: firstLine(testCase.Message),
+			Value:   testCase.Message,
+		}
+	case model3.TestResultSkipped:
+		junitTestCase.Skipped = &testreport.Skipped{Message: testCase.Message}
+	}
+
+	var properties []testreport.Property
+	if _, arguments := xcresult.SplitTestCaseName(testCase.Name); arguments != "" {
+		properties = append(properties, testreport.Property{Name: argumentsPropertyName, Value: arguments})
+	}
+	for _, tag := range tags {
+		properties = append(properties, testreport.Property{Name: tagPropertyName, Value: tag})
+	}
+	// The retries list contains every repetition of the test case, including the first run.
+	if len(testCase.Retries) > 1 {
+		properties = append(properties, testreport.Property{Name: retryCountPropertyName, Value: strconv.Itoa(len(testCase.Retries) - 1)})
+	}
+
+	var crashSummaries []string
+	for _, report := range crashReports {
+		properties = append(properties, testreport.Property{Name: crashReportPropertyName, Value: filepath.Base(report.Path)})
+		crashSummaries = append(crashSummaries, report.Summary())
+	}
+	if len(crashSummaries) > 0 {
+		junitTestCase.SystemErr = &testreport.SystemErr{Value: strings.Join(crashSummaries, "\n\n")}
+	}
+
+	if len(properties) > 0 {
+		junitTestCase.Properties = &testreport.Properties{Property: properties}
+	}
+
+	return junitTestCase
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-steputils/v2/testreport"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_convertToJUnitReport(t *testing.T) {
+	testSummary := model3.TestSummary{TestPlans: []model3.TestPlan{{Name: "TestPlan", TestBundles: []model3.TestBundle{
+		{
+			Name: "UnitTests",
+			TestSuites: []model3.TestSuite{
+				{
+					Name: "UnitTests",
+					TestCases: []model3.TestCaseWithRetries{
+						{
+							TestCase: model3.TestCase{Name: "testPassed()", ClassName: "UnitTests", Time: 1500 * time.Millisecond, Result: model3.TestResultPassed},
+						},
+						{
+							TestCase: model3.TestCase{Name: "testFailed()", ClassName: "UnitTests", Time: 500 * time.Millisecond, Result: model3.TestResultFailed, Message: "XCTAssertTrue failed\nat UnitTests.swift:12"},
+						},
+						{
+							TestCase: model3.TestCase{Name: "testSkipped()", ClassName: "UnitTests", Result: model3.TestResultSkipped, Message: "Test skipped"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "UITests",
+			TestSuites: []model3.TestSuite{
+				{
+					Name: "UITests",
+					TestCases: []model3.TestCaseWithRetries{
+						{
+							TestCase: model3.TestCase{Name: "testFlaky()", ClassName: "UITests", Time: 2 * time.Second, Result: model3.TestResultPassed},
+							Retries: []model3.TestCase{
+								{Name: "testFlaky()", ClassName: "UITests", Time: time.Second, Result: model3.TestResultFailed},
+								{Name: "testFlaky()", ClassName: "UITests", Time: time.Second, Result: model3.TestResultPassed},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}}}
+
+	want := testreport.TestReport{TestSuites: []testreport.TestSuite{
+		{
+			Name:     "UnitTests",
+			Tests:    3,
+			Failures: 1,
+			Skipped:  1,
+			Time:     2,
+			TestCases: []testreport.TestCase{
+				{Name: "testPassed()", ClassName: "UnitTests", Time: 1.5},
+				{Name: "testFailed()", ClassName: "UnitTests", Time: 0.5, Failure: &testreport.Failure{
+					Message: "XCTAssertTrue failed",
+					Value:   "XCTAssertTrue failed\nat UnitTests.swift:12",
+				}},
+				{Name: "testSkipped()", ClassName: "UnitTests", Skipped: &testreport.Skipped{Message: "Test skipped"}},
+			},
+		},
+		{
+			Name:  "UITests",
+			Tests: 1,
+			Time:  2,
+			TestCases: []testreport.TestCase{
+				{Name: "testFlaky()", ClassName: "UITests", Time: 2, Properties: &testreport.Properties{
+					Property: []testreport.Property{{Name: "retry_count", Value: "1"}},
+				}},
+			},
+		},
+	}}
+
+	require.Equal(t, want, convertToJUnitReport(testSummary, nil, nil))
+}
+
+func Test_GivenSwiftTestingTestCases_WhenConvertToJUnitReport_ThenArgumentsAndTagsAreProperties(t *testing.T) {
+	// Given
+	testSummary := model3.TestSummary{TestPlans: []model3.TestPlan{{Name: "TestPlan", TestBundles: []model3.TestBundle{{
+		Name: "BullsEyeTests",
+		TestSuites: []model3.TestSuite{{
+			Name: "NumberTests",
+			TestCases: []model3.TestCaseWithRetries{
+				{TestCase: model3.TestCase{Name: "isEven(number:) (arguments: 2)", ClassName: "NumberTests", Result: model3.TestResultPassed}},
+				{TestCase: model3.TestCase{Name: "isEven(number:) (arguments: 3)", ClassName: "NumberTests", Result: model3.TestResultFailed, Message: "Expectation failed"}},
+			},
+		}},
+	}}}}}
+	testCaseTags := map[string][]string{
+		"BullsEyeTests/NumberTests/isEven(number:) (arguments: 2)": {"numbers", "critical"},
+		"BullsEyeTests/NumberTests/isEven(number:) (arguments: 3)": {"numbers", "critical"},
+	}
+
+	// When
+	report := convertToJUnitReport(testSummary, testCaseTags, nil)
+
+	// Then
+	require.Len(t, report.TestSuites, 1)
+	testCases := report.TestSuites[0].TestCases
+	require.Len(t, testCases, 2)
+	require.Equal(t, "isEven(number:) (arguments: 3)", testCases[1].Name)
+	require.NotNil(t, testCases[1].Failure)
+	require.Equal(t, &testreport.Properties{Property: []testreport.Property{
+		{Name: "arguments", Value: "3"},
+		{Name: "tag", Value: "numbers"},
+		{Name: "tag", Value: "critical"},
+	}}, testCases[1].Properties)
+}
@@ -1,8 +1,13 @@
 package output
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bitrise-io/bitrise/configs"
 	"github.com/bitrise-io/go-steputils/v2/export"
@@ -10,24 +15,38 @@ import (
 	"github.com/bitrise-io/go-utils/v2/env"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-utils/ziputil"
-	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3"
 	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
 	"github.com/bitrise-steplib/steps-xcode-test/testaddon"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
 )
 
 const (
 	flakyTestCasesEnvVarKey              = "BITRISE_FLAKY_TEST_CASES"
 	flakyTestCasesEnvVarSizeLimitInBytes = 1024
+	flakyTestCasesReportEnvVarKey        = "BITRISE_FLAKY_TEST_CASES_REPORT_PATH"
+	flakyTestCasesReportFileName         = "flaky_test_cases.json"
 )
 
 // Exporter ...
 type Exporter interface {
 	ExportXCResultBundle(deployDir, xcResultPath, scheme string)
+	ExportXCResultAttempts(deployDir string, xcResultPaths []string, scheme string) error
+	ExportTestPlanResults(deployDir, xcResultPath, scheme string, testPlanResults []TestPlanResult) error
 	ExportTestRunResult(failed bool)
+	ExportTestFailureCategory(category string)
+	ExportSimulatorBootDuration(duration time.Duration)
+	ExportResolvedDestinations(destinations []string)
 	ExportXcodebuildBuildLog(deployDir, xcodebuildBuildLog string) error
 	ExportXcodebuildTestLog(deployDir, xcodebuildTestLog string) error
 	ExportSimulatorDiagnostics(deployDir, pth, name string) error
-	ExportFlakyTestCases(xcResultPath string, useOldXCResultExtractionMethod bool) error
+	ExportSimulatorVideos(deployDir string, videoPaths []string) error
+	ExportSimulatorAppLog(deployDir, appLog string) error
+	ExportFlakyTestCases(deployDir string, testData model3.TestData, testSummary model3.TestSummary) error
+	ExportQuarantinedTestResults(deployDir string, results []QuarantinedTestResult) error
+	ExportCrashReports(deployDir string, crashReportPaths []string) error
+	ExportJUnitReport(deployDir string, testData model3.TestData, testSummary model3.TestSummary, crashReports []crashreport.Report) error
+	ExportTestSummary(deployDir string, testData model3.TestData, testSummary model3.TestSummary, crashReports []crashreport.Report) error
 }
 
 type exporter struct {
@@ -57,7 +76,31 @@ func (e exporter) ExportTestRunResult(failed bool) {
 	}
 }
 
+func (e exporter) ExportTestFailureCategory(category string) {
+	if err := e.envRepository.Set("BITRISE_XCODE_TEST_FAILURE_CATEGORY", category); err != nil {
+		e.logger.Warnf("Failed to export: BITRISE_XCODE_TEST_FAILURE_CATEGORY: %s", err)
+	}
+}
+
+func (e exporter) ExportSimulatorBootDuration(duration time.Duration) {
+	seconds := strconv.Itoa(int(duration.Round(time.Second).Seconds()))
+	if err := e.envRepository.Set("BITRISE_SIMULATOR_BOOT_DURATION", seconds); err != nil {
+		e.logger.Warnf("Failed to export: BITRISE_SIMULATOR_BOOT_DURATION: %s", err)
+	}
+}
+
+func (e exporter) ExportResolvedDestinations(destinations []string) {
+	if err := e.envRepository.Set("BITRISE_XCODE_TEST_DESTINATION", strings.Join(destinations, "\n")); err != nil {
+		e.logger.Warnf("Failed to export: BITRISE_XCODE_TEST_DESTINATION: %s", err)
+	}
+}
+
 func (e exporter) ExportXCResultBundle(deployDir, xcResultPath, scheme string) {
+	e.exportXCResultPaths(deployDir, xcResultPath)
+	e.exportTestAddonResults(xcResultPath, scheme)
+}
+
+func (e exporter) exportXCResultPaths(deployDir, xcResultPath string) {
 	// export xcresult bundle
 	if err := e.envRepository.Set("BITRISE_XCRESULT_PATH", xcResultPath); err != nil {
 		e.logger.Warnf("Failed to export: BITRISE_XCRESULT_PATH: %s", err)
@@ -67,8 +110,10 @@ func (e exporter) ExportXCResultBundle(deployDir, xcResultPath, scheme string) {
 	if err := e.outputExporter.ExportOutputFilesZip("BITRISE_XCRESULT_ZIP_PATH", []string{xcResultPath}, xcresultZipPath); err != nil {
 		e.logger.Warnf("Failed to export: BITRISE_XCRESULT_ZIP_PATH: %s", err)
 	}
+}
 
-	// export xcresult for the testing addon
+// exportTestAddonResults copies the xcresult bundle for the testing addon, if the step runs on Bitrise.
+func (e exporter) exportTestAddonResults(xcResultPath, bundleName string) {
 	if addonResultPath := e.envRepository.Get(configs.BitrisePerStepTestResultDirEnvKey); len(addonResultPath) > 0 {
 		e.logger.Println()
 		e.logger.Infof("Exporting test results")
@@ -76,13 +121,22 @@ func (e exporter) ExportXCResultBundle(deployDir, xcResultPath, scheme string) {
 		if err := e.testAddonExporter.CopyAndSaveMetadata(testaddon.AddonCopy{
 			SourceTestOutputDir:   xcResultPath,
 			TargetAddonPath:       addonResultPath,
-			TargetAddonBundleName: scheme,
+			TargetAddonBundleName: bundleName,
 		}); err != nil {
 			e.logger.Warnf("Failed to export test results: %s", err)
 		}
 	}
 }
 
+func (e exporter) ExportXCResultAttempts(deployDir string, xcResultPaths []string, scheme string) error {
+	zipPath := filepath.Join(deployDir, fmt.Sprintf("Test-%s-attempts.xcresult.zip", scheme))
+	if err := e.outputExporter.ExportOutputFilesZip("BITRISE_XCRESULT_ATTEMPTS_ZIP_PATH", xcResultPaths, zipPath); err != nil {
+		return fmt.Errorf("failed to export: BITRISE_XCRESULT_ATTEMPTS_ZIP_PATH: %w", err)
+	}
+
+	return nil
+}
+
 func (e exporter) ExportXcodebuildBuildLog(deployDir, xcodebuildBuildLog string) error {
 	pth, err := saveRawOutputToLogFile(xcodebuildBuildLog)
 	if err != nil {
@@ -128,44 +182,45 @@ func (e exporter) ExportSimulatorDiagnostics(deployDir, pth, name string) error
 	return nil
 }
 
-func (e exporter) ExportFlakyTestCases(xcResultPath string, useOldXCResultExtractionMethod bool) error {
-	testSummary, err := e.parseTestSummary(xcResultPath, useOldXCResultExtractionMethod)
-	if err != nil {
-		return fmt.Errorf("failed to parse test summary: %w", err)
+func (e exporter) ExportSimulatorAppLog(deployDir, appLog string) error {
+	deployPth := filepath.Join(deployDir, "simulator_app.log")
+	if err := os.WriteFile(deployPth, []byte(appLog), 0600); err != nil {
+		return fmt.Errorf("failed to write simulator app log: %w", err)
 	}
 
-	flakyTestPlans := e.collectFlakyTestPlans(*testSummary)
-	if len(flakyTestPlans) == 0 {
-		return nil
+	if err := e.envRepository.Set("BITRISE_SIMULATOR_APP_LOG_PATH", deployPth); err != nil {
+		e.logger.Warnf("Failed to export: BITRISE_SIMULATOR_APP_LOG_PATH: %s", err)
 	}
 
-	return e.exportFlakyTestCases(flakyTestPlans)
+	return nil
 }
 
-func (e exporter) parseTestSummary(xcResultPath string, useOldXCResultExtractionMethod bool) (*model3.TestSummary, error) {
-	converter := xcresult3.NewConverter(useOldXCResultExtractionMethod)
-	if !converter.Detect([]string{xcResultPath}) {
-		return nil, nil
+func (e exporter) ExportSimulatorVideos(deployDir string, videoPaths []string) error {
+	var deployPaths []string
+	for _, pth := range videoPaths {
+		deployPth := filepath.Join(deployDir, filepath.Base(pth))
+		if pth != deployPth {
+			if err := command.CopyFile(pth, deployPth); err != nil {
+				return fmt.Errorf("failed to copy screen recording from (%s) to (%s): %w", pth, deployPth, err)
+			}
+		}
+		deployPaths = append(deployPaths, deployPth)
 	}
 
-	results, err := xcresult3.ParseTestResults(xcResultPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse xcresult: %w", err)
+	if err := e.envRepository.Set("BITRISE_SIMULATOR_VIDEO_PATH", strings.Join(deployPaths, "|")); err != nil {
+		e.logger.Warnf("Failed to export: BITRISE_SIMULATOR_VIDEO_PATH: %s", err)
 	}
 
-	testSummary, warnings, err := model3.Convert(results)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert xcresult data: %w", err)
-	}
+	return nil
+}
 
-	if len(warnings) > 0 {
-		e.logger.Warnf("xcresult converter warnings:")
-		for _, warning := range warnings {
-			e.logger.Warnf("- %s", warning)
-		}
+func (e exporter) ExportFlakyTestCases(deployDir string, testData model3.TestData, testSummary model3.TestSummary) error {
+	flakyTestPlans := e.collectFlakyTestPlans(testSummary)
+	if len(flakyTestPlans) == 0 {
+		return nil
 	}
 
-	return testSummary, nil
+	return e.exportFlakyTestCases(deployDir, flakyTestPlans, xcresult.TestCaseTags(&testData))
 }
 
 func (e exporter) collectFlakyTestPlans(testSummary model3.TestSummary) []model3.TestPlan {
@@ -222,44 +277,41 @@ func (e exporter) collectFlakyTestPlans(testSummary model3.TestSummary) []model3
 	return flakyTestPlans
 }
 
-func (e exporter) exportFlakyTestCases(flakyTestPlans []model3.TestPlan) error {
+/*
+exportFlakyTestCases writes the flaky test cases report (with the attempts of every flaky test case) into the deploy dir,
+and exports a preview of the flaky test cases in the BITRISE_FLAKY_TEST_CASES env var.
+
+The preview is limited to flakyTestCasesEnvVarSizeLimitInBytes, the test cases not fitting into it are only listed in the report.
+*/
+func (e exporter) exportFlakyTestCases(deployDir string, flakyTestPlans []model3.TestPlan, testCaseTags map[string][]string) error {
 	if len(flakyTestPlans) == 0 {
 		return nil
 	}
 
-	storedFlakyTestCases := map[string]bool{}
-	var flakyTestCases []string
-
-	for _, testPlan := range flakyTestPlans {
-		for _, testBundle := range testPlan.TestBundles {
-			for _, testSuite := range testBundle.TestSuites {
-				for _, testCase := range testSuite.TestCases {
-					testCaseName := testCase.Name
-					if len(testCase.ClassName) > 0 {
-						testCaseName = fmt.Sprintf("%s.%s", testCase.ClassName, testCase.Name)
-					}
-
-					testCaseName = testBundle.Name + "." + testCaseName
-
-					if _, stored := storedFlakyTestCases[testCaseName]; !stored {
-						storedFlakyTestCases[testCaseName] = true
-						flakyTestCases = append(flakyTestCases, testCaseName)
-					}
-				}
-			}
-		}
+	report := createFlakyTestCasesReport(flakyTestPlans, testCaseTags)
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode flaky test cases report: %w", err)
 	}
 
-	var flakyTestCasesMessage string
-	for i, flakyTestCase := range flakyTestCases {
-		flakyTestCasesMessageLine := fmt.Sprintf("- %s\n", flakyTestCase)
+	reportPth := filepath.Join(deployDir, flakyTestCasesReportFileName)
+	if err := e.outputExporter.ExportStringToFileOutput(flakyTestCasesReportEnvVarKey, string(content), reportPth); err != nil {
+		return fmt.Errorf("failed to export %s: %w", flakyTestCasesReportEnvVarKey, err)
+	}
 
-		if len(flakyTestCasesMessage)+len(flakyTestCasesMessageLine) > flakyTestCasesEnvVarSizeLimitInBytes {
-			e.logger.Warnf("%s env var size limit (%d characters) exceeded. Skipping %d test cases.", flakyTestCasesEnvVarKey, flakyTestCasesEnvVarSizeLimitInBytes, len(flakyTestCases)-i)
-			break
+	storedFlakyTestCases := map[string]bool{}
+	var flakyTestCases []string
+	for _, testCase := range report.FlakyTestCases {
+		testCaseName := testCase.name()
+		if _, stored := storedFlakyTestCases[testCaseName]; !stored {
+			storedFlakyTestCases[testCaseName] = true
+			flakyTestCases = append(flakyTestCases, testCaseName)
 		}
+	}
 
-		flakyTestCasesMessage += flakyTestCasesMessageLine
+	flakyTestCasesMessage, skipped := flakyTestCasesPreview(flakyTestCases, reportPth)
+	if skipped > 0 {
+		e.logger.Warnf("%s env var size limit (%d characters) exceeded, %d test cases are only listed in the report: %s", flakyTestCasesEnvVarKey, flakyTestCasesEnvVarSizeLimitInBytes, skipped, reportPth)
 	}
 
 	if err := e.envRepository.Set(flakyTestCasesEnvVarKey, flakyTestCasesMessage); err != nil {
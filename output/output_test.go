@@ -4,16 +4,21 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitrise-io/go-steputils/v2/export"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/env"
 	"github.com/bitrise-io/go-utils/v2/fileutil"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
 	commonMocks "github.com/bitrise-steplib/steps-xcode-test/mocks"
 	"github.com/bitrise-steplib/steps-xcode-test/output/mocks"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -21,6 +26,8 @@ import (
 
 const (
 	xcodeTestResultKey     = "BITRISE_XCODE_TEST_RESULT"
+	failureCategoryKey     = "BITRISE_XCODE_TEST_FAILURE_CATEGORY"
+	simulatorBootKey       = "BITRISE_SIMULATOR_BOOT_DURATION"
 	xcodebuildBuildLogPath = "BITRISE_XCODEBUILD_BUILD_LOG_PATH"
 	xcodebuildTestLogPath  = "BITRISE_XCODEBUILD_TEST_LOG_PATH"
 )
@@ -51,6 +58,60 @@ func Test_GivenFailedTest_WhenExportingTestRunResults_ThenSetsEnvVariableToFailu
 	mocks.envRepository.AssertCalled(t, "Set", xcodeTestResultKey, "failed")
 }
 
+func Test_GivenFailureCategory_WhenExporting_ThenSetsEnvVariable(t *testing.T) {
+	// Given
+	exporter, mocks := createSutAndMocks()
+
+	// When
+	exporter.ExportTestFailureCategory("compile_error")
+
+	// Then
+	mocks.envRepository.AssertCalled(t, "Set", failureCategoryKey, "compile_error")
+}
+
+func Test_GivenSimulatorBootDuration_WhenExporting_ThenSetsEnvVariableInSeconds(t *testing.T) {
+	// Given
+	exporter, mocks := createSutAndMocks()
+
+	// When
+	exporter.ExportSimulatorBootDuration(83*time.Second + 600*time.Millisecond)
+
+	// Then
+	mocks.envRepository.AssertCalled(t, "Set", simulatorBootKey, "84")
+}
+
+func Test_GivenSimulatorAppLog_WhenExporting_ThenWritesItAndSetsEnvVariable(t *testing.T) {
+	// Given
+	deployDir := t.TempDir()
+	logPath := filepath.Join(deployDir, "simulator_app.log")
+	exporter, mocks := createSutAndMocks()
+
+	// When
+	err := exporter.ExportSimulatorAppLog(deployDir, "BullsEye: launched")
+
+	// Then
+	require.NoError(t, err)
+	mocks.envRepository.AssertCalled(t, "Set", "BITRISE_SIMULATOR_APP_LOG_PATH", logPath)
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	require.Equal(t, "BullsEye: launched", string(content))
+}
+
+func Test_GivenSimulatorVideos_WhenExporting_ThenSetsEnvVariable(t *testing.T) {
+	// Given
+	deployDir := t.TempDir()
+	videoPaths := []string{filepath.Join(deployDir, "Test-BullsEye-1234.mp4"), filepath.Join(deployDir, "Test-BullsEye-5678.mp4")}
+
+	exporter, mocks := createSutAndMocks()
+
+	// When
+	err := exporter.ExportSimulatorVideos(deployDir, videoPaths)
+
+	// Then
+	require.NoError(t, err)
+	mocks.envRepository.AssertCalled(t, "Set", "BITRISE_SIMULATOR_VIDEO_PATH", strings.Join(videoPaths, "|"))
+}
+
 func Test_GivenBuildLog_WhenExporting_ThenCopiesItAndSetsEnvVariable(t *testing.T) {
 	// Given
 	tempDir := t.TempDir()
@@ -119,10 +180,13 @@ func Test_GivenFlakyTestCases_WhenExporting_ThenSetsEnvVariable(t *testing.T) {
 	testDataDir := filepath.Join(outputPackageDir, "testdata")
 	xcresultPath := filepath.Join(testDataDir, "xcresult3-flaky-with-rerun.xcresult")
 
+	deployDir := t.TempDir()
 	exporter, mocks := createSutAndMocks()
+	testData, testSummary, err := xcresult.NewProcessor(log.NewLogger(), command.NewFactory(env.NewRepository())).ParseTestResults(xcresultPath, false)
+	require.NoError(t, err)
 
 	// When
-	err := exporter.ExportFlakyTestCases(xcresultPath, false)
+	err = exporter.ExportFlakyTestCases(deployDir, *testData, *testSummary)
 
 	// Then
 	assert.NoError(t, err)
@@ -266,8 +330,8 @@ func Test_exporter_collectAndExportFlakyTestPlans(t *testing.T) {
 					},
 				},
 			}}},
-			wantEnvValue: "- TestBundle1.TestSuite1.TestCase1\n",
-			wantLogArgs:  []interface{}{"%s env var size limit (%d characters) exceeded. Skipping %d test cases.", "BITRISE_FLAKY_TEST_CASES", flakyTestCasesEnvVarSizeLimitInBytes, 1},
+			wantEnvValue: "- TestBundle1.TestSuite1.TestCase1\n... and 1 more, see the full report: $REPORT\n",
+			wantLogArgs:  []interface{}{"%s env var size limit (%d characters) exceeded, %d test cases are only listed in the report: %s", "BITRISE_FLAKY_TEST_CASES", flakyTestCasesEnvVarSizeLimitInBytes, 1, "$REPORT"},
 		},
 	}
 	for _, tt := range tests {
@@ -276,12 +340,15 @@ func Test_exporter_collectAndExportFlakyTestPlans(t *testing.T) {
 			envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
 
 			logger := new(mocks.Logger)
-			logger.On("Warnf", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			logger.On("Warnf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			deployDir := t.TempDir()
+			reportPath := filepath.Join(deployDir, "flaky_test_cases.json")
 
 			exporter := exporter{
 				envRepository:     envRepository,
 				logger:            logger,
-				outputExporter:    export.NewExporter(new(commonMocks.CommandFactory), new(commonMocks.FileManager)),
+				outputExporter:    export.NewExporter(createEnvmanCommandFactory(), export.NewFileManager()),
 				testAddonExporter: nil,
 			}
 
@@ -290,17 +357,21 @@ func Test_exporter_collectAndExportFlakyTestPlans(t *testing.T) {
 			}
 
 			flakyTestCases := exporter.collectFlakyTestPlans(testSummary)
-			err := exporter.exportFlakyTestCases(flakyTestCases)
+			err := exporter.exportFlakyTestCases(deployDir, flakyTestCases, nil)
 			require.NoError(t, err)
 
 			if tt.wantEnvValue != "" {
-				envRepository.AssertCalled(t, "Set", "BITRISE_FLAKY_TEST_CASES", tt.wantEnvValue)
+				envRepository.AssertCalled(t, "Set", "BITRISE_FLAKY_TEST_CASES", strings.ReplaceAll(tt.wantEnvValue, "$REPORT", reportPath))
+				require.True(t, isPathExists(reportPath))
 			} else {
 				envRepository.AssertNumberOfCalls(t, "Set", 0)
+				require.False(t, isPathExists(reportPath))
 			}
 
 			if len(tt.wantLogArgs) > 0 {
-				logger.AssertCalled(t, "Warnf", tt.wantLogArgs...)
+				wantLogArgs := slices.Clone(tt.wantLogArgs)
+				wantLogArgs[len(wantLogArgs)-1] = reportPath
+				logger.AssertCalled(t, "Warnf", wantLogArgs...)
 			} else {
 				logger.AssertNumberOfCalls(t, "Warnf", 0)
 			}
@@ -311,18 +382,31 @@ func Test_exporter_collectAndExportFlakyTestPlans(t *testing.T) {
 // Helpers
 
 func createSutAndMocks() (Exporter, testingMocks) {
-	commandFactory := new(commonMocks.CommandFactory)
+	commandFactory := createEnvmanCommandFactory()
 	fileManager := new(commonMocks.FileManager)
+	fileManager.On("WriteBytes", mock.Anything, mock.Anything).Return(nil)
 	envRepository := new(mocks.Repository)
 	envRepository.On("Set", mock.Anything, mock.Anything).Return(nil)
 
-	exporter := NewExporter(envRepository, log.NewLogger(), export.NewExporter(commandFactory, fileManager), nil)
+	logger := log.NewLogger()
+	exporter := NewExporter(envRepository, logger, export.NewExporter(commandFactory, fileManager), nil)
 
 	return exporter, testingMocks{
 		envRepository: envRepository,
 	}
 }
 
+// createEnvmanCommandFactory creates a command factory for the export.Exporter, which exports the outputs with envman.
+func createEnvmanCommandFactory() *commonMocks.CommandFactory {
+	envmanCommand := new(commonMocks.Command)
+	envmanCommand.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+
+	commandFactory := new(commonMocks.CommandFactory)
+	commandFactory.On("Create", "envman", mock.Anything, mock.Anything).Return(envmanCommand)
+
+	return commandFactory
+}
+
 func isPathExists(path string) bool {
 	isExist, _ := pathutil.NewPathChecker().IsPathExists(path)
 	return isExist
@@ -0,0 +1,110 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	quarantinedTestsReportEnvVarKey  = "BITRISE_QUARANTINED_TESTS_REPORT_PATH"
+	quarantinedTestsReportFileName   = "quarantined_tests.json"
+	passingQuarantinedTestsEnvVarKey = "BITRISE_PASSING_QUARANTINED_TESTS"
+)
+
+// QuarantinedTestStatus is the outcome of a quarantined test in the quarantined tests run.
+type QuarantinedTestStatus string
+
+// QuarantinedTestStatus values
+const (
+	// QuarantinedTestPassing means that every run of the quarantined test passed, the test can be unquarantined.
+	QuarantinedTestPassing QuarantinedTestStatus = "passing"
+	QuarantinedTestFailing QuarantinedTestStatus = "failing"
+	QuarantinedTestNotRun  QuarantinedTestStatus = "not_run"
+)
+
+// QuarantinedTestResult is the result of a quarantined test in the quarantined tests run.
+type QuarantinedTestResult struct {
+	// Test is the quarantined test identifier: <TestTarget>[/<TestClass>[/<TestMethod>]].
+	Test     string                `json:"test"`
+	TestPlan string                `json:"test_plan,omitempty"`
+	Status   QuarantinedTestStatus `json:"status"`
+	// TestCases is the number of test cases of the quarantined test (more than one for a test target or class).
+	TestCases int `json:"test_cases"`
+	Runs      int `json:"runs"`
+	Failures  int `json:"failures"`
+}
+
+// String formats the result for the Step log, for example: `BullsEyeTests/BullsEyeTests: passing (3 test cases, 9 runs)`.
+func (r QuarantinedTestResult) String() string {
+	var details []string
+	if r.TestCases > 1 {
+		details = append(details, fmt.Sprintf("%d test cases", r.TestCases))
+	}
+	if r.Runs > 0 {
+		details = append(details, fmt.Sprintf("%d runs", r.Runs))
+	}
+	if r.Failures > 0 {
+		details = append(details, fmt.Sprintf("%d failures", r.Failures))
+	}
+
+	s := fmt.Sprintf("%s: %s", r.Test, r.Status)
+	if len(details) > 0 {
+		s += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+	}
+	return s
+}
+
+type quarantinedTestsReport struct {
+	QuarantinedTests []QuarantinedTestResult `json:"quarantined_tests"`
+}
+
+/*
+ExportQuarantinedTestResults exports the results of the quarantined tests run as a JSON report, and lists the quarantined
+tests passing in every test plan (one `- <test>` per line) in the BITRISE_PASSING_QUARANTINED_TESTS env var.
+*/
+func (e exporter) ExportQuarantinedTestResults(deployDir string, results []QuarantinedTestResult) error {
+	content, err := json.MarshalIndent(quarantinedTestsReport{QuarantinedTests: results}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantined tests report: %w", err)
+	}
+
+	reportPth := filepath.Join(deployDir, quarantinedTestsReportFileName)
+	if err := e.outputExporter.ExportStringToFileOutput(quarantinedTestsReportEnvVarKey, string(content), reportPth); err != nil {
+		return fmt.Errorf("failed to export %s: %w", quarantinedTestsReportEnvVarKey, err)
+	}
+
+	passingTests := passingQuarantinedTests(results)
+	// The passing tests are listed the same way as the flaky test cases, up to the same env var size limit.
+	preview, skipped := flakyTestCasesPreview(passingTests, reportPth)
+	if skipped > 0 {
+		e.logger.Warnf("%s env var size limit (%d characters) exceeded, %d tests are only listed in the report: %s", passingQuarantinedTestsEnvVarKey, flakyTestCasesEnvVarSizeLimitInBytes, skipped, reportPth)
+	}
+
+	if err := e.envRepository.Set(passingQuarantinedTestsEnvVarKey, preview); err != nil {
+		return fmt.Errorf("failed to export %s: %w", passingQuarantinedTestsEnvVarKey, err)
+	}
+
+	return nil
+}
+
+// passingQuarantinedTests returns the quarantined tests which passed in every test plan they ran in.
+func passingQuarantinedTests(results []QuarantinedTestResult) []string {
+	var passing, failing []string
+	for _, result := range results {
+		switch result.Status {
+		case QuarantinedTestPassing:
+			if !slices.Contains(passing, result.Test) {
+				passing = append(passing, result.Test)
+			}
+		case QuarantinedTestFailing:
+			failing = append(failing, result.Test)
+		}
+	}
+
+	return slices.DeleteFunc(passing, func(test string) bool {
+		return slices.Contains(failing, test)
+	})
+}
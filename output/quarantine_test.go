@@ -0,0 +1,31 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenQuarantinedTestResults_WhenExporting_ThenListsThePassingTests(t *testing.T) {
+	// Given
+	exporter, mocks := createSutAndMocks()
+	results := []QuarantinedTestResult{
+		{Test: "BullsEyeTests/BullsEyeTests", TestPlan: "UnitTests", Status: QuarantinedTestPassing, TestCases: 3, Runs: 9},
+		{Test: "BullsEyeUITests/BullsEyeUITests/testSlider", TestPlan: "UnitTests", Status: QuarantinedTestFailing, Runs: 2, Failures: 1},
+		{Test: "BullsEyeUITests/BullsEyeUITests/testSlider", TestPlan: "UITests", Status: QuarantinedTestPassing, Runs: 3},
+		{Test: "BullsEyeSlowTests", TestPlan: "UnitTests", Status: QuarantinedTestNotRun},
+	}
+
+	// When
+	err := exporter.ExportQuarantinedTestResults(t.TempDir(), results)
+
+	// Then
+	require.NoError(t, err)
+	mocks.envRepository.AssertCalled(t, "Set", "BITRISE_PASSING_QUARANTINED_TESTS", "- BullsEyeTests/BullsEyeTests\n")
+}
+
+func TestQuarantinedTestResult_String(t *testing.T) {
+	require.Equal(t, "BullsEyeTests/BullsEyeTests: passing (3 test cases, 9 runs)", QuarantinedTestResult{Test: "BullsEyeTests/BullsEyeTests", Status: QuarantinedTestPassing, TestCases: 3, Runs: 9}.String())
+	require.Equal(t, "BullsEyeTests/BullsEyeTests/testSlider: failing (2 runs, 1 failures)", QuarantinedTestResult{Test: "BullsEyeTests/BullsEyeTests/testSlider", Status: QuarantinedTestFailing, TestCases: 1, Runs: 2, Failures: 1}.String())
+	require.Equal(t, "BullsEyeSlowTests: not_run", QuarantinedTestResult{Test: "BullsEyeSlowTests", Status: QuarantinedTestNotRun}.String())
+}
@@ -0,0 +1,288 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
+)
+
+const (
+	testSummaryEnvVarKey   = "BITRISE_XCODE_TEST_SUMMARY_PATH"
+	testSummaryFileName    = "test_summary.json"
+	totalTestCountEnvKey   = "BITRISE_XCODE_TEST_TOTAL_COUNT"
+	passedTestCountEnvKey  = "BITRISE_XCODE_TEST_PASSED_COUNT"
+	failedTestCountEnvKey  = "BITRISE_XCODE_TEST_FAILED_COUNT"
+	skippedTestCountEnvKey = "BITRISE_XCODE_TEST_SKIPPED_COUNT"
+	deviceResultsEnvKey    = "BITRISE_XCODE_TEST_DEVICE_RESULTS"
+)
+
+type testCounts struct {
+	Total           int     `json:"total"`
+	Passed          int     `json:"passed"`
+	Failed          int     `json:"failed"`
+	Skipped         int     `json:"skipped"`
+	ExpectedFailure int     `json:"expected_failure"`
+	Duration        float64 `json:"duration"`
+}
+
+func (c *testCounts) add(other testCounts) {
+	c.Total += other.Total
+	c.Passed += other.Passed
+	c.Failed += other.Failed
+	c.Skipped += other.Skipped
+	c.ExpectedFailure += other.ExpectedFailure
+	c.Duration += other.Duration
+}
+
+type testSummaryDevice struct {
+	Identifier   string           `json:"identifier"`
+	Name         string           `json:"name"`
+	ModelName    string           `json:"model_name"`
+	Platform     string           `json:"platform"`
+	OS           string           `json:"os_version"`
+	Architecture string           `json:"architecture"`
+	Totals       deviceTestCounts `json:"totals"`
+}
+
+// deviceTestCounts are the test case results of a single destination device.
+type deviceTestCounts struct {
+	Total           int `json:"total"`
+	Passed          int `json:"passed"`
+	Failed          int `json:"failed"`
+	Skipped         int `json:"skipped"`
+	ExpectedFailure int `json:"expected_failure"`
+}
+
+func (c *deviceTestCounts) add(result model3.TestResult) {
+	c.Total++
+	switch result {
+	case model3.TestResultPassed:
+		c.Passed++
+	case model3.TestResultFailed:
+		c.Failed++
+	case model3.TestResultSkipped:
+		c.Skipped++
+	case model3.TestResultExpectedFailure:
+		c.ExpectedFailure++
+	}
+}
+
+type testCaseSummary struct {
+	Name      string `json:"name"`
+	ClassName string `json:"class_name"`
+	// Arguments are the arguments of a parameterized Swift Testing test case, every argument combination has its own result.
+	Arguments      string   `json:"arguments,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Result         string   `json:"result"`
+	Duration       float64  `json:"duration"`
+	FailureMessage string   `json:"failure_message,omitempty"`
+	RetryCount     int      `json:"retry_count,omitempty"`
+
+	CrashReports []crashReportSummary `json:"crash_reports,omitempty"`
+}
+
+type testSuiteSummary struct {
+	Name      string            `json:"name"`
+	Totals    testCounts        `json:"totals"`
+	TestCases []testCaseSummary `json:"test_cases"`
+}
+
+type testBundleSummary struct {
+	Name       string             `json:"name"`
+	TestPlan   string             `json:"test_plan"`
+	Totals     testCounts         `json:"totals"`
+	TestSuites []testSuiteSummary `json:"test_suites"`
+}
+
+type testSummaryReport struct {
+	Totals      testCounts          `json:"totals"`
+	Devices     []testSummaryDevice `json:"devices"`
+	TestBundles []testBundleSummary `json:"test_bundles"`
+}
+
+func (e exporter) ExportTestSummary(deployDir string, testData model3.TestData, testSummary model3.TestSummary, crashReports []crashreport.Report) error {
+	report := createTestSummaryReport(testData, testSummary, crashReports)
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode test summary: %w", err)
+	}
+
+	summaryPth := filepath.Join(deployDir, testSummaryFileName)
+	if err := os.WriteFile(summaryPth, content, 0600); err != nil {
+		return fmt.Errorf("failed to write test summary: %w", err)
+	}
+
+	envs := map[string]string{
+		testSummaryEnvVarKey:   summaryPth,
+		totalTestCountEnvKey:   strconv.Itoa(report.Totals.Total),
+		passedTestCountEnvKey:  strconv.Itoa(report.Totals.Passed),
+		failedTestCountEnvKey:  strconv.Itoa(report.Totals.Failed),
+		skippedTestCountEnvKey: strconv.Itoa(report.Totals.Skipped),
+	}
+	if len(report.Devices) > 0 {
+		envs[deviceResultsEnvKey] = deviceResultsValue(report.Devices)
+	}
+	for key, value := range envs {
+		if err := e.envRepository.Set(key, value); err != nil {
+			e.logger.Warnf("Failed to export: %s: %s", key, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+deviceResultsValue lists the test results of the destination devices, one
+`<device> (<platform> <OS version>): <passed> passed, <failed> failed, <skipped> skipped` per line.
+*/
+func deviceResultsValue(devices []testSummaryDevice) string {
+	var lines []string
+	for _, device := range devices {
+		lines = append(lines, fmt.Sprintf("%s (%s %s): %d passed, %d failed, %d skipped",
+			device.Name, device.Platform, device.OS, device.Totals.Passed, device.Totals.Failed, device.Totals.Skipped))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func createTestSummaryReport(testData model3.TestData, testSummary model3.TestSummary, crashReports []crashreport.Report) testSummaryReport {
+	report := testSummaryReport{
+		Devices:     []testSummaryDevice{},
+		TestBundles: []testBundleSummary{},
+	}
+
+	deviceCounts := make([]deviceTestCounts, len(testData.Devices))
+	countDeviceTestResults(testData.TestNodes, testData.Devices, deviceCounts)
+
+	for i, device := range testData.Devices {
+		report.Devices = append(report.Devices, testSummaryDevice{
+			Identifier:   device.Identifier,
+			Name:         device.Name,
+			ModelName:    device.ModelName,
+			Platform:     device.Platform,
+			OS:           device.OS,
+			Architecture: device.Architecture,
+			Totals:       deviceCounts[i],
+		})
+	}
+
+	testCaseTags := xcresult.TestCaseTags(&testData)
+	for _, testPlan := range testSummary.TestPlans {
+		for _, testBundle := range testPlan.TestBundles {
+			bundleSummary := testBundleSummary{
+				Name:       testBundle.Name,
+				TestPlan:   testPlan.Name,
+				TestSuites: []testSuiteSummary{},
+			}
+
+			for _, testSuite := range testBundle.TestSuites {
+				suiteSummary := testSuiteSummary{
+					Name:      testSuite.Name,
+					TestCases: []testCaseSummary{},
+				}
+
+				for _, testCase := range testSuite.TestCases {
+					tags := testCaseTags[xcresult.TestCaseKey(testBundle.Name, testCase.ClassName, testCase.Name)]
+					suiteSummary.TestCases = append(suiteSummary.TestCases, createTestCaseSummary(testCase, tags, testCaseCrashReports(testCase, crashReports)))
+					suiteSummary.Totals.add(countTestCase(testCase))
+				}
+
+				bundleSummary.Totals.add(suiteSummary.Totals)
+				bundleSummary.TestSuites = append(bundleSummary.TestSuites, suiteSummary)
+			}
+
+			report.Totals.add(bundleSummary.Totals)
+			report.TestBundles = append(report.TestBundles, bundleSummary)
+		}
+	}
+
+	return report
+}
+
+/*
+countDeviceTestResults counts the test case results per destination device.
+
+If the tests ran on multiple devices, the test case nodes have a Device child node per device, holding the
+result of the test case on the given device. If the tests ran on a single device, the test case result belongs to that device.
+*/
+func countDeviceTestResults(nodes []model3.TestNode, devices []model3.Devices, counts []deviceTestCounts) {
+	for _, node := range nodes {
+		if node.Type != model3.TestNodeTypeTestCase {
+			countDeviceTestResults(node.Children, devices, counts)
+			continue
+		}
+
+		hasDeviceNode := false
+		for _, child := range node.Children {
+			if child.Type != model3.TestNodeTypeDevice {
+				continue
+			}
+
+			hasDeviceNode = true
+			for i, device := range devices {
+				if child.Identifier == device.Identifier || child.Name == device.Name {
+					counts[i].add(child.Result)
+					break
+				}
+			}
+		}
+
+		if !hasDeviceNode && len(devices) == 1 {
+			counts[0].add(node.Result)
+		}
+	}
+}
+
+func createTestCaseSummary(testCase model3.TestCaseWithRetries, tags []string, crashReports []crashreport.Report) testCaseSummary {
+	name, arguments := xcresult.SplitTestCaseName(testCase.Name)
+	summary := testCaseSummary{
+		Name:      name,
+		ClassName: testCase.ClassName,
+		Arguments: arguments,
+		Tags:      tags,
+		Result:    string(testCase.Result),
+		Duration:  testCase.Time.Seconds(),
+	}
+
+	if testCase.Result == model3.TestResultFailed {
+		summary.FailureMessage = testCase.Message
+	}
+
+	// The retries list contains every repetition of the test case, including the first run.
+	if len(testCase.Retries) > 1 {
+		summary.RetryCount = len(testCase.Retries) - 1
+	}
+
+	for _, report := range crashReports {
+		summary.CrashReports = append(summary.CrashReports, createCrashReportSummary(report))
+	}
+
+	return summary
+}
+
+func countTestCase(testCase model3.TestCaseWithRetries) testCounts {
+	counts := testCounts{
+		Total:    1,
+		Duration: testCase.Time.Seconds(),
+	}
+
+	switch testCase.Result {
+	case model3.TestResultPassed:
+		counts.Passed = 1
+	case model3.TestResultFailed:
+		counts.Failed = 1
+	case model3.TestResultSkipped:
+		counts.Skipped = 1
+	case model3.TestResultExpectedFailure:
+		counts.ExpectedFailure = 1
+	}
+
+	return counts
+}
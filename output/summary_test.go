@@ -0,0 +1,149 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_createTestSummaryReport(t *testing.T) {
+	testData := model3.TestData{
+		Devices: []model3.Devices{
+			{Identifier: "E8C36A8B", Name: "iPhone 15", ModelName: "iPhone 15", Platform: "iOS Simulator", OS: "17.5", Architecture: "arm64"},
+		},
+	}
+	testSummary := model3.TestSummary{TestPlans: []model3.TestPlan{{Name: "UnitTests", TestBundles: []model3.TestBundle{
+		{
+			Name: "BullsEyeTests",
+			TestSuites: []model3.TestSuite{
+				{
+					Name: "BullsEyeTests",
+					TestCases: []model3.TestCaseWithRetries{
+						{TestCase: model3.TestCase{Name: "testPassed()", ClassName: "BullsEyeTests", Time: time.Second, Result: model3.TestResultPassed}},
+						{TestCase: model3.TestCase{Name: "testFailed()", ClassName: "BullsEyeTests", Time: 2 * time.Second, Result: model3.TestResultFailed, Message: "XCTAssertEqual failed"}},
+					},
+				},
+				{
+					Name: "BullsEyeSlowTests",
+					TestCases: []model3.TestCaseWithRetries{
+						{TestCase: model3.TestCase{Name: "testSkipped()", ClassName: "BullsEyeSlowTests", Result: model3.TestResultSkipped}},
+						{TestCase: model3.TestCase{Name: "testKnownIssue()", ClassName: "BullsEyeSlowTests", Result: model3.TestResultExpectedFailure}},
+						{
+							TestCase: model3.TestCase{Name: "testFlaky()", ClassName: "BullsEyeSlowTests", Time: 3 * time.Second, Result: model3.TestResultPassed},
+							Retries: []model3.TestCase{
+								{Name: "testFlaky()", ClassName: "BullsEyeSlowTests", Result: model3.TestResultFailed},
+								{Name: "testFlaky()", ClassName: "BullsEyeSlowTests", Result: model3.TestResultPassed},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}}}
+
+	report := createTestSummaryReport(testData, testSummary, nil)
+
+	require.Equal(t, testCounts{Total: 5, Passed: 2, Failed: 1, Skipped: 1, ExpectedFailure: 1, Duration: 6}, report.Totals)
+	require.Equal(t, []testSummaryDevice{
+		{Identifier: "E8C36A8B", Name: "iPhone 15", ModelName: "iPhone 15", Platform: "iOS Simulator", OS: "17.5", Architecture: "arm64"},
+	}, report.Devices)
+
+	require.Len(t, report.TestBundles, 1)
+	bundle := report.TestBundles[0]
+	require.Equal(t, "BullsEyeTests", bundle.Name)
+	require.Equal(t, "UnitTests", bundle.TestPlan)
+	require.Equal(t, report.Totals, bundle.Totals)
+
+	require.Len(t, bundle.TestSuites, 2)
+	require.Equal(t, testCounts{Total: 2, Passed: 1, Failed: 1, Duration: 3}, bundle.TestSuites[0].Totals)
+	require.Equal(t, testCaseSummary{
+		Name:           "testFailed()",
+		ClassName:      "BullsEyeTests",
+		Result:         "Failed",
+		Duration:       2,
+		FailureMessage: "XCTAssertEqual failed",
+	}, bundle.TestSuites[0].TestCases[1])
+	require.Equal(t, testCaseSummary{
+		Name:       "testFlaky()",
+		ClassName:  "BullsEyeSlowTests",
+		Result:     "Passed",
+		Duration:   3,
+		RetryCount: 1,
+	}, bundle.TestSuites[1].TestCases[2])
+}
+
+func Test_createTestSummaryReport_WhenMultipleDevices_ThenCountsResultsPerDevice(t *testing.T) {
+	testData := model3.TestData{
+		Devices: []model3.Devices{
+			{Identifier: "E8C36A8B", Name: "iPhone 15"},
+			{Identifier: "3F1C2D4E", Name: "iPad Air"},
+		},
+		TestNodes: []model3.TestNode{{Type: model3.TestNodeTypeTestPlan, Children: []model3.TestNode{{
+			Type: model3.TestNodeTypeUnitTestBundle,
+			Children: []model3.TestNode{{Type: model3.TestNodeTypeTestSuite, Children: []model3.TestNode{
+				{Type: model3.TestNodeTypeTestCase, Name: "testA()", Result: model3.TestResultFailed, Children: []model3.TestNode{
+					{Type: model3.TestNodeTypeDevice, Identifier: "E8C36A8B", Name: "iPhone 15", Result: model3.TestResultPassed},
+					{Type: model3.TestNodeTypeDevice, Identifier: "3F1C2D4E", Name: "iPad Air", Result: model3.TestResultFailed},
+				}},
+				{Type: model3.TestNodeTypeTestCase, Name: "testB()", Result: model3.TestResultPassed, Children: []model3.TestNode{
+					{Type: model3.TestNodeTypeDevice, Identifier: "E8C36A8B", Name: "iPhone 15", Result: model3.TestResultPassed},
+					{Type: model3.TestNodeTypeDevice, Identifier: "3F1C2D4E", Name: "iPad Air", Result: model3.TestResultPassed},
+				}},
+			}}},
+		}}}},
+	}
+
+	report := createTestSummaryReport(testData, model3.TestSummary{}, nil)
+
+	require.Equal(t, deviceTestCounts{Total: 2, Passed: 2}, report.Devices[0].Totals)
+	require.Equal(t, deviceTestCounts{Total: 2, Passed: 1, Failed: 1}, report.Devices[1].Totals)
+}
+
+func Test_deviceResultsValue(t *testing.T) {
+	value := deviceResultsValue([]testSummaryDevice{
+		{Name: "iPhone 15", Platform: "iOS Simulator", OS: "17.5", Totals: deviceTestCounts{Total: 2, Passed: 2}},
+		{Name: "iPad Air", Platform: "iOS Simulator", OS: "17.5", Totals: deviceTestCounts{Total: 3, Passed: 1, Failed: 1, Skipped: 1}},
+	})
+
+	require.Equal(t, "iPhone 15 (iOS Simulator 17.5): 2 passed, 0 failed, 0 skipped\niPad Air (iOS Simulator 17.5): 1 passed, 1 failed, 1 skipped", value)
+}
+
+func Test_createTestSummaryReport_WhenSwiftTestingTestCases_ThenReportsArgumentsAndTags(t *testing.T) {
+	testData := model3.TestData{TestNodes: []model3.TestNode{{
+		Type: model3.TestNodeTypeTestPlan,
+		Name: "UnitTests",
+		Children: []model3.TestNode{{
+			Type: model3.TestNodeTypeUnitTestBundle,
+			Name: "BullsEyeTests",
+			Children: []model3.TestNode{{
+				Type: model3.TestNodeTypeTestSuite,
+				Name: "NumberTests",
+				Tags: []string{"numbers"},
+				Children: []model3.TestNode{
+					{Identifier: "NumberTests/isEven(number:)", Type: model3.TestNodeTypeTestCase, Name: "isEven(number:) (arguments: 2)", Tags: []string{"critical"}},
+					{Identifier: "NumberTests/isEven(number:)", Type: model3.TestNodeTypeTestCase, Name: "isEven(number:) (arguments: 3)", Tags: []string{"critical"}},
+				},
+			}},
+		}},
+	}}}
+	testSummary := model3.TestSummary{TestPlans: []model3.TestPlan{{Name: "UnitTests", TestBundles: []model3.TestBundle{{
+		Name: "BullsEyeTests",
+		TestSuites: []model3.TestSuite{{
+			Name: "NumberTests",
+			TestCases: []model3.TestCaseWithRetries{
+				{TestCase: model3.TestCase{Name: "isEven(number:) (arguments: 2)", ClassName: "NumberTests", Time: time.Second, Result: model3.TestResultPassed}},
+				{TestCase: model3.TestCase{Name: "isEven(number:) (arguments: 3)", ClassName: "NumberTests", Time: time.Second, Result: model3.TestResultFailed, Message: "Expectation failed"}},
+			},
+		}},
+	}}}}}
+
+	report := createTestSummaryReport(testData, testSummary, nil)
+
+	require.Equal(t, testCounts{Total: 2, Passed: 1, Failed: 1, Duration: 2}, report.Totals)
+	require.Equal(t, []testCaseSummary{
+		{Name: "isEven(number:)", ClassName: "NumberTests", Arguments: "2", Tags: []string{"numbers", "critical"}, Result: "Passed", Duration: 1},
+		{Name: "isEven(number:)", ClassName: "NumberTests", Arguments: "3", Tags: []string{"numbers", "critical"}, Result: "Failed", Duration: 1, FailureMessage: "Expectation failed"},
+	}, report.TestBundles[0].TestSuites[0].TestCases)
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	testPlanResultsEnvVarKey   = "BITRISE_XCODE_TEST_PLAN_RESULTS"
+	testPlanXcresultsEnvVarKey = "BITRISE_XCRESULT_TEST_PLANS_ZIP_PATH"
+)
+
+// TestPlanResult is the result of a test plan, if multiple test plans ran.
+type TestPlanResult struct {
+	TestPlan     string
+	XcresultPath string
+	Failed       bool
+}
+
+/*
+ExportTestPlanResults exports the results of multiple test plans: the merged result bundle of the test plans is exported
+as the xcresult outputs (if the result bundles could be merged), the result bundles of the test plans are zipped together,
+and every test plan gets its own test result bundle in the testing addon (named <scheme>-<test plan>).
+*/
+func (e exporter) ExportTestPlanResults(deployDir, xcResultPath, scheme string, testPlanResults []TestPlanResult) error {
+	if xcResultPath != "" {
+		e.exportXCResultPaths(deployDir, xcResultPath)
+	}
+
+	if err := e.envRepository.Set(testPlanResultsEnvVarKey, testPlanResultsValue(testPlanResults)); err != nil {
+		e.logger.Warnf("Failed to export: %s: %s", testPlanResultsEnvVarKey, err)
+	}
+
+	var xcResultPaths []string
+	for _, result := range testPlanResults {
+		xcResultPaths = append(xcResultPaths, result.XcresultPath)
+		e.exportTestAddonResults(result.XcresultPath, fmt.Sprintf("%s-%s", scheme, result.TestPlan))
+	}
+
+	zipPath := filepath.Join(deployDir, fmt.Sprintf("Test-%s-test-plans.xcresult.zip", scheme))
+	if err := e.outputExporter.ExportOutputFilesZip(testPlanXcresultsEnvVarKey, xcResultPaths, zipPath); err != nil {
+		return fmt.Errorf("failed to export: %s: %w", testPlanXcresultsEnvVarKey, err)
+	}
+
+	return nil
+}
+
+// testPlanResultsValue lists the test plans with their results, one `<test plan>: succeeded|failed` per line.
+func testPlanResultsValue(testPlanResults []TestPlanResult) string {
+	var lines []string
+	for _, result := range testPlanResults {
+		status := "succeeded"
+		if result.Failed {
+			status = "failed"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", result.TestPlan, status))
+	}
+	return strings.Join(lines, "\n")
+}
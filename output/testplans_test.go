@@ -0,0 +1,16 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_testPlanResultsValue(t *testing.T) {
+	value := testPlanResultsValue([]TestPlanResult{
+		{TestPlan: "UnitTests", XcresultPath: "/tmp/Test-BullsEye-UnitTests.xcresult"},
+		{TestPlan: "UITests", XcresultPath: "/tmp/Test-BullsEye-UITests.xcresult", Failed: true},
+	})
+
+	require.Equal(t, "UnitTests: succeeded\nUITests: failed", value)
+}
@@ -0,0 +1,71 @@
+package simulator
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+// LogStream is a Simulator system log stream in progress.
+type LogStream interface {
+	// Stop stops the log stream and waits until the log file is written.
+	Stop() error
+}
+
+// logStreamProcess closes the log file after the log stream process exited.
+type logStreamProcess struct {
+	backgroundProcess
+	file *os.File
+}
+
+func (p logStreamProcess) Stop() error {
+	stopErr := p.backgroundProcess.Stop()
+	if err := p.file.Close(); err != nil && stopErr == nil {
+		return fmt.Errorf("failed to close the log file: %w", err)
+	}
+
+	return stopErr
+}
+
+// StartLogStream streams the system log entries of the booted Simulator matching the predicate into the given file
+// (`simctl spawn log stream`).
+func (m manager) StartLogStream(id, predicate, outputPath string) (LogStream, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+
+	args := []string{"simctl", "spawn", id, "log", "stream", "--level", "debug", "--style", "compact", "--predicate", predicate}
+	m.logger.TPrintf("$ %s", command.PrintableCommandArgs(false, append([]string{"xcrun"}, args...)))
+
+	cmd := exec.Command("xcrun", args...)
+	cmd.Stdout = file
+	process, err := startBackgroundProcess(fmt.Sprintf("log stream of Simulator (%s)", id), cmd)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return logStreamProcess{backgroundProcess: process, file: file}, nil
+}
+
+/*
+LogPredicate returns a `log stream` predicate matching the log entries of the given processes or subsystems, for example:
+
+	process == "BullsEye" OR subsystem == "io.bitrise.BullsEye"
+*/
+func LogPredicate(processes, subsystems []string) string {
+	var conditions []string
+	for _, process := range processes {
+		conditions = append(conditions, "process == "+strconv.Quote(process))
+	}
+	for _, subsystem := range subsystems {
+		conditions = append(conditions, "subsystem == "+strconv.Quote(subsystem))
+	}
+
+	return strings.Join(conditions, " OR ")
+}
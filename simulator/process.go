@@ -0,0 +1,97 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// backgroundProcessStopTimeout is the time a background process gets to finish its output after it was interrupted.
+const backgroundProcessStopTimeout = 30 * time.Second
+
+// backgroundProcess is a simctl process running in the background until it is stopped with an interrupt signal
+// (the same as pressing Ctrl+C), which is not supported by command.Command.
+type backgroundProcess struct {
+	description string
+	cmd         *exec.Cmd
+	stderr      *bytes.Buffer
+}
+
+func startBackgroundProcess(description string, cmd *exec.Cmd) (backgroundProcess, error) {
+	var stderr bytes.Buffer
+	if cmd.Stdout == nil {
+		cmd.Stdout = &stderr
+	}
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return backgroundProcess{}, fmt.Errorf("failed to start the %s: %w", description, err)
+	}
+
+	return backgroundProcess{description: description, cmd: cmd, stderr: &stderr}, nil
+}
+
+// Stop interrupts the process and waits until it exits.
+func (p backgroundProcess) Stop() error {
+	if err := p.cmd.Process.Signal(os.Interrupt); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to stop the %s: %w", p.description, err)
+	}
+
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- p.cmd.Wait()
+	}()
+
+	timer := time.NewTimer(backgroundProcessStopTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-doneCh:
+		if err != nil && !isInterruptExit(err) {
+			return fmt.Errorf("%s failed: %s", p.description, strings.TrimSpace(p.stderr.String()))
+		}
+		return nil
+	case <-timer.C:
+		if err := p.cmd.Process.Kill(); err != nil {
+			return fmt.Errorf("failed to kill the %s: %w", p.description, err)
+		}
+		return fmt.Errorf("%s did not stop in %s", p.description, backgroundProcessStopTimeout)
+	}
+}
+
+// errProcessTimeout is returned by runProcess if the process did not finish in time.
+var errProcessTimeout = errors.New("process timed out")
+
+// runProcess runs the command and returns its trimmed combined output, the process is killed if it does not
+// finish in the given time.
+func runProcess(timeout time.Duration, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	// Child processes may keep the output pipe open after the process was killed.
+	cmd.WaitDelay = time.Second
+
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return strings.TrimSpace(string(out)), errProcessTimeout
+	}
+
+	return strings.TrimSpace(string(out)), err
+}
+
+// isInterruptExit returns true if the process exited because of the interrupt signal.
+func isInterruptExit(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+
+	// 130 is the conventional exit code of processes interrupted with Ctrl+C.
+	return exitErr.ExitCode() == 130 || strings.Contains(exitErr.String(), "signal: interrupt")
+}
@@ -0,0 +1,20 @@
+package simulator
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundProcess_Stop(t *testing.T) {
+	process, err := startBackgroundProcess("sleep", exec.Command("sleep", "60"))
+	require.NoError(t, err)
+
+	require.NoError(t, process.Stop())
+}
+
+func TestLogPredicate(t *testing.T) {
+	require.Equal(t, `process == "BullsEye" OR process == "BullsEyeUITests-Runner" OR subsystem == "io.bitrise.BullsEye"`,
+		LogPredicate([]string{"BullsEye", "BullsEyeUITests-Runner"}, []string{"io.bitrise.BullsEye"}))
+}
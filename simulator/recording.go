@@ -0,0 +1,27 @@
+package simulator
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+// VideoRecording is a Simulator screen recording in progress.
+type VideoRecording interface {
+	// Stop stops the recording and waits until the video file is written.
+	Stop() error
+}
+
+// StartVideoRecording starts recording the screen of the booted Simulator to the given .mp4 file (`simctl io recordVideo`).
+func (m manager) StartVideoRecording(id, outputPath string) (VideoRecording, error) {
+	args := []string{"simctl", "io", id, "recordVideo", "--codec=h264", "--force", outputPath}
+	m.logger.TPrintf("$ %s", command.PrintableCommandArgs(false, append([]string{"xcrun"}, args...)))
+
+	process, err := startBackgroundProcess(fmt.Sprintf("screen recording of Simulator (%s)", id), exec.Command("xcrun", args...))
+	if err != nil {
+		return nil, err
+	}
+
+	return process, nil
+}
@@ -0,0 +1,145 @@
+package simulator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PrivacyAction is the `simctl privacy` action applied to a privacy service.
+type PrivacyAction string
+
+// PrivacyAction values
+const (
+	PrivacyActionGrant  PrivacyAction = "grant"
+	PrivacyActionRevoke PrivacyAction = "revoke"
+	PrivacyActionReset  PrivacyAction = "reset"
+)
+
+// privacyServices are the services supported by `simctl privacy`.
+var privacyServices = []string{
+	"all", "calendar", "contacts-limited", "contacts", "location", "location-always",
+	"photos-add", "photos", "media-library", "microphone", "motion", "reminders", "siri",
+}
+
+// Setup is the declarative setup of a Simulator, applied after the Simulator booted.
+type Setup struct {
+	Privacy          []PrivacySetting   `yaml:"privacy"`
+	StatusBar        *StatusBarOverride `yaml:"status_bar"`
+	Media            []string           `yaml:"media"`
+	RootCertificates []string           `yaml:"root_certificates"`
+	Language         string             `yaml:"language"`
+	Locale           string             `yaml:"locale"`
+	Location         *Location          `yaml:"location"`
+}
+
+// PrivacySetting grants, revokes or resets the access of an app to a privacy service.
+type PrivacySetting struct {
+	Service  string        `yaml:"service"`
+	BundleID string        `yaml:"bundle_id"`
+	Action   PrivacyAction `yaml:"action"`
+}
+
+// StatusBarOverride holds the `simctl status_bar override` options, empty options are not overridden.
+type StatusBarOverride struct {
+	Time         string `yaml:"time"`
+	DataNetwork  string `yaml:"data_network"`
+	WifiMode     string `yaml:"wifi_mode"`
+	WifiBars     string `yaml:"wifi_bars"`
+	CellularMode string `yaml:"cellular_mode"`
+	CellularBars string `yaml:"cellular_bars"`
+	OperatorName string `yaml:"operator_name"`
+	BatteryState string `yaml:"battery_state"`
+	BatteryLevel string `yaml:"battery_level"`
+}
+
+// Location is the simulated location of the Simulator.
+type Location struct {
+	Latitude  float64 `yaml:"latitude"`
+	Longitude float64 `yaml:"longitude"`
+}
+
+/*
+ParseSetup parses a YAML (or JSON) Simulator setup, for example:
+
+	privacy:
+	  - service: location
+	    bundle_id: io.bitrise.BullsEye
+	status_bar:
+	  time: "9:41"
+	  battery_level: 100
+	media:
+	  - ./fixtures/photo.jpg
+	root_certificates:
+	  - ./fixtures/proxy.pem
+	language: de
+	locale: de_DE
+	location:
+	  latitude: 47.4979
+	  longitude: 19.0402
+
+The privacy action defaults to `grant` if not set.
+*/
+func ParseSetup(content []byte) (Setup, error) {
+	var setup Setup
+	if err := yaml.Unmarshal(content, &setup); err != nil {
+		return Setup{}, fmt.Errorf("failed to parse simulator setup: %w", err)
+	}
+
+	for i, setting := range setup.Privacy {
+		if setting.Action == "" {
+			setup.Privacy[i].Action = PrivacyActionGrant
+		}
+		if err := setup.Privacy[i].validate(); err != nil {
+			return Setup{}, fmt.Errorf("invalid privacy setting (%d): %w", i+1, err)
+		}
+	}
+
+	return setup, nil
+}
+
+func (s PrivacySetting) validate() error {
+	if !slices.Contains(privacyServices, s.Service) {
+		return fmt.Errorf("invalid service (%s), should be one of: %s", s.Service, strings.Join(privacyServices, ", "))
+	}
+
+	switch s.Action {
+	case PrivacyActionGrant, PrivacyActionRevoke:
+		if s.BundleID == "" {
+			return fmt.Errorf("bundle_id is required for the %s action", s.Action)
+		}
+		return nil
+	case PrivacyActionReset:
+		return nil
+	default:
+		return fmt.Errorf("invalid action (%s), should be one of: %s, %s, %s", s.Action, PrivacyActionGrant, PrivacyActionRevoke, PrivacyActionReset)
+	}
+}
+
+func (o StatusBarOverride) args() []string {
+	options := []struct {
+		flag  string
+		value string
+	}{
+		{"--time", o.Time},
+		{"--dataNetwork", o.DataNetwork},
+		{"--wifiMode", o.WifiMode},
+		{"--wifiBars", o.WifiBars},
+		{"--cellularMode", o.CellularMode},
+		{"--cellularBars", o.CellularBars},
+		{"--operatorName", o.OperatorName},
+		{"--batteryState", o.BatteryState},
+		{"--batteryLevel", o.BatteryLevel},
+	}
+
+	var args []string
+	for _, option := range options {
+		if option.value != "" {
+			args = append(args, option.flag, option.value)
+		}
+	}
+
+	return args
+}
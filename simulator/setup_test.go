@@ -0,0 +1,69 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetup(t *testing.T) {
+	content := `
+privacy:
+  - service: location
+    bundle_id: io.bitrise.BullsEye
+  - service: photos
+    action: reset
+status_bar:
+  time: "9:41"
+  battery_level: 100
+media:
+  - ./fixtures/photo.jpg
+language: de
+locale: de_DE
+location:
+  latitude: 47.4979
+  longitude: 19.0402
+`
+
+	setup, err := ParseSetup([]byte(content))
+
+	require.NoError(t, err)
+	require.Equal(t, Setup{
+		Privacy: []PrivacySetting{
+			{Service: "location", BundleID: "io.bitrise.BullsEye", Action: PrivacyActionGrant},
+			{Service: "photos", Action: PrivacyActionReset},
+		},
+		StatusBar: &StatusBarOverride{Time: "9:41", BatteryLevel: "100"},
+		Media:     []string{"./fixtures/photo.jpg"},
+		Language:  "de",
+		Locale:    "de_DE",
+		Location:  &Location{Latitude: 47.4979, Longitude: 19.0402},
+	}, setup)
+	require.Equal(t, []string{"--time", "9:41", "--batteryLevel", "100"}, setup.StatusBar.args())
+}
+
+func TestParseSetup_InvalidPrivacySettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "Unknown service",
+			content: `{"privacy": [{"service": "notifications", "bundle_id": "io.bitrise.BullsEye"}]}`,
+			wantErr: "invalid privacy setting (1): invalid service (notifications), should be one of: all, calendar, contacts-limited, contacts, location, location-always, photos-add, photos, media-library, microphone, motion, reminders, siri",
+		},
+		{
+			name:    "Missing bundle ID",
+			content: `{"privacy": [{"service": "photos", "action": "revoke"}]}`,
+			wantErr: "invalid privacy setting (1): bundle_id is required for the revoke action",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSetup([]byte(tt.content))
+
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/errorutil"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-io/go-xcode/v2/simulator"
+)
+
+// Manager extends the go-xcode Simulator manager with device lifecycle operations.
+type Manager interface {
+	simulator.Manager
+	Clone(device destination.Device, name string) (destination.Device, error)
+	Create(device destination.Device, name string) (destination.Device, error)
+	Delete(id string) error
+	WaitForBootStatus(id string, timeout time.Duration) error
+
+	SetPrivacy(id string, setting PrivacySetting) error
+	OverrideStatusBar(id string, override StatusBarOverride) error
+	AddMedia(id string, paths []string) error
+	AddRootCertificate(id, certificatePath string) error
+	SetLanguage(id, language, locale string) error
+	SetLocation(id string, location Location) error
+
+	StartVideoRecording(id, outputPath string) (VideoRecording, error)
+	StartLogStream(id, predicate, outputPath string) (LogStream, error)
+}
+
+type manager struct {
+	simulator.Manager
+	logger         log.Logger
+	commandFactory command.Factory
+}
+
+// NewManager ...
+func NewManager(logger log.Logger, commandFactory command.Factory) Manager {
+	return manager{
+		Manager:        simulator.NewManager(logger, commandFactory),
+		logger:         logger,
+		commandFactory: commandFactory,
+	}
+}
+
+// Clone creates a new Simulator from the given (shut down) device, and returns the new device.
+func (m manager) Clone(device destination.Device, name string) (destination.Device, error) {
+	cmd := m.commandFactory.Create("xcrun", []string{"simctl", "clone", device.UDID, name}, nil)
+	m.logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return destination.Device{}, fmt.Errorf("failed to clone Simulator (%s): %s", device.UDID, out)
+		}
+
+		return destination.Device{}, fmt.Errorf("failed to clone Simulator (%s), command execution failed: %w", device.UDID, err)
+	}
+
+	udid := lastLine(out)
+	if udid == "" {
+		return destination.Device{}, fmt.Errorf("failed to clone Simulator (%s): no device identifier in the output", device.UDID)
+	}
+
+	clone := device
+	clone.Name = name
+	clone.UDID = udid
+	clone.State = "Shutdown"
+
+	return clone, nil
+}
+
+// Create creates a new Simulator with the device type and runtime of the given device, and returns the new device.
+func (m manager) Create(device destination.Device, name string) (destination.Device, error) {
+	if device.TypeIdentifier == "" {
+		return destination.Device{}, fmt.Errorf("failed to create Simulator: unknown device type of %s (%s)", device.Name, device.UDID)
+	}
+
+	runtime, err := m.runtimeIdentifier(device.Platform, device.OS)
+	if err != nil {
+		return destination.Device{}, fmt.Errorf("failed to create Simulator (%s): %w", device.TypeIdentifier, err)
+	}
+
+	cmd := m.commandFactory.Create("xcrun", []string{"simctl", "create", name, device.TypeIdentifier, runtime}, nil)
+	m.logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return destination.Device{}, fmt.Errorf("failed to create Simulator (%s, %s): %s", device.TypeIdentifier, runtime, out)
+		}
+
+		return destination.Device{}, fmt.Errorf("failed to create Simulator (%s, %s), command execution failed: %w", device.TypeIdentifier, runtime, err)
+	}
+
+	udid := lastLine(out)
+	if udid == "" {
+		return destination.Device{}, fmt.Errorf("failed to create Simulator (%s, %s): no device identifier in the output", device.TypeIdentifier, runtime)
+	}
+
+	created := device
+	created.Name = name
+	created.UDID = udid
+	created.State = "Shutdown"
+	created.IsAvailable = true
+	created.AvailabilityError = ""
+
+	return created, nil
+}
+
+// Delete deletes the Simulator
+func (m manager) Delete(id string) error {
+	cmd := m.commandFactory.Create("xcrun", []string{"simctl", "delete", id}, nil)
+	m.logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return fmt.Errorf("failed to delete Simulator (%s): %s", id, out)
+		}
+
+		return fmt.Errorf("failed to delete Simulator (%s), command execution failed: %w", id, err)
+	}
+
+	return nil
+}
+
+// WaitForBootStatus waits until the Simulator finished booting (`simctl bootstatus`), the Simulator is booted if it is not running.
+// The bootstatus process is killed if the Simulator does not finish booting in the given time.
+func (m manager) WaitForBootStatus(id string, timeout time.Duration) error {
+	args := []string{"simctl", "bootstatus", id, "-b"}
+	m.logger.TPrintf("$ %s", v1command.PrintableCommandArgs(false, append([]string{"xcrun"}, args...)))
+
+	return waitForBootStatus(id, timeout, "xcrun", args...)
+}
+
+func waitForBootStatus(id string, timeout time.Duration, name string, args ...string) error {
+	out, err := runProcess(timeout, name, args...)
+	if err != nil {
+		if errors.Is(err, errProcessTimeout) {
+			return fmt.Errorf("simulator (%s) did not finish booting in %s", id, timeout)
+		}
+		if errorutil.IsExitStatusError(err) {
+			return fmt.Errorf("failed to wait for Simulator (%s) boot: %s", id, out)
+		}
+
+		return fmt.Errorf("failed to wait for Simulator (%s) boot, command execution failed: %w", id, err)
+	}
+
+	return nil
+}
+
+// SetPrivacy grants, revokes or resets the access of an app to a privacy service (`simctl privacy`).
+func (m manager) SetPrivacy(id string, setting PrivacySetting) error {
+	args := []string{"privacy", id, string(setting.Action), setting.Service}
+	if setting.BundleID != "" {
+		args = append(args, setting.BundleID)
+	}
+
+	return m.runSimctl(fmt.Sprintf("failed to %s %s access on Simulator (%s)", setting.Action, setting.Service, id), args...)
+}
+
+// OverrideStatusBar overrides the status bar of the Simulator (`simctl status_bar override`), the Simulator needs to be booted.
+func (m manager) OverrideStatusBar(id string, override StatusBarOverride) error {
+	args := append([]string{"status_bar", id, "override"}, override.args()...)
+
+	return m.runSimctl(fmt.Sprintf("failed to override the status bar of Simulator (%s)", id), args...)
+}
+
+// AddMedia adds photos and videos to the library of the Simulator (`simctl addmedia`), the Simulator needs to be booted.
+func (m manager) AddMedia(id string, paths []string) error {
+	args := append([]string{"addmedia", id}, paths...)
+
+	return m.runSimctl(fmt.Sprintf("failed to add media to Simulator (%s)", id), args...)
+}
+
+// AddRootCertificate adds a trusted root certificate to the keychain of the Simulator (`simctl keychain add-root-cert`).
+func (m manager) AddRootCertificate(id, certificatePath string) error {
+	return m.runSimctl(fmt.Sprintf("failed to add root certificate (%s) to Simulator (%s)", certificatePath, id), "keychain", id, "add-root-cert", certificatePath)
+}
+
+// SetLanguage sets the preferred language and the region format of the Simulator, empty values are not set.
+// Apps pick up the new settings on the next launch.
+func (m manager) SetLanguage(id, language, locale string) error {
+	if language != "" {
+		if err := m.runSimctl(fmt.Sprintf("failed to set the language of Simulator (%s)", id), "spawn", id, "defaults", "write", "Apple Global Domain", "AppleLanguages", "-array", language); err != nil {
+			return err
+		}
+	}
+
+	if locale != "" {
+		if err := m.runSimctl(fmt.Sprintf("failed to set the locale of Simulator (%s)", id), "spawn", id, "defaults", "write", "Apple Global Domain", "AppleLocale", "-string", locale); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetLocation sets the simulated location of the Simulator (`simctl location set`).
+func (m manager) SetLocation(id string, location Location) error {
+	coordinate := fmt.Sprintf("%s,%s", strconv.FormatFloat(location.Latitude, 'f', -1, 64), strconv.FormatFloat(location.Longitude, 'f', -1, 64))
+
+	return m.runSimctl(fmt.Sprintf("failed to set the location of Simulator (%s)", id), "location", id, "set", coordinate)
+}
+
+func (m manager) runSimctl(errorMessage string, args ...string) error {
+	cmd := m.commandFactory.Create("xcrun", append([]string{"simctl"}, args...), nil)
+	m.logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return fmt.Errorf("%s: %s", errorMessage, out)
+		}
+
+		return fmt.Errorf("%s, command execution failed: %w", errorMessage, err)
+	}
+
+	return nil
+}
+
+// lastLine returns the last line of the simctl output, simctl prints the UDID of the new device.
+func lastLine(out string) string {
+	lines := strings.Split(out, "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+type simRuntime struct {
+	Identifier  string `json:"identifier"`
+	Version     string `json:"version"`
+	Platform    string `json:"platform"`
+	Name        string `json:"name"`
+	IsAvailable bool   `json:"isAvailable"`
+}
+
+/*
+runtimeIdentifier looks up the CoreSimulator runtime identifier of a platform and OS version with `simctl list runtimes`.
+The identifier can not be derived from the version: the identifier of the iOS 17.0.1 runtime is
+com.apple.CoreSimulator.SimRuntime.iOS-17-0 for example.
+*/
+func (m manager) runtimeIdentifier(platform, osVersion string) (string, error) {
+	cmd := m.commandFactory.Create("xcrun", []string{"simctl", "list", "runtimes", "-j"}, nil)
+	m.logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return "", fmt.Errorf("failed to list Simulator runtimes: %s", out)
+		}
+
+		return "", fmt.Errorf("failed to list Simulator runtimes, command execution failed: %w", err)
+	}
+
+	var list struct {
+		Runtimes []simRuntime `json:"runtimes"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return "", fmt.Errorf("failed to parse Simulator runtimes: %w", err)
+	}
+
+	runtimePlatform := strings.TrimSuffix(platform, " Simulator")
+	for _, runtime := range list.Runtimes {
+		if runtime.IsAvailable && runtime.Version == osVersion && isRuntimeOfPlatform(runtime, runtimePlatform) {
+			return runtime.Identifier, nil
+		}
+	}
+
+	return "", fmt.Errorf("no available Simulator runtime found for %s %s", runtimePlatform, osVersion)
+}
+
+// isRuntimeOfPlatform reports whether the runtime belongs to the platform (iOS, tvOS, watchOS or visionOS),
+// older simctl versions do not list the platform, the runtime name starts with it.
+func isRuntimeOfPlatform(runtime simRuntime, platform string) bool {
+	if runtime.Platform == "" {
+		return strings.HasPrefix(runtime.Name, platform+" ")
+	}
+	if platform == "visionOS" && runtime.Platform == "xrOS" {
+		return true
+	}
+
+	return runtime.Platform == platform
+}
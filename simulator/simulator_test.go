@@ -0,0 +1,180 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenDevice_WhenCloned_ThenReturnsTheNewDevice(t *testing.T) {
+	// Given
+	device := destination.Device{Name: "iPhone 15", UDID: "E8C36A8B", State: "Shutdown", Platform: "iOS Simulator", OS: "17.5"}
+
+	cmd := new(mocks.Command)
+	cmd.On("PrintableCommandArgs").Return("")
+	cmd.On("RunAndReturnTrimmedCombinedOutput").Return("3F1C2D4E-0000-1111-2222-333344445555", nil)
+	commandFactory := new(mocks.CommandFactory)
+	commandFactory.On("Create", "xcrun", []string{"simctl", "clone", "E8C36A8B", "iPhone 15 (shard 2)"}, mock.Anything).Return(cmd)
+
+	manager := NewManager(log.NewLogger(), commandFactory)
+
+	// When
+	clone, err := manager.Clone(device, "iPhone 15 (shard 2)")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, destination.Device{
+		Name:     "iPhone 15 (shard 2)",
+		UDID:     "3F1C2D4E-0000-1111-2222-333344445555",
+		State:    "Shutdown",
+		Platform: "iOS Simulator",
+		OS:       "17.5",
+	}, clone)
+}
+
+func Test_GivenSimulator_WhenBootStatusCommandFails_ThenReturnsError(t *testing.T) {
+	// When
+	err := waitForBootStatus("E8C36A8B", time.Minute, "sh", "-c", "echo 'Invalid device: E8C36A8B'; exit 1")
+
+	// Then
+	require.EqualError(t, err, "failed to wait for Simulator (E8C36A8B) boot: Invalid device: E8C36A8B")
+}
+
+func Test_GivenSimulator_WhenBootStatusTimesOut_ThenKillsTheCommand(t *testing.T) {
+	// Given
+	startTime := time.Now()
+
+	// When
+	err := waitForBootStatus("E8C36A8B", 100*time.Millisecond, "sleep", "60")
+
+	// Then
+	require.EqualError(t, err, "simulator (E8C36A8B) did not finish booting in 100ms")
+	require.Less(t, time.Since(startTime), 10*time.Second)
+}
+
+func Test_GivenDevice_WhenCreated_ThenCreatesTheSameDeviceTypeAndRuntime(t *testing.T) {
+	// Given
+	device := destination.Device{
+		Name:           "iPhone 15",
+		TypeIdentifier: "com.apple.CoreSimulator.SimDeviceType.iPhone-15",
+		UDID:           "E8C36A8B",
+		State:          "Booted",
+		Platform:       "iOS Simulator",
+		OS:             "17.5",
+	}
+
+	commandFactory := new(mocks.CommandFactory)
+	mockRuntimeList(commandFactory)
+	cmd := new(mocks.Command)
+	cmd.On("PrintableCommandArgs").Return("")
+	cmd.On("RunAndReturnTrimmedCombinedOutput").Return("3F1C2D4E-0000-1111-2222-333344445555", nil)
+	commandFactory.On("Create", "xcrun", []string{"simctl", "create", "iPhone 15 (ephemeral)", "com.apple.CoreSimulator.SimDeviceType.iPhone-15", "com.apple.CoreSimulator.SimRuntime.iOS-17-5"}, mock.Anything).Return(cmd)
+
+	manager := NewManager(log.NewLogger(), commandFactory)
+
+	// When
+	created, err := manager.Create(device, "iPhone 15 (ephemeral)")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "3F1C2D4E-0000-1111-2222-333344445555", created.UDID)
+	require.Equal(t, "iPhone 15 (ephemeral)", created.Name)
+	require.Equal(t, "Shutdown", created.State)
+}
+
+func Test_runtimeIdentifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		platform   string
+		osVersion  string
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name:      "minor version",
+			platform:  "iOS Simulator",
+			osVersion: "17.5",
+			want:      "com.apple.CoreSimulator.SimRuntime.iOS-17-5",
+		},
+		{
+			name:      "patch version",
+			platform:  "iOS Simulator",
+			osVersion: "17.0.1",
+			want:      "com.apple.CoreSimulator.SimRuntime.iOS-17-0",
+		},
+		{
+			name:      "same version on an other platform",
+			platform:  "tvOS Simulator",
+			osVersion: "17.5",
+			want:      "com.apple.CoreSimulator.SimRuntime.tvOS-17-5",
+		},
+		{
+			name:      "visionOS",
+			platform:  "visionOS Simulator",
+			osVersion: "2.0",
+			want:      "com.apple.CoreSimulator.SimRuntime.xrOS-2-0",
+		},
+		{
+			name:       "unavailable runtime",
+			platform:   "iOS Simulator",
+			osVersion:  "16.4",
+			wantErrMsg: "no available Simulator runtime found for iOS 16.4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commandFactory := new(mocks.CommandFactory)
+			mockRuntimeList(commandFactory)
+			manager := NewManager(log.NewLogger(), commandFactory).(manager)
+
+			got, err := manager.runtimeIdentifier(tt.platform, tt.osVersion)
+			if tt.wantErrMsg != "" {
+				require.EqualError(t, err, tt.wantErrMsg)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func mockRuntimeList(commandFactory *mocks.CommandFactory) {
+	runtimes := `{
+  "runtimes" : [
+    {"identifier" : "com.apple.CoreSimulator.SimRuntime.iOS-16-4", "version" : "16.4", "platform" : "iOS", "name" : "iOS 16.4", "isAvailable" : false},
+    {"identifier" : "com.apple.CoreSimulator.SimRuntime.iOS-17-0", "version" : "17.0.1", "platform" : "iOS", "name" : "iOS 17.0", "isAvailable" : true},
+    {"identifier" : "com.apple.CoreSimulator.SimRuntime.iOS-17-5", "version" : "17.5", "platform" : "iOS", "name" : "iOS 17.5", "isAvailable" : true},
+    {"identifier" : "com.apple.CoreSimulator.SimRuntime.tvOS-17-5", "version" : "17.5", "platform" : "tvOS", "name" : "tvOS 17.5", "isAvailable" : true},
+    {"identifier" : "com.apple.CoreSimulator.SimRuntime.xrOS-2-0", "version" : "2.0", "platform" : "xrOS", "name" : "visionOS 2.0", "isAvailable" : true}
+  ]
+}`
+
+	cmd := new(mocks.Command)
+	cmd.On("PrintableCommandArgs").Return("")
+	cmd.On("RunAndReturnTrimmedOutput").Return(runtimes, nil)
+	commandFactory.On("Create", "xcrun", []string{"simctl", "list", "runtimes", "-j"}, mock.Anything).Return(cmd)
+}
+
+func Test_GivenLocation_WhenSet_ThenPassesTheCoordinateToSimctl(t *testing.T) {
+	// Given
+	cmd := new(mocks.Command)
+	cmd.On("PrintableCommandArgs").Return("")
+	cmd.On("RunAndReturnTrimmedCombinedOutput").Return("", nil)
+	commandFactory := new(mocks.CommandFactory)
+	commandFactory.On("Create", "xcrun", []string{"simctl", "location", "E8C36A8B", "set", "47.4979,19.0402"}, mock.Anything).Return(cmd)
+
+	manager := NewManager(log.NewLogger(), commandFactory)
+
+	// When
+	err := manager.SetLocation("E8C36A8B", Location{Latitude: 47.4979, Longitude: 19.0402})
+
+	// Then
+	require.NoError(t, err)
+	commandFactory.AssertExpectations(t)
+}
@@ -0,0 +1,80 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/simulator"
+)
+
+type appLogStream struct {
+	device destination.Device
+	path   string
+	stream simulator.LogStream
+}
+
+/*
+startAppLogStreams streams the system log of the given simulators into outputDir, filtered by the app log predicate,
+if the Collect Simulator app log (collect_simulator_app_log) input is enabled. The simulators are booted if they are not running yet.
+
+A failing log stream does not fail the test run.
+*/
+func (s XcodeTestRunner) startAppLogStreams(cfg Config, sims []destination.Device, outputDir string) []appLogStream {
+	if cfg.CollectSimulatorAppLog == "" || cfg.CollectSimulatorAppLog == never || cfg.SimulatorAppLogPredicate == "" {
+		return nil
+	}
+
+	var streams []appLogStream
+	for _, sim := range sims {
+		if err := s.simulatorManager.WaitForBootStatus(sim.UDID, cfg.SimulatorBootTimeout); err != nil {
+			s.logger.Warnf("Failed to boot simulator for the app log stream: %s", err)
+			continue
+		}
+
+		pth := filepath.Join(outputDir, fmt.Sprintf("simulator_app-%s.log", sim.UDID))
+		stream, err := s.simulatorManager.StartLogStream(sim.UDID, cfg.SimulatorAppLogPredicate, pth)
+		if err != nil {
+			s.logger.Warnf("Failed to stream the simulator app log: %s", err)
+			continue
+		}
+
+		s.logger.Printf("Streaming the app log of %s (%s): %s", sim.Name, sim.UDID, cfg.SimulatorAppLogPredicate)
+		streams = append(streams, appLogStream{device: sim, path: pth, stream: stream})
+	}
+
+	return streams
+}
+
+/*
+stopAppLogStreams stops the log streams and returns the collected app log based on the Collect Simulator app log
+(collect_simulator_app_log) input. The logs of multiple simulators are concatenated, separated by a header line.
+*/
+func (s XcodeTestRunner) stopAppLogStreams(streams []appLogStream, condition exportCondition, testErr error) string {
+	var logs []string
+	for _, stream := range streams {
+		if err := stream.stream.Stop(); err != nil {
+			s.logger.Warnf("%s", err)
+		}
+
+		if condition == onFailure && testErr == nil {
+			continue
+		}
+
+		content, err := os.ReadFile(stream.path)
+		if err != nil {
+			s.logger.Warnf("Failed to read the simulator app log: %s", err)
+			continue
+		}
+
+		if len(streams) > 1 {
+			logs = append(logs, fmt.Sprintf("=== %s (%s) ===\n%s", stream.device.Name, stream.device.UDID, content))
+		} else {
+			logs = append(logs, string(content))
+		}
+	}
+
+	return strings.Join(logs, "\n")
+}
@@ -0,0 +1,48 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/step/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenAppLogOnMultipleSimulators_WhenStopped_ThenConcatenatesTheLogs(t *testing.T) {
+	// Given
+	step, stepMocks := createStepAndMocks(t)
+	outputDir := t.TempDir()
+	sims := []destination.Device{{Name: "iPhone 15", UDID: "1234"}, {Name: "iPad Air", UDID: "5678"}}
+	predicate := `process == "BullsEye"`
+
+	for _, sim := range sims {
+		pth := filepath.Join(outputDir, "simulator_app-"+sim.UDID+".log")
+		stream := mocks.NewLogStream(t)
+		stream.On("Stop").Return(nil).Run(func(_ mock.Arguments) {
+			require.NoError(t, os.WriteFile(pth, []byte("BullsEye: launched on "+sim.Name), 0600))
+		})
+		stepMocks.simulatorManager.On("WaitForBootStatus", sim.UDID, defaultSimulatorBootTimeout).Return(nil)
+		stepMocks.simulatorManager.On("StartLogStream", sim.UDID, predicate, pth).Return(stream, nil)
+	}
+
+	cfg := Config{CollectSimulatorAppLog: always, SimulatorAppLogPredicate: predicate, SimulatorBootTimeout: defaultSimulatorBootTimeout}
+
+	// When
+	streams := step.startAppLogStreams(cfg, sims, outputDir)
+	appLog := step.stopAppLogStreams(streams, cfg.CollectSimulatorAppLog, nil)
+
+	// Then
+	require.Equal(t, "=== iPhone 15 (1234) ===\nBullsEye: launched on iPhone 15\n=== iPad Air (5678) ===\nBullsEye: launched on iPad Air", appLog)
+}
+
+func Test_appLogPredicate(t *testing.T) {
+	require.Equal(t, `process == "BullsEye"`, appLogPredicate(Input{Scheme: "BullsEye"}))
+	require.Equal(t, `process == "BullsEye" OR subsystem == "io.bitrise.BullsEye"`, appLogPredicate(Input{
+		Scheme:                    "BullsEye-Tests",
+		SimulatorAppLogProcesses:  "BullsEye\n",
+		SimulatorAppLogSubsystems: " io.bitrise.BullsEye ",
+	}))
+}
@@ -0,0 +1,159 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
+)
+
+/*
+collectCrashReports collects the crash reports of the processes which crashed on the simulators (including the clones
+of the test shards) after the test run started, if the Collect crash reports (collect_crash_reports) input is enabled.
+
+The reports are symbolicated against the dSYMs of the build products where possible. The collected .ips files and the
+symbolicated summaries are returned for the export.
+*/
+func (s XcodeTestRunner) collectCrashReports(cfg Config, shardSims []destination.Device, since time.Time) ([]crashreport.Report, []string) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		s.logger.Warnf("Failed to collect crash reports: %s", err)
+		return nil, nil
+	}
+
+	sims := slices.Concat(cfg.simulators(), shardSims)
+	return s.collectCrashReportsFrom(crashReportDirs(homeDir, sims), dsymSearchDirs(homeDir, cfg), sims, since)
+}
+
+func (s XcodeTestRunner) collectCrashReportsFrom(reportDirs, dsymDirs []string, sims []destination.Device, since time.Time) ([]crashreport.Report, []string) {
+	paths, err := crashreport.Collect(reportDirs, since)
+	if err != nil {
+		s.logger.Warnf("Failed to collect crash reports: %s", err)
+		return nil, nil
+	}
+
+	var reports []crashreport.Report
+	for _, pth := range paths {
+		report, err := crashreport.ParseFile(pth)
+		if err != nil {
+			if !errors.Is(err, crashreport.ErrNotCrashReport) {
+				s.logger.Warnf("%s", err)
+			}
+			continue
+		}
+
+		if isSimulatorCrashReport(report, sims) {
+			reports = append(reports, report)
+		}
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Collecting crash reports")
+
+	outputDir, err := s.pathProvider.CreateTempDir("CrashReports")
+	if err != nil {
+		s.logger.Warnf("Failed to create crash reports directory: %s", err)
+		return nil, nil
+	}
+
+	symbolicator := crashreport.NewSymbolicator(s.logger, s.commandFactory, dsymDirs)
+
+	var collected []crashreport.Report
+	var outputPaths []string
+	for _, report := range reports {
+		report = symbolicator.Symbolicate(report)
+
+		reportPath, summaryPath, err := writeCrashReport(report, outputDir)
+		if err != nil {
+			s.logger.Warnf("Failed to save crash report: %s", err)
+			continue
+		}
+		report.Path = reportPath
+
+		s.logger.Printf("%s crashed: %s", report.Identifier(), report.Exception)
+		collected = append(collected, report)
+		outputPaths = append(outputPaths, reportPath, summaryPath)
+	}
+
+	s.logger.Donef("%d crash report(s) are available as an artifact", len(collected))
+
+	return collected, outputPaths
+}
+
+// isSimulatorCrashReport returns whether the crashed process ran on one of the given simulators.
+func isSimulatorCrashReport(report crashreport.Report, sims []destination.Device) bool {
+	for _, sim := range sims {
+		if strings.Contains(report.Path, sim.UDID) || strings.Contains(report.ProcPath, sim.UDID) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCrashReport copies the .ips report into the output directory next to the symbolicated summary of the crash.
+func writeCrashReport(report crashreport.Report, outputDir string) (string, string, error) {
+	content, err := os.ReadFile(report.Path)
+	if err != nil {
+		return "", "", err
+	}
+
+	reportPath := filepath.Join(outputDir, filepath.Base(report.Path))
+	if err := os.WriteFile(reportPath, content, 0600); err != nil {
+		return "", "", err
+	}
+
+	summaryPath := strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + ".txt"
+	if err := os.WriteFile(summaryPath, []byte(report.Summary()+"\n"), 0600); err != nil {
+		return "", "", err
+	}
+
+	return reportPath, summaryPath, nil
+}
+
+// crashReportDirs are the directories where the crash reports of the simulator processes are written.
+func crashReportDirs(homeDir string, sims []destination.Device) []string {
+	dirs := []string{filepath.Join(homeDir, "Library", "Logs", "DiagnosticReports")}
+	for _, sim := range sims {
+		dirs = append(dirs, filepath.Join(homeDir, "Library", "Developer", "CoreSimulator", "Devices", sim.UDID, "data", "Library", "Logs", "CrashReporter"))
+	}
+	return dirs
+}
+
+/*
+dsymSearchDirs are the build products directories where the dSYMs of the tested app are looked up: the directory of the
+test run (.xctestrun) file and the DerivedData build products.
+*/
+func dsymSearchDirs(homeDir string, cfg Config) []string {
+	var dirs []string
+	if filepath.Ext(cfg.XctestrunPath) == ".xctestrun" {
+		dirs = append(dirs, filepath.Dir(cfg.XctestrunPath))
+	}
+
+	if derivedDataPath := derivedDataPathOption(cfg.XcodebuildOptions); derivedDataPath != "" {
+		return append(dirs, filepath.Join(derivedDataPath, "Build", "Products"))
+	}
+
+	productsDirs, err := filepath.Glob(filepath.Join(homeDir, "Library", "Developer", "Xcode", "DerivedData", "*", "Build", "Products"))
+	if err == nil {
+		dirs = append(dirs, productsDirs...)
+	}
+
+	return dirs
+}
+
+func derivedDataPathOption(options []string) string {
+	for i, option := range options {
+		if option == "-derivedDataPath" && i+1 < len(options) {
+			return options[i+1]
+		}
+	}
+	return ""
+}
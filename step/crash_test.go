@@ -0,0 +1,56 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/stretchr/testify/require"
+)
+
+const simulatorCrashReport = `{"app_name":"BullsEye","timestamp":"2024-05-02 10:11:12.00 +0200","bundleID":"io.bitrise.BullsEye","bug_type":"309","name":"BullsEye"}
+{"pid":12345,"procName":"BullsEye","procPath":"/Users/vagrant/Library/Developer/CoreSimulator/Devices/1234/data/Containers/Bundle/Application/0A1B/BullsEye.app/BullsEye","exception":{"type":"EXC_BAD_ACCESS","signal":"SIGSEGV"},"faultingThread":0,"threads":[{"triggered":true,"frames":[{"imageOffset":16400,"symbol":"main","symbolLocation":12,"imageIndex":0}]}],"usedImages":[{"arch":"arm64","base":4301750272,"uuid":"9c4c7e3e-5a0b-3e4f-8e2a-0d1f2c3b4a59","name":"BullsEye"}]}`
+
+const hostCrashReport = `{"app_name":"Finder","timestamp":"2024-05-02 10:11:13.00 +0200","bundleID":"com.apple.finder","bug_type":"309","name":"Finder"}
+{"pid":500,"procName":"Finder","procPath":"/System/Library/CoreServices/Finder.app/Contents/MacOS/Finder","exception":{"type":"EXC_CRASH","signal":"SIGABRT"},"threads":[]}`
+
+func Test_GivenCrashesDuringTheTestRun_WhenCollected_ThenReturnsTheSimulatorCrashReports(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	since := time.Now().Add(-time.Minute)
+	reportsDir := t.TempDir()
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(reportsDir, "BullsEye-2024-05-02-101112.ips"), []byte(simulatorCrashReport), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(reportsDir, "Finder-2024-05-02-101113.ips"), []byte(hostCrashReport), 0600))
+
+	mocks.pathProvider.On("CreateTempDir", "CrashReports").Return(outputDir, nil)
+
+	sims := []destination.Device{{Name: "iPhone 15", UDID: "1234"}}
+
+	// When
+	reports, paths := step.collectCrashReportsFrom([]string{reportsDir}, nil, sims, since)
+
+	// Then
+	require.Len(t, reports, 1)
+	require.Equal(t, "BullsEye (12345)", reports[0].Identifier())
+	require.Equal(t, filepath.Join(outputDir, "BullsEye-2024-05-02-101112.ips"), reports[0].Path)
+	require.Equal(t, []string{
+		filepath.Join(outputDir, "BullsEye-2024-05-02-101112.ips"),
+		filepath.Join(outputDir, "BullsEye-2024-05-02-101112.txt"),
+	}, paths)
+
+	summary, err := os.ReadFile(paths[1])
+	require.NoError(t, err)
+	require.Contains(t, string(summary), "BullsEye (12345) crashed: EXC_BAD_ACCESS (SIGSEGV)")
+}
+
+func Test_GivenDerivedDataPathOption_WhenSearchingDSYMs_ThenUsesItsBuildProducts(t *testing.T) {
+	cfg := Config{
+		XctestrunPath:     "/build/BullsEye_iphonesimulator17.5-arm64.xctestrun",
+		XcodebuildOptions: []string{"-derivedDataPath", "/tmp/DerivedData"},
+	}
+
+	require.Equal(t, []string{"/build", "/tmp/DerivedData/Build/Products"}, dsymSearchDirs("/Users/vagrant", cfg))
+}
@@ -0,0 +1,226 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	// destinationFallbackSeparator separates the destination specifiers of a fallback chain.
+	destinationFallbackSeparator = "||"
+	// osWildcardSuffix matches any installed runtime version with the given prefix (for example `OS=17.x`).
+	osWildcardSuffix = ".x"
+)
+
+/*
+parseDestinationFallbacks splits a destination line into its fallback chain, for example:
+
+	platform=iOS Simulator,name=iPhone 15,OS=17.5 || OS=17.x || latest
+
+A fallback entry is either a complete destination specifier (with a platform), or only the keys overriding the first
+destination specifier of the chain. `latest` is a shorthand for `OS=latest`.
+*/
+func parseDestinationFallbacks(line string) ([]string, error) {
+	var candidates []string
+	var primary destination.Specifier
+	for i, entry := range strings.Split(line, destinationFallbackSeparator) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return nil, fmt.Errorf("empty destination specifier in fallback chain: %s", line)
+		}
+		if i == 0 {
+			candidates = append(candidates, entry)
+			primary, _ = destination.NewSpecifier(entry)
+			continue
+		}
+
+		if entry == "latest" {
+			entry = "OS=latest"
+		}
+
+		specifier, err := destination.NewSpecifier(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback destination specifier (%s): %w", entry, err)
+		}
+		if _, hasPlatform := specifier["platform"]; hasPlatform || primary == nil {
+			candidates = append(candidates, entry)
+			continue
+		}
+
+		merged := destination.Specifier{}
+		for key, value := range primary {
+			merged[key] = value
+		}
+		for key, value := range specifier {
+			merged[key] = value
+		}
+		candidates = append(candidates, formatDestinationSpecifier(merged))
+	}
+
+	return candidates, nil
+}
+
+// formatDestinationSpecifier formats a destination specifier with the platform, name, OS and arch keys first.
+func formatDestinationSpecifier(specifier destination.Specifier) string {
+	order := map[string]int{"platform": 0, "name": 1, "OS": 2, "arch": 3}
+	keys := make([]string, 0, len(specifier))
+	for key := range specifier {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		iOrder, iKnown := order[keys[i]]
+		jOrder, jKnown := order[keys[j]]
+		switch {
+		case iKnown && jKnown:
+			return iOrder < jOrder
+		case iKnown != jKnown:
+			return iKnown
+		default:
+			return keys[i] < keys[j]
+		}
+	})
+
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, specifier[key]))
+	}
+	return strings.Join(parts, ",")
+}
+
+/*
+getSimulatorForDestinationFallbacks returns the simulator of the first available destination of the fallback chain,
+and the resolved destination specifier (with the OS version of the matching simulator).
+*/
+func (s XcodeTestConfigParser) getSimulatorForDestinationFallbacks(candidates []string) (destination.Device, string, error) {
+	var lastErr error
+	for i, candidate := range candidates {
+		if i > 0 {
+			s.logger.Printf("Trying fallback destination: %s", colorstring.Cyan(candidate))
+		}
+
+		resolvedSpecifier, err := s.resolveOSWildcard(candidate)
+		if err == nil {
+			var sim destination.Device
+			sim, err = s.getSimulatorForDestination(resolvedSpecifier)
+			if err == nil {
+				if i > 0 {
+					s.logger.Warnf("Using fallback destination (%s), as the preceding destinations are not available", candidate)
+				}
+				return sim, resolvedDestinationSpecifier(resolvedSpecifier, sim), nil
+			}
+		}
+
+		if len(candidates) == 1 {
+			return destination.Device{}, "", err
+		}
+
+		s.logger.Warnf("Destination (%s) is not available: %s", candidate, err)
+		lastErr = err
+	}
+
+	return destination.Device{}, "", fmt.Errorf("none of the fallback destinations is available (%s): %w", strings.Join(candidates, " || "), lastErr)
+}
+
+// resolvedDestinationSpecifier replaces the OS version of the destination specifier with the version of the matching simulator.
+func resolvedDestinationSpecifier(destinationSpecifier string, sim destination.Device) string {
+	specifier, err := destination.NewSpecifier(destinationSpecifier)
+	if err != nil || sim.OS == "" {
+		return destinationSpecifier
+	}
+
+	specifier["OS"] = sim.OS
+	return formatDestinationSpecifier(specifier)
+}
+
+/*
+resolveOSWildcard replaces a wildcard OS version (for example `OS=17.x`) with the latest installed runtime version
+of the platform matching the version prefix.
+*/
+func (s XcodeTestConfigParser) resolveOSWildcard(destinationSpecifier string) (string, error) {
+	specifier, err := destination.NewSpecifier(destinationSpecifier)
+	if err != nil || !strings.HasSuffix(specifier.OS(), osWildcardSuffix) {
+		// Invalid specifiers are reported by the simulator destination parsing.
+		return destinationSpecifier, nil
+	}
+
+	prefix, err := parseVersionPrefix(strings.TrimSuffix(specifier.OS(), osWildcardSuffix))
+	if err != nil {
+		return "", fmt.Errorf("invalid OS version (%s): %w", specifier.OS(), err)
+	}
+
+	deviceList, err := s.deviceFinder.ListDevices()
+	if err != nil {
+		return "", err
+	}
+
+	platform, _ := specifier.Platform()
+	runtimeVersion := latestRuntimeVersion(deviceList.Runtimes, strings.TrimSuffix(string(platform), " Simulator"), prefix)
+	if runtimeVersion == "" {
+		return "", fmt.Errorf("no %s %s runtime is installed", platform, specifier.OS())
+	}
+
+	specifier["OS"] = runtimeVersion
+	return formatDestinationSpecifier(specifier), nil
+}
+
+func parseVersionPrefix(prefix string) ([]int, error) {
+	var segments []int
+	for _, part := range strings.Split(prefix, ".") {
+		segment, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, errors.New("should be a version prefix followed by .x, for example 17.x")
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// latestRuntimeVersion returns the latest available runtime version of the platform which starts with the given version segments.
+func latestRuntimeVersion(runtimes []destination.DeviceRuntime, platform string, prefix []int) string {
+	var latest *version.Version
+	var latestRuntime string
+	for _, runtime := range runtimes {
+		if !runtime.IsAvailable || !isRuntimeOfPlatform(runtime, platform) {
+			continue
+		}
+
+		runtimeVersion, err := version.NewVersion(runtime.Version)
+		if err != nil || !hasVersionPrefix(runtimeVersion.Segments(), prefix) {
+			continue
+		}
+
+		if latest == nil || runtimeVersion.GreaterThan(latest) {
+			latest = runtimeVersion
+			latestRuntime = runtime.Version
+		}
+	}
+
+	return latestRuntime
+}
+
+func isRuntimeOfPlatform(runtime destination.DeviceRuntime, platform string) bool {
+	if runtime.Platform != "" {
+		// simctl reports visionOS as xrOS
+		return runtime.Platform == platform || (runtime.Platform == "xrOS" && platform == "visionOS")
+	}
+	return strings.HasPrefix(runtime.Name, platform)
+}
+
+func hasVersionPrefix(segments, prefix []int) bool {
+	if len(prefix) > len(segments) {
+		return false
+	}
+	for i, segment := range prefix {
+		if segments[i] != segment {
+			return false
+		}
+	}
+	return true
+}
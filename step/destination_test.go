@@ -0,0 +1,71 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDestinationFallbacks(t *testing.T) {
+	candidates, err := parseDestinationFallbacks("platform=iOS Simulator,name=iPhone 15,OS=17.5 || OS=17.x || latest || platform=iOS Simulator,name=iPhone 16")
+
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"platform=iOS Simulator,name=iPhone 15,OS=17.5",
+		"platform=iOS Simulator,name=iPhone 15,OS=17.x",
+		"platform=iOS Simulator,name=iPhone 15,OS=latest",
+		"platform=iOS Simulator,name=iPhone 16",
+	}, candidates)
+}
+
+func Test_GivenEmptyFallback_WhenParsed_ThenReturnsError(t *testing.T) {
+	_, err := parseDestinationFallbacks("platform=iOS Simulator,name=iPhone 15,OS=17.5 ||")
+
+	require.EqualError(t, err, "empty destination specifier in fallback chain: platform=iOS Simulator,name=iPhone 15,OS=17.5 ||")
+}
+
+func Test_GivenMissingRuntime_WhenResolvingFallbacks_ThenSelectsTheLatestMatchingRuntime(t *testing.T) {
+	// Given
+	configParser, mocks := createConfigParser(t, nil)
+	device := destination.Device{Name: "iPhone 15", UDID: "1234", State: "Shutdown", Platform: "iOS Simulator", OS: "17.4"}
+
+	mocks.deviceFinder.On("FindDevice", destination.Simulator{Platform: "iOS Simulator", Name: "iPhone 15", OS: "17.5"}).
+		Return(destination.Device{}, errors.New("iOS 17.5 is not installed"))
+	mocks.deviceFinder.On("ListDevices").Return(&destination.DeviceList{Runtimes: []destination.DeviceRuntime{
+		{Platform: "iOS", Version: "17.2", IsAvailable: true},
+		{Platform: "iOS", Version: "17.4", IsAvailable: true},
+		{Platform: "iOS", Version: "17.5", IsAvailable: false},
+		{Platform: "iOS", Version: "18.0", IsAvailable: true},
+		{Platform: "tvOS", Version: "17.6", IsAvailable: true},
+	}}, nil)
+	mocks.deviceFinder.On("FindDevice", destination.Simulator{Platform: "iOS Simulator", Name: "iPhone 15", OS: "17.4"}).Return(device, nil)
+
+	candidates := []string{"platform=iOS Simulator,name=iPhone 15,OS=17.5", "platform=iOS Simulator,name=iPhone 15,OS=17.x", "platform=iOS Simulator,name=iPhone 15,OS=latest"}
+
+	// When
+	sim, resolvedDestination, err := configParser.getSimulatorForDestinationFallbacks(candidates)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, device, sim)
+	require.Equal(t, "platform=iOS Simulator,name=iPhone 15,OS=17.4", resolvedDestination)
+	mocks.deviceFinder.AssertNumberOfCalls(t, "FindDevice", 2)
+}
+
+func Test_GivenNoAvailableFallback_WhenResolvingFallbacks_ThenReturnsTheLastError(t *testing.T) {
+	// Given
+	configParser, mocks := createConfigParser(t, nil)
+	mocks.deviceFinder.On("FindDevice", mock.Anything).Return(destination.Device{}, errors.New("iOS 16.4 is not installed"))
+
+	candidates := []string{"platform=iOS Simulator,name=iPhone 14,OS=16.4", "platform=iOS Simulator,name=iPhone 14,OS=16.4.1"}
+
+	// When
+	_, _, err := configParser.getSimulatorForDestinationFallbacks(candidates)
+
+	// Then
+	require.ErrorContains(t, err, "none of the fallback destinations is available (platform=iOS Simulator,name=iPhone 14,OS=16.4 || platform=iOS Simulator,name=iPhone 14,OS=16.4.1)")
+	require.ErrorContains(t, err, "iOS 16.4 is not installed")
+}
@@ -0,0 +1,183 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	v1command "github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+type xcodebuildList struct {
+	Project   *xcodebuildListContainer `json:"project"`
+	Workspace *xcodebuildListContainer `json:"workspace"`
+}
+
+type xcodebuildListContainer struct {
+	Schemes []string `json:"schemes"`
+}
+
+type xcodebuildTestPlans struct {
+	TestPlans []struct {
+		Name string `json:"name"`
+	} `json:"testPlans"`
+}
+
+/*
+DryRun validates the processed inputs without booting the simulators and running the tests, if the Dry run (dry_run)
+input is enabled.
+
+The scheme and the test plans are looked up in the project with `xcodebuild -list` and `xcodebuild -showTestPlans`,
+then the resolved destinations and the xcodebuild commands of the test run are printed.
+*/
+func (s XcodeTestRunner) DryRun(cfg Config) error {
+	s.logger.Println()
+	s.logger.Infof("Validating the Step inputs (dry run)")
+
+	testPlans := []string{""}
+	if cfg.XctestrunPath != "" {
+		if _, err := os.Stat(cfg.XctestrunPath); err != nil {
+			return fmt.Errorf("'Test Run file' (xctestrun) is not accessible: %w", err)
+		}
+	} else {
+		if err := s.validateScheme(cfg.ProjectPath, cfg.Scheme); err != nil {
+			return err
+		}
+		if len(cfg.TestPlans) > 0 {
+			available, err := s.listTestPlans(cfg.ProjectPath, cfg.Scheme)
+			if err != nil {
+				return err
+			}
+			if testPlans, err = matchTestPlans(cfg.TestPlans, available, cfg.Scheme); err != nil {
+				return err
+			}
+			cfg.TestPlans = testPlans
+		}
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Resolved destinations:")
+	for _, destination := range cfg.ResolvedDestinations {
+		s.logger.Printf("- %s", colorstring.Cyan(destination))
+	}
+
+	tempDir, err := s.pathProvider.CreateTempDir("XCUITestOutput")
+	if err != nil {
+		return fmt.Errorf("could not create test output temporary directory: %w", err)
+	}
+
+	if cfg.XctestrunPath != "" {
+		xctestrunPath, err := s.prepareXctestrun(cfg.XctestrunPath, tempDir)
+		if err != nil {
+			return err
+		}
+		cfg.XctestrunPath = xctestrunPath
+	}
+
+	s.logger.Println()
+	s.logger.Infof("xcodebuild commands:")
+	for _, testPlan := range testPlans {
+		cfg.TestPlan = testPlan
+		testParams := s.utils.CreateTestParams(cfg, filepath.Join(tempDir, cfg.testRunName()+".xcresult"), "")
+
+		if cfg.XctestrunPath == "" {
+			buildArgs, err := s.xcodebuild.BuildForTestingArgs(testParams)
+			if err != nil {
+				return fmt.Errorf("failed to create the build-for-testing command: %w", err)
+			}
+			s.logger.Printf("$ %s", printableXcodebuildCommand(buildArgs))
+		}
+
+		testArgs, err := s.xcodebuild.TestWithoutBuildingArgs(testParams)
+		if err != nil {
+			return fmt.Errorf("failed to create the test-without-building command: %w", err)
+		}
+		s.logger.Printf("$ %s", printableXcodebuildCommand(testArgs))
+	}
+
+	s.logger.Println()
+	s.logger.Donef("The Step inputs are valid")
+
+	return nil
+}
+
+func (s XcodeTestRunner) validateScheme(projectPath, scheme string) error {
+	out, err := s.runXcodebuildQuery(projectPath, "-list", "-json")
+	if err != nil {
+		return fmt.Errorf("failed to list the schemes of the project (%s): %w", projectPath, err)
+	}
+
+	schemes, err := parseXcodebuildListSchemes(out)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range schemes {
+		if name == scheme {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("'Scheme' (scheme) %s is not found in the project (%s), available schemes: %s", scheme, projectPath, strings.Join(schemes, ", "))
+}
+
+// runXcodebuildQuery runs an informational xcodebuild command on the project, workspace or Swift package.
+func (s XcodeTestRunner) runXcodebuildQuery(projectPath string, args ...string) (string, error) {
+	switch filepath.Ext(projectPath) {
+	case ".xcodeproj":
+		args = append(args, "-project", projectPath)
+	case ".xcworkspace":
+		args = append(args, "-workspace", projectPath)
+	}
+
+	// Swift packages are looked up in the working directory.
+	cmd := s.commandFactory.Create("xcodebuild", args, &command.Opts{Dir: filepath.Dir(projectPath)})
+	s.logger.Printf("$ %s", cmd.PrintableCommandArgs())
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		if out != "" {
+			return "", fmt.Errorf("%w: %s", err, out)
+		}
+		return "", err
+	}
+
+	return out, nil
+}
+
+func parseXcodebuildListSchemes(out string) ([]string, error) {
+	var list xcodebuildList
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse xcodebuild -list output: %w", err)
+	}
+
+	switch {
+	case list.Workspace != nil:
+		return list.Workspace.Schemes, nil
+	case list.Project != nil:
+		return list.Project.Schemes, nil
+	default:
+		return nil, nil
+	}
+}
+
+func parseXcodebuildTestPlans(out string) ([]string, error) {
+	var testPlans xcodebuildTestPlans
+	if err := json.Unmarshal([]byte(out), &testPlans); err != nil {
+		return nil, fmt.Errorf("failed to parse xcodebuild -showTestPlans output: %w", err)
+	}
+
+	var names []string
+	for _, plan := range testPlans.TestPlans {
+		names = append(names, plan.Name)
+	}
+
+	return names, nil
+}
+
+func printableXcodebuildCommand(args []string) string {
+	return v1command.PrintableCommandArgs(false, append([]string{"xcodebuild"}, args...))
+}
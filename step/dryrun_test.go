@@ -0,0 +1,88 @@
+package step
+
+import (
+	"testing"
+
+	commonMocks "github.com/bitrise-steplib/steps-xcode-test/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const xcodebuildListOutput = `{
+  "workspace" : {
+    "name" : "BullsEye",
+    "schemes" : [
+      "BullsEye",
+      "BullsEye-Tests"
+    ]
+  }
+}`
+
+const xcodebuildTestPlansOutput = `{
+  "name" : "BullsEye",
+  "testPlans" : [
+    {
+      "name" : "FullTests"
+    },
+    {
+      "name" : "UnitTests"
+    }
+  ]
+}`
+
+func Test_GivenValidInputs_WhenDryRun_ThenPrintsTheXcodebuildCommands(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{
+		ProjectPath:          "/project/BullsEye.xcworkspace",
+		Scheme:               "BullsEye",
+		TestPlans:            []string{"UnitTests"},
+		ResolvedDestinations: []string{"platform=iOS Simulator,name=iPhone 15,OS=17.5"},
+	}
+	mockXcodebuildQuery(mocks, []string{"-list", "-json", "-workspace", cfg.ProjectPath}, xcodebuildListOutput)
+	mockXcodebuildQuery(mocks, []string{"-showTestPlans", "-json", "-scheme", cfg.Scheme, "-workspace", cfg.ProjectPath}, xcodebuildTestPlansOutput)
+	mocks.pathProvider.On("CreateTempDir", "XCUITestOutput").Return("/tmp/XCUITestOutput", nil)
+	mocks.xcodebuilder.On("BuildForTestingArgs", mock.Anything).Return([]string{"build-for-testing"}, nil)
+	mocks.xcodebuilder.On("TestWithoutBuildingArgs", mock.Anything).Return([]string{"test-without-building"}, nil)
+
+	// When
+	err := step.DryRun(cfg)
+
+	// Then
+	require.NoError(t, err)
+	mocks.commandFactory.AssertExpectations(t)
+}
+
+func Test_GivenMissingScheme_WhenDryRun_ThenListsTheAvailableSchemes(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{ProjectPath: "/project/BullsEye.xcworkspace", Scheme: "BullsEye-UITests"}
+	mockXcodebuildQuery(mocks, []string{"-list", "-json", "-workspace", cfg.ProjectPath}, xcodebuildListOutput)
+
+	// When
+	err := step.DryRun(cfg)
+
+	// Then
+	require.EqualError(t, err, "'Scheme' (scheme) BullsEye-UITests is not found in the project (/project/BullsEye.xcworkspace), available schemes: BullsEye, BullsEye-Tests")
+}
+
+func Test_GivenMissingTestPlan_WhenDryRun_ThenListsTheAvailableTestPlans(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{ProjectPath: "/project/BullsEye.xcodeproj", Scheme: "BullsEye", TestPlans: []string{"UITests"}}
+	mockXcodebuildQuery(mocks, []string{"-list", "-json", "-project", cfg.ProjectPath}, `{"project": {"schemes": ["BullsEye"]}}`)
+	mockXcodebuildQuery(mocks, []string{"-showTestPlans", "-json", "-scheme", cfg.Scheme, "-project", cfg.ProjectPath}, xcodebuildTestPlansOutput)
+
+	// When
+	err := step.DryRun(cfg)
+
+	// Then
+	require.EqualError(t, err, "'Test Plan' (test_plan) UITests is not found in the scheme (BullsEye), available test plans: FullTests, UnitTests")
+}
+
+func mockXcodebuildQuery(mocks stepMocks, args []string, output string) {
+	cmd := new(commonMocks.Command)
+	cmd.On("PrintableCommandArgs").Return("")
+	cmd.On("RunAndReturnTrimmedOutput").Return(output, nil)
+	mocks.commandFactory.On("Create", "xcodebuild", args, mock.Anything).Return(cmd)
+}
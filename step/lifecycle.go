@@ -0,0 +1,117 @@
+package step
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+)
+
+type simulatorLifecycle string
+
+const (
+	// simulatorLifecycleReuse runs the tests on the matching simulator as is.
+	simulatorLifecycleReuse simulatorLifecycle = "reuse"
+	// simulatorLifecycleEraseBefore erases the content and settings of the matching simulator before the test run.
+	simulatorLifecycleEraseBefore simulatorLifecycle = "erase_before"
+	// simulatorLifecycleClone runs the tests on a clone of the matching simulator, the clone is deleted after the test run.
+	simulatorLifecycleClone simulatorLifecycle = "clone"
+	// simulatorLifecycleEphemeral runs the tests on a new simulator with the device type and runtime of the matching simulator,
+	// the new simulator is deleted after the test run.
+	simulatorLifecycleEphemeral simulatorLifecycle = "ephemeral"
+)
+
+/*
+applySimulatorLifecycle prepares a clean simulator for every simulator destination according to the Simulator Lifecycle
+(simulator_lifecycle) input, and returns the config with the prepared simulators.
+
+The simulators created by the step (clone and ephemeral modes) are returned even if the preparation fails,
+so that they can be deleted.
+*/
+func (s XcodeTestRunner) applySimulatorLifecycle(cfg Config) (Config, []destination.Device, error) {
+	if cfg.SimulatorLifecycle == "" || cfg.SimulatorLifecycle == simulatorLifecycleReuse {
+		return cfg, nil, nil
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Preparing a clean simulator (%s)", cfg.SimulatorLifecycle)
+
+	var sims, createdSims []destination.Device
+	for _, sim := range cfg.simulators() {
+		var prepared destination.Device
+		var err error
+		switch cfg.SimulatorLifecycle {
+		case simulatorLifecycleEraseBefore:
+			prepared, err = s.eraseSimulator(sim)
+		case simulatorLifecycleClone:
+			prepared, err = s.cloneSimulator(sim)
+		case simulatorLifecycleEphemeral:
+			prepared, err = s.simulatorManager.Create(sim, fmt.Sprintf("%s (ephemeral)", sim.Name))
+		default:
+			return cfg, createdSims, fmt.Errorf("unknown simulator lifecycle: %s", cfg.SimulatorLifecycle)
+		}
+		if err != nil {
+			return cfg, createdSims, err
+		}
+
+		if cfg.SimulatorLifecycle != simulatorLifecycleEraseBefore {
+			createdSims = append(createdSims, prepared)
+		}
+		sims = append(sims, prepared)
+
+		s.logger.Printf("%s (%s)", prepared.Name, prepared.UDID)
+	}
+
+	cfg.Simulator = sims[0]
+	if cfg.SimulatorLifecycle != simulatorLifecycleEraseBefore {
+		// Clones and ephemeral Simulators are new devices, the user's device keeps its booted state after the test run.
+		cfg.IsSimulatorBooted = false
+	}
+	cfg.AdditionalSimulators = nil
+	if len(sims) > 1 {
+		cfg.AdditionalSimulators = sims[1:]
+	}
+
+	return cfg, createdSims, nil
+}
+
+func (s XcodeTestRunner) eraseSimulator(sim destination.Device) (destination.Device, error) {
+	// Only shut down simulators can be erased.
+	if sim.State != simulatorShutdownState {
+		if err := s.simulatorManager.Shutdown(sim.UDID); err != nil {
+			s.logger.Warnf("Failed to shut down simulator before erasing: %s", err)
+		}
+	}
+
+	if err := s.simulatorManager.Erase(sim.UDID); err != nil {
+		return destination.Device{}, fmt.Errorf("failed to erase simulator: %w", err)
+	}
+
+	sim.State = simulatorShutdownState
+
+	return sim, nil
+}
+
+func (s XcodeTestRunner) cloneSimulator(sim destination.Device) (destination.Device, error) {
+	// Only shut down simulators can be cloned.
+	if sim.State != simulatorShutdownState {
+		if err := s.simulatorManager.Shutdown(sim.UDID); err != nil {
+			s.logger.Warnf("Failed to shut down simulator before cloning: %s", err)
+		}
+	}
+
+	return s.simulatorManager.Clone(sim, fmt.Sprintf("%s (clone)", sim.Name))
+}
+
+// discardSimulators shuts down and deletes the simulators created by the step.
+func (s XcodeTestRunner) discardSimulators(devices []destination.Device) {
+	s.shutdownSimulators(devices)
+	s.deleteSimulators(devices)
+}
+
+func (s XcodeTestRunner) shutdownSimulators(devices []destination.Device) {
+	for _, device := range devices {
+		if err := s.simulatorManager.Shutdown(device.UDID); err != nil {
+			s.logger.Debugf("Failed to shut down simulator: %s", err)
+		}
+	}
+}
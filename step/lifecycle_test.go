@@ -0,0 +1,120 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenEraseBeforeLifecycle_WhenApplied_ThenErasesTheBootedSimulator(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	sim := destination.Device{Name: "iPhone 15", UDID: "1234", State: "Booted"}
+
+	mocks.simulatorManager.On("Shutdown", sim.UDID).Return(nil)
+	mocks.simulatorManager.On("Erase", sim.UDID).Return(nil)
+
+	cfg := Config{Simulator: sim, IsSimulatorBooted: true, SimulatorLifecycle: simulatorLifecycleEraseBefore}
+
+	// When
+	lifecycleCfg, createdSims, err := step.applySimulatorLifecycle(cfg)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, createdSims)
+	require.Equal(t, "1234", lifecycleCfg.Simulator.UDID)
+	require.Equal(t, simulatorShutdownState, lifecycleCfg.Simulator.State)
+	require.True(t, lifecycleCfg.IsSimulatorBooted)
+}
+
+func Test_GivenCloneLifecycle_WhenApplied_ThenReplacesEverySimulatorWithAClone(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	sim := destination.Device{Name: "iPhone 15", UDID: "1234", State: "Shutdown"}
+	additionalSim := destination.Device{Name: "iPad Air", UDID: "5678", State: "Booted"}
+	clone := destination.Device{Name: "iPhone 15 (clone)", UDID: "clone-1234", State: "Shutdown"}
+	additionalClone := destination.Device{Name: "iPad Air (clone)", UDID: "clone-5678", State: "Shutdown"}
+
+	mocks.simulatorManager.On("Clone", sim, "iPhone 15 (clone)").Return(clone, nil)
+	mocks.simulatorManager.On("Shutdown", additionalSim.UDID).Return(nil)
+	mocks.simulatorManager.On("Clone", additionalSim, "iPad Air (clone)").Return(additionalClone, nil)
+
+	cfg := Config{Simulator: sim, IsSimulatorBooted: true, AdditionalSimulators: []destination.Device{additionalSim}, SimulatorLifecycle: simulatorLifecycleClone}
+
+	// When
+	lifecycleCfg, createdSims, err := step.applySimulatorLifecycle(cfg)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []destination.Device{clone, additionalClone}, createdSims)
+	require.Equal(t, clone, lifecycleCfg.Simulator)
+	require.False(t, lifecycleCfg.IsSimulatorBooted)
+	require.Equal(t, []destination.Device{additionalClone}, lifecycleCfg.AdditionalSimulators)
+	mocks.simulatorManager.AssertNotCalled(t, "Shutdown", sim.UDID)
+}
+
+func Test_GivenEphemeralLifecycle_WhenRuns_ThenTestsOnANewSimulatorAndDeletesIt(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	sim := destination.Device{Name: "iPhone 15", UDID: "1234", State: "Booted", TypeIdentifier: "com.apple.CoreSimulator.SimDeviceType.iPhone-15"}
+	ephemeral := destination.Device{Name: "iPhone 15 (ephemeral)", UDID: "5678", State: "Shutdown", TypeIdentifier: sim.TypeIdentifier}
+
+	mocks.simulatorManager.On("Create", sim, "iPhone 15 (ephemeral)").Return(ephemeral, nil)
+	mocks.simulatorManager.On("ResetLaunchServices").Return(nil)
+	mocks.simulatorManager.On("Shutdown", ephemeral.UDID).Return(nil)
+	mocks.simulatorManager.On("Delete", ephemeral.UDID).Return(nil)
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return len(params.TestParams.Destinations) == 1 && params.TestParams.Destinations[0] == "id=5678"
+	})).Return("", 0, nil)
+	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
+	mocks.pathProvider.On("CreateTempDir", mock.Anything).Return("tmp_dir", nil)
+	mocks.xcresultProcessor.On("ParseTestResults", mock.Anything, false).Return(nil, nil, nil)
+
+	config := Config{
+		ProjectPath: "./project.xcodeproj",
+		Scheme:      "Project",
+
+		Simulator:          sim,
+		IsSimulatorBooted:  true,
+		SimulatorLifecycle: simulatorLifecycleEphemeral,
+
+		TestRepetitionMode: "none",
+		LogFormatter:       "xcodebuild",
+
+		CollectSimulatorDiagnostics: never,
+		HeadlessMode:                true,
+	}
+
+	// When
+	_, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	mocks.simulatorManager.AssertCalled(t, "Delete", ephemeral.UDID)
+	mocks.simulatorManager.AssertNotCalled(t, "Delete", sim.UDID)
+}
+
+func Test_GivenSecondCloneFails_WhenApplied_ThenReturnsTheCreatedClonesForCleanup(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	sim := destination.Device{Name: "iPhone 15", UDID: "1234", State: "Shutdown"}
+	additionalSim := destination.Device{Name: "iPad Air", UDID: "5678", State: "Shutdown"}
+	clone := destination.Device{Name: "iPhone 15 (clone)", UDID: "clone-1234", State: "Shutdown"}
+
+	mocks.simulatorManager.On("Clone", sim, "iPhone 15 (clone)").Return(clone, nil)
+	mocks.simulatorManager.On("Clone", additionalSim, "iPad Air (clone)").Return(destination.Device{}, errors.New("failed to clone Simulator (5678)"))
+
+	cfg := Config{Simulator: sim, AdditionalSimulators: []destination.Device{additionalSim}, SimulatorLifecycle: simulatorLifecycleClone}
+
+	// When
+	_, createdSims, err := step.applySimulatorLifecycle(cfg)
+
+	// Then
+	require.EqualError(t, err, "failed to clone Simulator (5678)")
+	require.Equal(t, []destination.Device{clone}, createdSims)
+}
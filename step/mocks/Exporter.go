@@ -2,24 +2,69 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	crashreport "github.com/bitrise-steplib/steps-xcode-test/crashreport"
+	mock "github.com/stretchr/testify/mock"
+
+	model3 "github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+
+	output "github.com/bitrise-steplib/steps-xcode-test/output"
+
+	time "time"
+)
 
 // Exporter is an autogenerated mock type for the Exporter type
 type Exporter struct {
 	mock.Mock
 }
 
-// ExportFlakyTestCases provides a mock function with given fields: xcResultPath, useOldXCResultExtractionMethod
-func (_m *Exporter) ExportFlakyTestCases(xcResultPath string, useOldXCResultExtractionMethod bool) error {
-	ret := _m.Called(xcResultPath, useOldXCResultExtractionMethod)
+// ExportCrashReports provides a mock function with given fields: deployDir, crashReportPaths
+func (_m *Exporter) ExportCrashReports(deployDir string, crashReportPaths []string) error {
+	ret := _m.Called(deployDir, crashReportPaths)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportCrashReports")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(deployDir, crashReportPaths)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportFlakyTestCases provides a mock function with given fields: deployDir, testData, testSummary
+func (_m *Exporter) ExportFlakyTestCases(deployDir string, testData model3.TestData, testSummary model3.TestSummary) error {
+	ret := _m.Called(deployDir, testData, testSummary)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ExportFlakyTestCases")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, bool) error); ok {
-		r0 = rf(xcResultPath, useOldXCResultExtractionMethod)
+	if rf, ok := ret.Get(0).(func(string, model3.TestData, model3.TestSummary) error); ok {
+		r0 = rf(deployDir, testData, testSummary)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportJUnitReport provides a mock function with given fields: deployDir, testData, testSummary, crashReports
+func (_m *Exporter) ExportJUnitReport(deployDir string, testData model3.TestData, testSummary model3.TestSummary, crashReports []crashreport.Report) error {
+	ret := _m.Called(deployDir, testData, testSummary, crashReports)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportJUnitReport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, model3.TestData, model3.TestSummary, []crashreport.Report) error); ok {
+		r0 = rf(deployDir, testData, testSummary, crashReports)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -45,16 +90,139 @@ func (_m *Exporter) ExportSimulatorDiagnostics(deployDir string, pth string, nam
 	return r0
 }
 
+// ExportTestSummary provides a mock function with given fields: deployDir, testData, testSummary, crashReports
+func (_m *Exporter) ExportTestSummary(deployDir string, testData model3.TestData, testSummary model3.TestSummary, crashReports []crashreport.Report) error {
+	ret := _m.Called(deployDir, testData, testSummary, crashReports)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportTestSummary")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, model3.TestData, model3.TestSummary, []crashreport.Report) error); ok {
+		r0 = rf(deployDir, testData, testSummary, crashReports)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportQuarantinedTestResults provides a mock function with given fields: deployDir, results
+func (_m *Exporter) ExportQuarantinedTestResults(deployDir string, results []output.QuarantinedTestResult) error {
+	ret := _m.Called(deployDir, results)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportQuarantinedTestResults")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []output.QuarantinedTestResult) error); ok {
+		r0 = rf(deployDir, results)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportTestPlanResults provides a mock function with given fields: deployDir, xcResultPath, scheme, testPlanResults
+func (_m *Exporter) ExportTestPlanResults(deployDir string, xcResultPath string, scheme string, testPlanResults []output.TestPlanResult) error {
+	ret := _m.Called(deployDir, xcResultPath, scheme, testPlanResults)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportTestPlanResults")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, []output.TestPlanResult) error); ok {
+		r0 = rf(deployDir, xcResultPath, scheme, testPlanResults)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ExportTestRunResult provides a mock function with given fields: failed
 func (_m *Exporter) ExportTestRunResult(failed bool) {
 	_m.Called(failed)
 }
 
+// ExportSimulatorAppLog provides a mock function with given fields: deployDir, appLog
+func (_m *Exporter) ExportSimulatorAppLog(deployDir string, appLog string) error {
+	ret := _m.Called(deployDir, appLog)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportSimulatorAppLog")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(deployDir, appLog)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportSimulatorVideos provides a mock function with given fields: deployDir, videoPaths
+func (_m *Exporter) ExportSimulatorVideos(deployDir string, videoPaths []string) error {
+	ret := _m.Called(deployDir, videoPaths)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportSimulatorVideos")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(deployDir, videoPaths)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportTestFailureCategory provides a mock function with given fields: category
+func (_m *Exporter) ExportTestFailureCategory(category string) {
+	_m.Called(category)
+}
+
+// ExportResolvedDestinations provides a mock function with given fields: destinations
+func (_m *Exporter) ExportResolvedDestinations(destinations []string) {
+	_m.Called(destinations)
+}
+
+// ExportSimulatorBootDuration provides a mock function with given fields: duration
+func (_m *Exporter) ExportSimulatorBootDuration(duration time.Duration) {
+	_m.Called(duration)
+}
+
 // ExportXCResultBundle provides a mock function with given fields: deployDir, xcResultPath, scheme
 func (_m *Exporter) ExportXCResultBundle(deployDir string, xcResultPath string, scheme string) {
 	_m.Called(deployDir, xcResultPath, scheme)
 }
 
+// ExportXCResultAttempts provides a mock function with given fields: deployDir, xcResultPaths, scheme
+func (_m *Exporter) ExportXCResultAttempts(deployDir string, xcResultPaths []string, scheme string) error {
+	ret := _m.Called(deployDir, xcResultPaths, scheme)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportXCResultAttempts")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string, string) error); ok {
+		r0 = rf(deployDir, xcResultPaths, scheme)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ExportXcodebuildBuildLog provides a mock function with given fields: deployDir, xcodebuildBuildLog
 func (_m *Exporter) ExportXcodebuildBuildLog(deployDir string, xcodebuildBuildLog string) error {
 	ret := _m.Called(deployDir, xcodebuildBuildLog)
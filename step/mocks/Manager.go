@@ -6,6 +6,8 @@ import (
 	destination "github.com/bitrise-io/go-xcode/v2/destination"
 	mock "github.com/stretchr/testify/mock"
 
+	simulator "github.com/bitrise-steplib/steps-xcode-test/simulator"
+
 	time "time"
 )
 
@@ -14,6 +16,34 @@ type SimulatorManager struct {
 	mock.Mock
 }
 
+// AddMedia provides a mock function with given fields: id, paths
+func (_m *SimulatorManager) AddMedia(id string, paths []string) error {
+	ret := _m.Called(id, paths)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(id, paths)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddRootCertificate provides a mock function with given fields: id, certificatePath
+func (_m *SimulatorManager) AddRootCertificate(id string, certificatePath string) error {
+	ret := _m.Called(id, certificatePath)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(id, certificatePath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Boot provides a mock function with given fields: device
 func (_m *SimulatorManager) Boot(device destination.Device) error {
 	ret := _m.Called(device)
@@ -28,6 +58,30 @@ func (_m *SimulatorManager) Boot(device destination.Device) error {
 	return r0
 }
 
+// Clone provides a mock function with given fields: device, name
+func (_m *SimulatorManager) Clone(device destination.Device, name string) (destination.Device, error) {
+	ret := _m.Called(device, name)
+
+	var r0 destination.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(destination.Device, string) (destination.Device, error)); ok {
+		return rf(device, name)
+	}
+	if rf, ok := ret.Get(0).(func(destination.Device, string) destination.Device); ok {
+		r0 = rf(device, name)
+	} else {
+		r0 = ret.Get(0).(destination.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(destination.Device, string) error); ok {
+		r1 = rf(device, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CollectDiagnostics provides a mock function with given fields:
 func (_m *SimulatorManager) CollectDiagnostics() (string, error) {
 	ret := _m.Called()
@@ -52,6 +106,44 @@ func (_m *SimulatorManager) CollectDiagnostics() (string, error) {
 	return r0, r1
 }
 
+// Create provides a mock function with given fields: device, name
+func (_m *SimulatorManager) Create(device destination.Device, name string) (destination.Device, error) {
+	ret := _m.Called(device, name)
+
+	var r0 destination.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(destination.Device, string) (destination.Device, error)); ok {
+		return rf(device, name)
+	}
+	if rf, ok := ret.Get(0).(func(destination.Device, string) destination.Device); ok {
+		r0 = rf(device, name)
+	} else {
+		r0 = ret.Get(0).(destination.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(destination.Device, string) error); ok {
+		r1 = rf(device, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: id
+func (_m *SimulatorManager) Delete(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // EnableVerboseLog provides a mock function with given fields: id
 func (_m *SimulatorManager) EnableVerboseLog(id string) error {
 	ret := _m.Called(id)
@@ -94,6 +186,20 @@ func (_m *SimulatorManager) LaunchWithGUI(simulatorID string) error {
 	return r0
 }
 
+// OverrideStatusBar provides a mock function with given fields: id, override
+func (_m *SimulatorManager) OverrideStatusBar(id string, override simulator.StatusBarOverride) error {
+	ret := _m.Called(id, override)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, simulator.StatusBarOverride) error); ok {
+		r0 = rf(id, override)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResetLaunchServices provides a mock function with given fields:
 func (_m *SimulatorManager) ResetLaunchServices() error {
 	ret := _m.Called()
@@ -108,6 +214,48 @@ func (_m *SimulatorManager) ResetLaunchServices() error {
 	return r0
 }
 
+// SetLanguage provides a mock function with given fields: id, language, locale
+func (_m *SimulatorManager) SetLanguage(id string, language string, locale string) error {
+	ret := _m.Called(id, language, locale)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(id, language, locale)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetLocation provides a mock function with given fields: id, location
+func (_m *SimulatorManager) SetLocation(id string, location simulator.Location) error {
+	ret := _m.Called(id, location)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, simulator.Location) error); ok {
+		r0 = rf(id, location)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPrivacy provides a mock function with given fields: id, setting
+func (_m *SimulatorManager) SetPrivacy(id string, setting simulator.PrivacySetting) error {
+	ret := _m.Called(id, setting)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, simulator.PrivacySetting) error); ok {
+		r0 = rf(id, setting)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Shutdown provides a mock function with given fields: id
 func (_m *SimulatorManager) Shutdown(id string) error {
 	ret := _m.Called(id)
@@ -122,6 +270,58 @@ func (_m *SimulatorManager) Shutdown(id string) error {
 	return r0
 }
 
+// StartLogStream provides a mock function with given fields: id, predicate, outputPath
+func (_m *SimulatorManager) StartLogStream(id string, predicate string, outputPath string) (simulator.LogStream, error) {
+	ret := _m.Called(id, predicate, outputPath)
+
+	var r0 simulator.LogStream
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, string) (simulator.LogStream, error)); ok {
+		return rf(id, predicate, outputPath)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, string) simulator.LogStream); ok {
+		r0 = rf(id, predicate, outputPath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(simulator.LogStream)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(id, predicate, outputPath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartVideoRecording provides a mock function with given fields: id, outputPath
+func (_m *SimulatorManager) StartVideoRecording(id string, outputPath string) (simulator.VideoRecording, error) {
+	ret := _m.Called(id, outputPath)
+
+	var r0 simulator.VideoRecording
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (simulator.VideoRecording, error)); ok {
+		return rf(id, outputPath)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) simulator.VideoRecording); ok {
+		r0 = rf(id, outputPath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(simulator.VideoRecording)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(id, outputPath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WaitForBootFinished provides a mock function with given fields: id, timeout
 func (_m *SimulatorManager) WaitForBootFinished(id string, timeout time.Duration) error {
 	ret := _m.Called(id, timeout)
@@ -136,6 +336,20 @@ func (_m *SimulatorManager) WaitForBootFinished(id string, timeout time.Duration
 	return r0
 }
 
+// WaitForBootStatus provides a mock function with given fields: id, timeout
+func (_m *SimulatorManager) WaitForBootStatus(id string, timeout time.Duration) error {
+	ret := _m.Called(id, timeout)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, time.Duration) error); ok {
+		r0 = rf(id, timeout)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewSimulatorManager creates a new instance of SimulatorManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewSimulatorManager(t interface {
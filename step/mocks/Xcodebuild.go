@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	log "github.com/bitrise-io/go-utils/v2/log"
 	xcodebuild "github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
 	xcodecommand "github.com/bitrise-io/go-xcode/v2/xcodecommand"
 	mock "github.com/stretchr/testify/mock"
@@ -13,6 +14,89 @@ type Xcodebuild struct {
 	mock.Mock
 }
 
+// BuildForTesting provides a mock function with given fields: params
+func (_m *Xcodebuild) BuildForTesting(params xcodebuild.TestRunParams) (string, int, error) {
+	ret := _m.Called(params)
+
+	var r0 string
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams) (string, int, error)); ok {
+		return rf(params)
+	}
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams) string); ok {
+		r0 = rf(params)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(xcodebuild.TestRunParams) int); ok {
+		r1 = rf(params)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(xcodebuild.TestRunParams) error); ok {
+		r2 = rf(params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// BuildForTestingArgs provides a mock function with given fields: params
+func (_m *Xcodebuild) BuildForTestingArgs(params xcodebuild.TestRunParams) ([]string, error) {
+	ret := _m.Called(params)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams) ([]string, error)); ok {
+		return rf(params)
+	}
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams) []string); ok {
+		r0 = rf(params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(xcodebuild.TestRunParams) error); ok {
+		r1 = rf(params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnumerateTests provides a mock function with given fields: params, outputPath
+func (_m *Xcodebuild) EnumerateTests(params xcodebuild.TestRunParams, outputPath string) ([]string, error) {
+	ret := _m.Called(params, outputPath)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams, string) ([]string, error)); ok {
+		return rf(params, outputPath)
+	}
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams, string) []string); ok {
+		r0 = rf(params, outputPath)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(xcodebuild.TestRunParams, string) error); ok {
+		r1 = rf(params, outputPath)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetXcodeCommadRunner provides a mock function with given fields:
 func (_m *Xcodebuild) GetXcodeCommadRunner() xcodecommand.Runner {
 	ret := _m.Called()
@@ -29,8 +113,13 @@ func (_m *Xcodebuild) GetXcodeCommadRunner() xcodecommand.Runner {
 	return r0
 }
 
-// RunTest provides a mock function with given fields: params
-func (_m *Xcodebuild) RunTest(params xcodebuild.TestRunParams) (string, int, error) {
+// SetXcodeCommandRunner provides a mock function with given fields: runner
+func (_m *Xcodebuild) SetXcodeCommandRunner(runner xcodecommand.Runner) {
+	_m.Called(runner)
+}
+
+// TestWithoutBuilding provides a mock function with given fields: params
+func (_m *Xcodebuild) TestWithoutBuilding(params xcodebuild.TestRunParams) (string, int, error) {
 	ret := _m.Called(params)
 
 	var r0 string
@@ -60,9 +149,46 @@ func (_m *Xcodebuild) RunTest(params xcodebuild.TestRunParams) (string, int, err
 	return r0, r1, r2
 }
 
-// SetXcodeCommandRunner provides a mock function with given fields: runner
-func (_m *Xcodebuild) SetXcodeCommandRunner(runner xcodecommand.Runner) {
-	_m.Called(runner)
+// TestWithoutBuildingArgs provides a mock function with given fields: params
+func (_m *Xcodebuild) TestWithoutBuildingArgs(params xcodebuild.TestRunParams) ([]string, error) {
+	ret := _m.Called(params)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams) ([]string, error)); ok {
+		return rf(params)
+	}
+	if rf, ok := ret.Get(0).(func(xcodebuild.TestRunParams) []string); ok {
+		r0 = rf(params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(xcodebuild.TestRunParams) error); ok {
+		r1 = rf(params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WithCommandRunner provides a mock function with given fields: logger, runner
+func (_m *Xcodebuild) WithCommandRunner(logger log.Logger, runner xcodecommand.Runner) xcodebuild.Xcodebuild {
+	ret := _m.Called(logger, runner)
+
+	var r0 xcodebuild.Xcodebuild
+	if rf, ok := ret.Get(0).(func(log.Logger, xcodecommand.Runner) xcodebuild.Xcodebuild); ok {
+		r0 = rf(logger, runner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(xcodebuild.Xcodebuild)
+		}
+	}
+
+	return r0
 }
 
 // NewXcodebuild creates a new instance of Xcodebuild. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
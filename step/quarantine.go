@@ -0,0 +1,336 @@
+package step
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/output"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
+	"gopkg.in/yaml.v3"
+)
+
+// quarantineExpiryLayout is the date format of the quarantine expiry dates.
+const quarantineExpiryLayout = "2006-01-02"
+
+// quarantineEntry is a test quarantined by the Quarantine file (quarantine_file).
+type quarantineEntry struct {
+	Test    string `yaml:"test"`
+	Owner   string `yaml:"owner"`
+	Reason  string `yaml:"reason"`
+	Expires string `yaml:"expires"`
+}
+
+// UnmarshalYAML allows listing a quarantined test by its test identifier only.
+func (e *quarantineEntry) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Test = value.Value
+		return nil
+	}
+
+	type plainEntry quarantineEntry
+	return value.Decode((*plainEntry)(e))
+}
+
+func (e quarantineEntry) description() string {
+	var details []string
+	if e.Owner != "" {
+		details = append(details, "owner: "+e.Owner)
+	}
+	if e.Reason != "" {
+		details = append(details, "reason: "+e.Reason)
+	}
+	if e.Expires != "" {
+		details = append(details, "expires: "+e.Expires)
+	}
+
+	if len(details) == 0 {
+		return e.Test
+	}
+	return fmt.Sprintf("%s (%s)", e.Test, strings.Join(details, ", "))
+}
+
+/*
+processQuarantineFile converts the tests of the Quarantine file (quarantine_file) to test identifiers for the
+`-skip-testing` xcodebuild option, and the `tag:` prefixed entries to Swift Testing tags for the `-skip-test-tags` option.
+Expired quarantines are reported and the tests are not skipped anymore.
+*/
+func (s XcodeTestConfigParser) processQuarantineFile(pth string) (testSelection, error) {
+	if pth == "" {
+		return testSelection{}, nil
+	}
+
+	absPth, err := s.pathModifier.AbsPath(pth)
+	if err != nil {
+		return testSelection{}, fmt.Errorf("failed to get absolute Quarantine file (quarantine_file) path: %w", err)
+	}
+
+	content, err := os.ReadFile(absPth)
+	if err != nil {
+		return testSelection{}, fmt.Errorf("failed to read Quarantine file (quarantine_file): %w", err)
+	}
+
+	entries, err := parseQuarantineFile(content, filepath.Ext(absPth))
+	if err != nil {
+		return testSelection{}, fmt.Errorf("invalid Quarantine file (quarantine_file): %w", err)
+	}
+
+	active, expired, err := splitExpiredQuarantineEntries(entries, time.Now())
+	if err != nil {
+		return testSelection{}, fmt.Errorf("invalid Quarantine file (quarantine_file): %w", err)
+	}
+
+	for _, entry := range expired {
+		s.logger.Warnf("Quarantine expired, the test is run again: %s", entry.description())
+	}
+
+	if len(active) == 0 {
+		return testSelection{}, nil
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Quarantined tests:")
+	var tests []string
+	for _, entry := range active {
+		s.logger.Printf("- %s", entry.description())
+		tests = append(tests, entry.Test)
+	}
+
+	quarantined, err := parseTestSelectionEntries(tests)
+	if err != nil {
+		return testSelection{}, fmt.Errorf("invalid Quarantine file (quarantine_file): %w", err)
+	}
+
+	return quarantined, nil
+}
+
+/*
+parseQuarantineFile parses the quarantined tests of a YAML (or JSON) file, if the file extension is .yml, .yaml or .json:
+
+	# quarantine.yml
+	- BullsEyeTests/BullsEyeTests/testFlakyAnimation
+	- tag:networking
+	- test: BullsEyeUITests/*
+	  owner: ui-team
+	  reason: Simulator keyboard issues
+	  expires: 2024-12-31
+
+otherwise the quarantined tests are listed line by line, lines starting with # are comments.
+*/
+func parseQuarantineFile(content []byte, ext string) ([]quarantineEntry, error) {
+	switch strings.ToLower(ext) {
+	case ".yml", ".yaml", ".json":
+		var entries []quarantineEntry
+		if err := yaml.Unmarshal(content, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse quarantined tests: %w", err)
+		}
+
+		for i, entry := range entries {
+			if strings.TrimSpace(entry.Test) == "" {
+				return nil, fmt.Errorf("quarantined test (%d) has no test identifier", i+1)
+			}
+		}
+
+		return entries, nil
+	default:
+		var entries []quarantineEntry
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			entries = append(entries, quarantineEntry{Test: line})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read quarantined tests: %w", err)
+		}
+
+		return entries, nil
+	}
+}
+
+// splitExpiredQuarantineEntries separates the quarantines expired before the given day, the expiry date is the last quarantined day.
+func splitExpiredQuarantineEntries(entries []quarantineEntry, now time.Time) ([]quarantineEntry, []quarantineEntry, error) {
+	var active, expired []quarantineEntry
+	for _, entry := range entries {
+		if entry.Expires == "" {
+			active = append(active, entry)
+			continue
+		}
+
+		expires, err := time.ParseInLocation(quarantineExpiryLayout, entry.Expires, now.Location())
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid expiry date (%s) of %s, should be in YYYY-MM-DD format", entry.Expires, entry.Test)
+		}
+
+		if now.Before(expires.AddDate(0, 0, 1)) {
+			active = append(active, entry)
+		} else {
+			expired = append(expired, entry)
+		}
+	}
+
+	return active, expired, nil
+}
+
+/*
+runQuarantinedTests runs the quarantined tests (with `test-without-building` and `-only-testing`) after the test run,
+if the Run Quarantined Tests (run_quarantined_tests) input is enabled. The tests are repeated until failure up to the
+Quarantined Test Iterations, so that a test passing in every iteration can be unquarantined.
+The tests of the quarantined Swift Testing tags run in a second test run (with `-only-test-tags`).
+
+The quarantined tests run does not affect the result of the Step, its failures are only reported.
+*/
+func (s XcodeTestRunner) runQuarantinedTests(cfg Config, testParams xcodebuild.TestRunParams) []output.QuarantinedTestResult {
+	if !cfg.RunQuarantinedTests || (len(cfg.QuarantinedTests) == 0 && len(cfg.QuarantinedTestTags) == 0) {
+		return nil
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Running %d quarantined test(s), the results do not affect the test result:", len(cfg.QuarantinedTests)+len(cfg.QuarantinedTestTags))
+	for _, test := range cfg.QuarantinedTests {
+		s.logger.Printf("- %s", test)
+	}
+	for _, tag := range cfg.QuarantinedTestTags {
+		s.logger.Printf("- %s%s", testTagPrefix, tag)
+	}
+
+	var results []output.QuarantinedTestResult
+	if len(cfg.QuarantinedTests) > 0 {
+		quarantineParams := quarantinedTestRunParams(cfg, testParams, cfg.testRunName()+"-quarantined.xcresult")
+		quarantineParams.TestParams.OnlyTesting = cfg.QuarantinedTests
+
+		if _, testSummary := s.runQuarantinedTestRun(quarantineParams); testSummary != nil {
+			results = append(results, quarantinedTestResults(cfg.QuarantinedTests, *testSummary, cfg.TestPlan)...)
+		}
+	}
+	if len(cfg.QuarantinedTestTags) > 0 {
+		quarantineParams := quarantinedTestRunParams(cfg, testParams, cfg.testRunName()+"-quarantined-tags.xcresult")
+		quarantineParams.TestParams.OnlyTesting = cfg.OnlyTesting
+		quarantineParams.TestParams.OnlyTestTags = cfg.QuarantinedTestTags
+
+		if testData, testSummary := s.runQuarantinedTestRun(quarantineParams); testSummary != nil {
+			results = append(results, quarantinedTestTagResults(cfg.QuarantinedTestTags, *testSummary, xcresult.TestCaseTags(testData), cfg.TestPlan)...)
+		}
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Quarantined test results:")
+	for _, result := range results {
+		if result.Status == output.QuarantinedTestPassing {
+			s.logger.Donef("- %s", result)
+		} else {
+			s.logger.Printf("- %s", result)
+		}
+	}
+
+	return results
+}
+
+// quarantinedTestRunParams are the test params of a quarantined tests run, writing its results into the given xcresult bundle.
+func quarantinedTestRunParams(cfg Config, testParams xcodebuild.TestRunParams, xcresultName string) xcodebuild.TestRunParams {
+	quarantineParams := testParams
+	quarantineParams.TestParams.TestOutputDir = filepath.Join(filepath.Dir(testParams.TestParams.TestOutputDir), xcresultName)
+	quarantineParams.TestParams.SkipTesting = cfg.SkipTesting
+	quarantineParams.TestParams.SkipTestTags = cfg.SkipTestTags
+	quarantineParams.TestParams.TestRepetitionMode = xcodebuild.TestRepetitionNone
+	quarantineParams.TestParams.MaximumTestRepetitions = 0
+	quarantineParams.TestParams.RelaunchTestsForEachRepetition = false
+	if cfg.QuarantinedTestIterations > 1 {
+		quarantineParams.TestParams.TestRepetitionMode = xcodebuild.TestRepetitionUntilFailure
+		quarantineParams.TestParams.MaximumTestRepetitions = cfg.QuarantinedTestIterations
+	}
+	return quarantineParams
+}
+
+// runQuarantinedTestRun runs the quarantined tests and returns their results, or nil if the results can't be collected.
+func (s XcodeTestRunner) runQuarantinedTestRun(quarantineParams xcodebuild.TestRunParams) (*model3.TestData, *model3.TestSummary) {
+	if _, _, err := s.xcodebuild.TestWithoutBuilding(quarantineParams); err != nil {
+		s.logger.Warnf("Quarantined tests failed: %s", err)
+	}
+
+	testData, testSummary, err := s.xcresultProcessor.ParseTestResults(quarantineParams.TestParams.TestOutputDir, false)
+	if err != nil || testSummary == nil {
+		s.logger.Warnf("Failed to collect the results of the quarantined tests: %s", err)
+		return nil, nil
+	}
+
+	return testData, testSummary
+}
+
+// quarantinedTestResults summarizes the runs of the test cases of every quarantined test, skipped runs are not counted.
+func quarantinedTestResults(quarantinedTests []string, testSummary model3.TestSummary, testPlan string) []output.QuarantinedTestResult {
+	var results []output.QuarantinedTestResult
+	for _, test := range quarantinedTests {
+		results = append(results, quarantinedTestResult(test, testPlan, testSummary, func(bundle string, testCase model3.TestCaseWithRetries) bool {
+			return isTestCaseOf(testCaseIdentifier(bundle, testCase.ClassName, testCase.Name), test)
+		}))
+	}
+
+	return results
+}
+
+// quarantinedTestTagResults summarizes the runs of the test cases of every quarantined Swift Testing tag (as `tag:<tag>`).
+func quarantinedTestTagResults(quarantinedTags []string, testSummary model3.TestSummary, testCaseTags map[string][]string, testPlan string) []output.QuarantinedTestResult {
+	var results []output.QuarantinedTestResult
+	for _, tag := range quarantinedTags {
+		results = append(results, quarantinedTestResult(testTagPrefix+tag, testPlan, testSummary, func(bundle string, testCase model3.TestCaseWithRetries) bool {
+			return slices.Contains(testCaseTags[xcresult.TestCaseKey(bundle, testCase.ClassName, testCase.Name)], tag)
+		}))
+	}
+
+	return results
+}
+
+func quarantinedTestResult(test, testPlan string, testSummary model3.TestSummary, isQuarantined func(bundle string, testCase model3.TestCaseWithRetries) bool) output.QuarantinedTestResult {
+	result := output.QuarantinedTestResult{Test: test, TestPlan: testPlan}
+	for _, summaryTestPlan := range testSummary.TestPlans {
+		for _, testBundle := range summaryTestPlan.TestBundles {
+			for _, testSuite := range testBundle.TestSuites {
+				for _, testCase := range testSuite.TestCases {
+					if !isQuarantined(testBundle.Name, testCase) {
+						continue
+					}
+
+					result.TestCases++
+					runs := testCase.Retries
+					if len(runs) == 0 {
+						runs = []model3.TestCase{testCase.TestCase}
+					}
+					for _, run := range runs {
+						switch run.Result {
+						case model3.TestResultPassed:
+							result.Runs++
+						case model3.TestResultFailed:
+							result.Runs++
+							result.Failures++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	switch {
+	case result.Runs == 0:
+		result.Status = output.QuarantinedTestNotRun
+	case result.Failures > 0:
+		result.Status = output.QuarantinedTestFailing
+	default:
+		result.Status = output.QuarantinedTestPassing
+	}
+
+	return result
+}
@@ -0,0 +1,224 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/output"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseQuarantineFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		ext       string
+		want      []quarantineEntry
+		wantError string
+	}{
+		{
+			name: "YAML",
+			content: `
+- BullsEyeTests/BullsEyeTests/testFlakyAnimation
+- test: BullsEyeUITests/*
+  owner: ui-team
+  reason: Simulator keyboard issues
+  expires: 2024-12-31
+`,
+			ext: ".yml",
+			want: []quarantineEntry{
+				{Test: "BullsEyeTests/BullsEyeTests/testFlakyAnimation"},
+				{Test: "BullsEyeUITests/*", Owner: "ui-team", Reason: "Simulator keyboard issues", Expires: "2024-12-31"},
+			},
+		},
+		{
+			name:    "JSON",
+			content: `[{"test": "BullsEyeTests/BullsEyeTests/*", "owner": "core-team"}]`,
+			ext:     ".json",
+			want:    []quarantineEntry{{Test: "BullsEyeTests/BullsEyeTests/*", Owner: "core-team"}},
+		},
+		{
+			name:    "Plain text",
+			content: "# Flaky on CI\nBullsEyeTests/BullsEyeTests/testFlakyAnimation\n\n  BullsEyeUITests  \n",
+			ext:     ".txt",
+			want: []quarantineEntry{
+				{Test: "BullsEyeTests/BullsEyeTests/testFlakyAnimation"},
+				{Test: "BullsEyeUITests"},
+			},
+		},
+		{
+			name:      "Missing test identifier",
+			content:   `- owner: ui-team`,
+			ext:       ".yaml",
+			wantError: "quarantined test (1) has no test identifier",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQuarantineFile([]byte(tt.content), tt.ext)
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_splitExpiredQuarantineEntries(t *testing.T) {
+	now := time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC)
+	entries := []quarantineEntry{
+		{Test: "BullsEyeTests"},
+		{Test: "BullsEyeUITests", Expires: "2024-12-31"},
+		{Test: "BullsEyeSlowTests", Expires: "2024-12-30"},
+	}
+
+	active, expired, err := splitExpiredQuarantineEntries(entries, now)
+
+	require.NoError(t, err)
+	require.Equal(t, entries[:2], active)
+	require.Equal(t, entries[2:], expired)
+
+	_, _, err = splitExpiredQuarantineEntries([]quarantineEntry{{Test: "BullsEyeTests", Expires: "31/12/2024"}}, now)
+	require.EqualError(t, err, "invalid expiry date (31/12/2024) of BullsEyeTests, should be in YYYY-MM-DD format")
+}
+
+func Test_GivenQuarantineFile_WhenProcessConfig_ThenSkipsTheQuarantinedTests(t *testing.T) {
+	// Given
+	quarantineFile := filepath.Join(t.TempDir(), "quarantine.yml")
+	content := `
+- BullsEyeTests/BullsEyeTests/*
+- test: Target2/Class2/Method1()
+  owner: core-team
+- test: BullsEyeUITests
+  expires: 2020-01-31
+`
+	require.NoError(t, os.WriteFile(quarantineFile, []byte(content), 0644))
+
+	envValues := defaultEnvValues()
+	envValues["quarantined_tests"] = `[{"testCaseName": "Method1()", "testSuiteName": ["Target2"], "className": "Class2"}]`
+	envValues["quarantine_file"] = quarantineFile
+	configParser, mocks := createConfigParser(t, envValues)
+	mocks.pathModifier.On("AbsPath", quarantineFile).Return(quarantineFile, nil)
+	mocks.pathModifier.On("AbsPath", mock.Anything).Return("/_tmp/BullsEye.xcworkspace", nil)
+	mocks.deviceFinder.On("FindDevice", mock.Anything, mock.Anything).Return(defaultSimulator(), nil)
+
+	// When
+	config, err := configParser.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{"Target2/Class2/Method1()", "BullsEyeTests/BullsEyeTests"}, config.QuarantinedTests)
+}
+
+func Test_quarantinedTestResults(t *testing.T) {
+	// Given
+	testSummary := testSummaryWithFailedTests("testFailing()")
+	testCases := &testSummary.TestPlans[0].TestBundles[0].TestSuites[0].TestCases
+	*testCases = append(*testCases, model3.TestCaseWithRetries{
+		TestCase: model3.TestCase{Name: "testRepeated()", ClassName: "BullsEyeTests", Result: model3.TestResultPassed},
+		Retries: []model3.TestCase{
+			{Name: "testRepeated()", ClassName: "BullsEyeTests", Result: model3.TestResultPassed},
+			{Name: "testRepeated()", ClassName: "BullsEyeTests", Result: model3.TestResultPassed},
+			{Name: "testRepeated()", ClassName: "BullsEyeTests", Result: model3.TestResultPassed},
+		},
+	})
+	quarantinedTests := []string{"BullsEyeTests/BullsEyeTests/testRepeated", "BullsEyeTests/BullsEyeTests", "BullsEyeUITests"}
+
+	// When
+	results := quarantinedTestResults(quarantinedTests, *testSummary, "UnitTests")
+
+	// Then
+	require.Equal(t, []output.QuarantinedTestResult{
+		{Test: "BullsEyeTests/BullsEyeTests/testRepeated", TestPlan: "UnitTests", Status: output.QuarantinedTestPassing, TestCases: 1, Runs: 3},
+		{Test: "BullsEyeTests/BullsEyeTests", TestPlan: "UnitTests", Status: output.QuarantinedTestFailing, TestCases: 3, Runs: 5, Failures: 1},
+		{Test: "BullsEyeUITests", TestPlan: "UnitTests", Status: output.QuarantinedTestNotRun},
+	}, results)
+}
+
+func Test_GivenQuarantinedTag_WhenProcessConfig_ThenSkipsTheTestsWithTheTag(t *testing.T) {
+	// Given
+	quarantineFile := filepath.Join(t.TempDir(), "quarantine.txt")
+	require.NoError(t, os.WriteFile(quarantineFile, []byte("BullsEyeTests/BullsEyeTests/testFlaky\ntag:networking\n"), 0644))
+
+	envValues := defaultEnvValues()
+	envValues["quarantine_file"] = quarantineFile
+	envValues["skip_testing"] = "tag:slow"
+	configParser, mocks := createConfigParser(t, envValues)
+	mocks.pathModifier.On("AbsPath", quarantineFile).Return(quarantineFile, nil)
+	mocks.pathModifier.On("AbsPath", mock.Anything).Return("/_tmp/BullsEye.xcworkspace", nil)
+	mocks.deviceFinder.On("FindDevice", mock.Anything, mock.Anything).Return(defaultSimulator(), nil)
+
+	// When
+	config, err := configParser.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{"BullsEyeTests/BullsEyeTests/testFlaky"}, config.QuarantinedTests)
+	require.Equal(t, []string{"networking"}, config.QuarantinedTestTags)
+	testParams := NewUtils(nil).CreateTestParams(config, "Test-BullsEye.xcresult", "")
+	require.Equal(t, []string{"slow", "networking"}, testParams.TestParams.SkipTestTags)
+}
+
+func Test_quarantinedTestTagResults(t *testing.T) {
+	// Given
+	testSummary := testSummaryWithFailedTests("testFailing()")
+	testCaseTags := map[string][]string{"BullsEyeTests/BullsEyeTests/testPassing()": {"networking"}}
+
+	// When
+	results := quarantinedTestTagResults([]string{"networking", "slow"}, *testSummary, testCaseTags, "UnitTests")
+
+	// Then
+	require.Equal(t, []output.QuarantinedTestResult{
+		{Test: "tag:networking", TestPlan: "UnitTests", Status: output.QuarantinedTestPassing, TestCases: 1, Runs: 1},
+		{Test: "tag:slow", TestPlan: "UnitTests", Status: output.QuarantinedTestNotRun},
+	}, results)
+}
+
+func Test_GivenRunQuarantinedTests_WhenQuarantinedTestsFail_ThenTheTestRunSucceeds(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{
+		Scheme:                    "BullsEye",
+		TestRepetitionMode:        xcodebuild.TestRepetitionNone,
+		LogFormatter:              "xcbeautify",
+		QuarantinedTests:          []string{"BullsEyeTests/BullsEyeTests/testFailing()"},
+		RunQuarantinedTests:       true,
+		QuarantinedTestIterations: 3,
+	}
+	testParams := xcodebuild.TestRunParams{TestParams: xcodebuild.TestParams{
+		TestOutputDir: "tmp/Test-BullsEye.xcresult",
+		SkipTesting:   cfg.QuarantinedTests,
+	}}
+
+	mocks.xcodebuilder.On("TestWithoutBuilding", testParams).Return("", 0, nil).Once()
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return params.TestParams.TestOutputDir == "tmp/Test-BullsEye-quarantined.xcresult" &&
+			params.TestParams.SkipTesting == nil &&
+			len(params.TestParams.OnlyTesting) == 1 && params.TestParams.OnlyTesting[0] == "BullsEyeTests/BullsEyeTests/testFailing()" &&
+			params.TestParams.TestRepetitionMode == xcodebuild.TestRepetitionUntilFailure &&
+			params.TestParams.MaximumTestRepetitions == 3
+	})).Return("", 65, errors.New("exit status 65")).Once()
+	mocks.xcresultProcessor.On("ParseTestResults", "tmp/Test-BullsEye-quarantined.xcresult", false).
+		Return(nil, testSummaryWithFailedTests("testFailing()"), nil).Once()
+
+	// When
+	result, exitCode, err := step.runBuiltTests(cfg, testParams, Result{})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, []output.QuarantinedTestResult{
+		{Test: "BullsEyeTests/BullsEyeTests/testFailing()", Status: output.QuarantinedTestFailing, TestCases: 1, Runs: 1, Failures: 1},
+	}, result.QuarantinedTestResults)
+	mocks.xcodebuilder.AssertExpectations(t)
+}
@@ -0,0 +1,162 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
+)
+
+/*
+rerunFailedTests implements the `rerun_failed_tests` test repetition mode.
+
+The failed test cases of the previous run are collected from its xcresult bundle and rerun
+(with `test-without-building` and `-only-testing`) until they pass or the number of runs reaches
+the Maximum Test Repetitions. Tests passing on a rerun are reported as flaky and don't fail the step.
+The xcresult bundles of the runs (including the automatically retried ones) are merged into a single bundle.
+
+If Rerun Test Tags (rerun_test_tags) are set, only the failed Swift Testing tests with any of the tags are rerun,
+the other failed tests keep failing the step.
+*/
+func (s XcodeTestRunner) rerunFailedTests(cfg Config, testParams xcodebuild.TestRunParams, result Result, exitCode int, testErr error) (Result, int, error) {
+	outputDir := filepath.Dir(result.XcresultPath)
+	xcresultPaths := append(xcodebuild.PreviousAttemptResultBundles(result.XcresultPath), result.XcresultPath)
+
+	failedTests, notRerunTests, err := s.collectFailedTests(result.XcresultPath, cfg.RerunTestTags)
+	if err != nil {
+		s.logger.Warnf("Failed to collect failed tests, skipping rerun: %s", err)
+	} else if len(failedTests) == 0 && len(notRerunTests) == 0 {
+		s.logger.Warnf("No failed test found in the test results, skipping rerun")
+	}
+
+	if len(notRerunTests) > 0 {
+		s.logger.Println()
+		s.logger.Warnf("%d failed test(s) have none of the Rerun Test Tags (rerun_test_tags), they are not rerun:", len(notRerunTests))
+		for _, test := range notRerunTests {
+			s.logger.Warnf("- %s", test)
+		}
+	}
+	firstExitCode, firstTestErr := exitCode, testErr
+
+	testLogs := []string{result.XcodebuildTestLog}
+	var flakyTests []string
+
+	for run := 2; run <= cfg.MaximumTestRepetitions && len(failedTests) > 0; run++ {
+		s.logger.Println()
+		s.logger.Infof("Rerunning %d failed test(s) (run %d of %d):", len(failedTests), run, cfg.MaximumTestRepetitions)
+		for _, test := range failedTests {
+			s.logger.Printf("- %s", test)
+		}
+
+		rerunParams := testParams
+		rerunParams.TestParams.TestOutputDir = filepath.Join(outputDir, fmt.Sprintf("%s-rerun-%d.xcresult", cfg.testRunName(), run-1))
+		rerunParams.TestParams.OnlyTesting = failedTests
+
+		testLog, rerunExitCode, rerunErr := s.xcodebuild.TestWithoutBuilding(rerunParams)
+		xcresultPaths = append(xcresultPaths, xcodebuild.PreviousAttemptResultBundles(rerunParams.TestParams.TestOutputDir)...)
+		xcresultPaths = append(xcresultPaths, rerunParams.TestParams.TestOutputDir)
+		testLogs = append(testLogs, testLog)
+		exitCode, testErr = rerunExitCode, rerunErr
+
+		if rerunErr == nil {
+			flakyTests = append(flakyTests, failedTests...)
+			failedTests = nil
+			break
+		}
+
+		stillFailingTests, _, err := s.collectFailedTests(rerunParams.TestParams.TestOutputDir, nil)
+		if err != nil {
+			s.logger.Warnf("Failed to collect failed tests of the rerun: %s", err)
+			break
+		}
+		if len(stillFailingTests) == 0 {
+			s.logger.Warnf("The rerun failed without failing tests, stopping reruns")
+			break
+		}
+
+		for _, test := range failedTests {
+			if !slices.Contains(stillFailingTests, test) {
+				flakyTests = append(flakyTests, test)
+			}
+		}
+		failedTests = stillFailingTests
+	}
+
+	result.XcodebuildTestLog = strings.Join(testLogs, "\n")
+
+	if len(xcresultPaths) > 1 {
+		mergedXcresultPath := filepath.Join(outputDir, cfg.testRunName()+"-merged.xcresult")
+		if err := s.mergeXcresults(&result, xcresultPaths, mergedXcresultPath); err != nil {
+			s.logger.Warnf("Failed to merge test results, exporting the results of the first run: %s", err)
+		}
+	}
+
+	if len(flakyTests) > 0 {
+		s.logger.Println()
+		s.logger.Warnf("%d test(s) passed on rerun, treating them as flaky:", len(flakyTests))
+		for _, test := range flakyTests {
+			s.logger.Warnf("- %s", test)
+		}
+	}
+
+	if testErr == nil && len(notRerunTests) > 0 {
+		return result, firstExitCode, firstTestErr
+	}
+	if testErr == nil {
+		return result, 0, nil
+	}
+
+	return result, exitCode, testErr
+}
+
+// collectFailedTests returns the failed test cases of an xcresult bundle in the `-only-testing` identifier format:
+// <TestTarget>/<TestClass>/<TestMethod>. If rerun tags are given, the failed test cases without any of the tags
+// are returned separately.
+func (s XcodeTestRunner) collectFailedTests(xcresultPath string, rerunTestTags []string) ([]string, []string, error) {
+	testData, testSummary, err := s.xcresultProcessor.ParseTestResults(xcresultPath, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if testSummary == nil {
+		return nil, nil, fmt.Errorf("no test results found in: %s", xcresultPath)
+	}
+
+	failedTests, notRerunTests := failedTestIdentifiers(*testSummary, xcresult.TestCaseTags(testData), rerunTestTags)
+	return failedTests, notRerunTests, nil
+}
+
+// failedTestIdentifiers returns the failed test cases with any of the rerun tags (every failed test case if no tag is given),
+// and the failed test cases without the tags.
+func failedTestIdentifiers(testSummary model3.TestSummary, testCaseTags map[string][]string, rerunTestTags []string) ([]string, []string) {
+	var identifiers, notRerunIdentifiers []string
+	for _, testPlan := range testSummary.TestPlans {
+		for _, testBundle := range testPlan.TestBundles {
+			for _, testSuite := range testBundle.TestSuites {
+				for _, testCase := range testSuite.TestCases {
+					if testCase.Result != model3.TestResultFailed {
+						continue
+					}
+
+					identifier := testCaseIdentifier(testBundle.Name, testCase.ClassName, testCase.Name)
+					tags := testCaseTags[xcresult.TestCaseKey(testBundle.Name, testCase.ClassName, testCase.Name)]
+					if len(rerunTestTags) > 0 && !slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(rerunTestTags, tag) }) {
+						if !slices.Contains(notRerunIdentifiers, identifier) {
+							notRerunIdentifiers = append(notRerunIdentifiers, identifier)
+						}
+						continue
+					}
+
+					if !slices.Contains(identifiers, identifier) {
+						identifiers = append(identifiers, identifier)
+					}
+				}
+			}
+		}
+	}
+
+	return identifiers, notRerunIdentifiers
+}
@@ -0,0 +1,156 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenFailedTests_WhenRerunPasses_ThenSucceedsWithMergedResults(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{Scheme: "BullsEye", TestRepetitionMode: xcodebuild.TestRepetitionRerunFailedTests, MaximumTestRepetitions: 3}
+	result := Result{XcresultPath: "tmp/Test-BullsEye.xcresult", XcodebuildTestLog: "first run"}
+
+	mocks.xcresultProcessor.On("ParseTestResults", "tmp/Test-BullsEye.xcresult", false).
+		Return(nil, testSummaryWithFailedTests("testFlaky()", "testFailing()"), nil).Once()
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return params.TestParams.TestOutputDir == "tmp/Test-BullsEye-rerun-1.xcresult" &&
+			len(params.TestParams.OnlyTesting) == 2
+	})).Return("second run", 65, errors.New("exit status 65")).Once()
+	mocks.xcresultProcessor.On("ParseTestResults", "tmp/Test-BullsEye-rerun-1.xcresult", false).
+		Return(nil, testSummaryWithFailedTests("testFailing()"), nil).Once()
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return params.TestParams.TestOutputDir == "tmp/Test-BullsEye-rerun-2.xcresult" &&
+			len(params.TestParams.OnlyTesting) == 1 && params.TestParams.OnlyTesting[0] == "BullsEyeTests/BullsEyeTests/testFailing"
+	})).Return("third run", 0, nil).Once()
+	mocks.xcresultProcessor.On("Merge", []string{
+		"tmp/Test-BullsEye.xcresult",
+		"tmp/Test-BullsEye-rerun-1.xcresult",
+		"tmp/Test-BullsEye-rerun-2.xcresult",
+	}, "tmp/Test-BullsEye-merged.xcresult").Return(nil).Once()
+
+	// When
+	rerunResult, exitCode, err := step.rerunFailedTests(cfg, xcodebuild.TestRunParams{}, result, 65, errors.New("exit status 65"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, "tmp/Test-BullsEye-merged.xcresult", rerunResult.XcresultPath)
+	require.Equal(t, "first run\nsecond run\nthird run", rerunResult.XcodebuildTestLog)
+}
+
+func Test_GivenFailedTests_WhenRerunsKeepFailing_ThenFailsAfterMaximumRepetitions(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{Scheme: "BullsEye", TestRepetitionMode: xcodebuild.TestRepetitionRerunFailedTests, MaximumTestRepetitions: 2}
+	result := Result{XcresultPath: "tmp/Test-BullsEye.xcresult"}
+	rerunErr := errors.New("exit status 65")
+
+	mocks.xcresultProcessor.On("ParseTestResults", mock.Anything, false).Return(nil, testSummaryWithFailedTests("testFailing()"), nil).Twice()
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.Anything).Return("", 65, rerunErr).Once()
+	mocks.xcresultProcessor.On("Merge", mock.Anything, mock.Anything).Return(errors.New("merge failed")).Once()
+
+	// When
+	rerunResult, exitCode, err := step.rerunFailedTests(cfg, xcodebuild.TestRunParams{}, result, 65, errors.New("exit status 65"))
+
+	// Then
+	require.Equal(t, rerunErr, err)
+	require.Equal(t, 65, exitCode)
+	require.Equal(t, "tmp/Test-BullsEye.xcresult", rerunResult.XcresultPath)
+}
+
+func Test_GivenFailureWithoutFailedTests_WhenRerunning_ThenSkipsRerun(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{Scheme: "BullsEye", TestRepetitionMode: xcodebuild.TestRepetitionRerunFailedTests, MaximumTestRepetitions: 3}
+	result := Result{XcresultPath: "tmp/Test-BullsEye.xcresult"}
+	buildErr := errors.New("exit status 65")
+
+	mocks.xcresultProcessor.On("ParseTestResults", "tmp/Test-BullsEye.xcresult", false).Return(nil, &model3.TestSummary{}, nil).Once()
+
+	// When
+	_, exitCode, err := step.rerunFailedTests(cfg, xcodebuild.TestRunParams{}, result, 65, buildErr)
+
+	// Then
+	require.Equal(t, buildErr, err)
+	require.Equal(t, 65, exitCode)
+	mocks.xcodebuilder.AssertNotCalled(t, "TestWithoutBuilding", mock.Anything)
+}
+
+func testSummaryWithFailedTests(failedTestNames ...string) *model3.TestSummary {
+	testCases := []model3.TestCaseWithRetries{
+		{TestCase: model3.TestCase{Name: "testPassing()", ClassName: "BullsEyeTests", Result: model3.TestResultPassed}},
+	}
+	for _, name := range failedTestNames {
+		testCases = append(testCases, model3.TestCaseWithRetries{
+			TestCase: model3.TestCase{Name: name, ClassName: "BullsEyeTests", Result: model3.TestResultFailed},
+		})
+	}
+
+	return &model3.TestSummary{TestPlans: []model3.TestPlan{{TestBundles: []model3.TestBundle{{
+		Name:       "BullsEyeTests",
+		TestSuites: []model3.TestSuite{{Name: "BullsEyeTests", TestCases: testCases}},
+	}}}}}
+}
+
+func Test_GivenRerunTestTags_WhenRerunPasses_ThenTheFailedTestsWithoutTheTagsFailTheRun(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	cfg := Config{Scheme: "BullsEye", TestRepetitionMode: xcodebuild.TestRepetitionRerunFailedTests, MaximumTestRepetitions: 2, RerunTestTags: []string{"flaky"}}
+	result := Result{XcresultPath: "tmp/Test-BullsEye.xcresult"}
+	testErr := errors.New("exit status 65")
+
+	mocks.xcresultProcessor.On("ParseTestResults", "tmp/Test-BullsEye.xcresult", false).
+		Return(testDataWithTags("testFlaky()", "flaky"), testSummaryWithFailedTests("testFlaky()", "testFailing()"), nil).Once()
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return len(params.TestParams.OnlyTesting) == 1 && params.TestParams.OnlyTesting[0] == "BullsEyeTests/BullsEyeTests/testFlaky"
+	})).Return("", 0, nil).Once()
+	mocks.xcresultProcessor.On("Merge", mock.Anything, mock.Anything).Return(nil).Once()
+
+	// When
+	_, exitCode, err := step.rerunFailedTests(cfg, xcodebuild.TestRunParams{}, result, 65, testErr)
+
+	// Then
+	require.Equal(t, testErr, err)
+	require.Equal(t, 65, exitCode)
+	mocks.xcodebuilder.AssertExpectations(t)
+}
+
+func Test_failedTestIdentifiers(t *testing.T) {
+	testSummary := testSummaryWithFailedTests("isEven(number:) (arguments: 1)", "isEven(number:) (arguments: 3)", "testFailing()")
+	testCaseTags := map[string][]string{
+		"BullsEyeTests/BullsEyeTests/isEven(number:) (arguments: 1)": {"numbers"},
+		"BullsEyeTests/BullsEyeTests/isEven(number:) (arguments: 3)": {"numbers"},
+	}
+
+	failedTests, notRerunTests := failedTestIdentifiers(*testSummary, testCaseTags, nil)
+	require.Equal(t, []string{"BullsEyeTests/BullsEyeTests/isEven(number:)", "BullsEyeTests/BullsEyeTests/testFailing"}, failedTests)
+	require.Empty(t, notRerunTests)
+
+	failedTests, notRerunTests = failedTestIdentifiers(*testSummary, testCaseTags, []string{"numbers"})
+	require.Equal(t, []string{"BullsEyeTests/BullsEyeTests/isEven(number:)"}, failedTests)
+	require.Equal(t, []string{"BullsEyeTests/BullsEyeTests/testFailing"}, notRerunTests)
+}
+
+// testDataWithTags creates the test data of testSummaryWithFailedTests with the given tags on a test case.
+func testDataWithTags(testCaseName string, tags ...string) *model3.TestData {
+	return &model3.TestData{TestNodes: []model3.TestNode{{
+		Type: model3.TestNodeTypeTestPlan,
+		Children: []model3.TestNode{{
+			Type: model3.TestNodeTypeUnitTestBundle,
+			Name: "BullsEyeTests",
+			Children: []model3.TestNode{{
+				Type: model3.TestNodeTypeTestSuite,
+				Name: "BullsEyeTests",
+				Children: []model3.TestNode{
+					{Identifier: "BullsEyeTests/" + testCaseName, Type: model3.TestNodeTypeTestCase, Name: testCaseName, Tags: tags},
+				},
+			}},
+		}},
+	}}}
+}
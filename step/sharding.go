@@ -0,0 +1,272 @@
+package step
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+)
+
+// defaultTestClassDuration is used for balancing test classes without historical duration.
+const defaultTestClassDuration = time.Second
+
+type testClass struct {
+	identifier string
+	duration   time.Duration
+}
+
+type shardResult struct {
+	xcresultPath string
+	testLog      string
+	exitCode     int
+	err          error
+}
+
+/*
+runShardedTests splits the test classes into balanced shards and runs every shard concurrently
+(with `test-without-building` and `-only-testing`) on its own clone of the destination simulator.
+
+Test classes are discovered with `-enumerate-tests`, or from the xcresult bundle of a previous test run
+(ShardingHistoryPath) if the enumeration fails. Shards are balanced by the test class durations of that bundle.
+The xcresult bundles of the shards (including the automatically retried runs) are merged into a single bundle.
+
+Every shard logs into its own buffer (without a log formatter), the log of a shard is printed once the shard finishes.
+The clones are shut down after the test run and returned in Result.ShardSimulators, they are deleted by Run once the
+crash reports are collected.
+*/
+func (s XcodeTestRunner) runShardedTests(cfg Config, testParams xcodebuild.TestRunParams, result Result) (Result, int, error) {
+	outputDir := filepath.Dir(testParams.TestParams.TestOutputDir)
+
+	testClasses, err := s.collectTestClasses(cfg, testParams, outputDir)
+	if err != nil {
+		return result, -1, err
+	}
+
+	shards := createShards(testClasses, cfg.ParallelShards)
+	if len(shards) < 2 {
+		s.logger.Warnf("Not enough test classes for sharding, running the tests on a single simulator")
+		return s.testWithoutBuilding(cfg, testParams, result)
+	}
+
+	// Only shut down simulators can be cloned.
+	if err := s.simulatorManager.Shutdown(cfg.Simulator.UDID); err != nil {
+		s.logger.Warnf("Failed to shut down simulator before cloning: %s", err)
+	}
+
+	devices, err := s.cloneSimulators(cfg.Simulator, len(shards))
+	result.ShardSimulators = append(result.ShardSimulators, devices...)
+	defer s.shutdownSimulators(devices)
+	if err != nil {
+		return result, -1, err
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Running the tests in %d parallel shards", len(shards))
+
+	recordings := s.startVideoRecordings(cfg, devices, outputDir)
+	appLogStreams := s.startAppLogStreams(cfg, devices, outputDir)
+
+	shardResults := make([]shardResult, len(shards))
+	var wg sync.WaitGroup
+	var printMutex sync.Mutex
+	for i, shard := range shards {
+		shardOutput := &shardLog{}
+		shardRunner := s.withShardLog(shardOutput)
+
+		shardParams := testParams
+		shardParams.TestParams.Destinations = []string{devices[i].XcodebuildDestination()}
+		shardParams.TestParams.TestOutputDir = filepath.Join(outputDir, fmt.Sprintf("%s-shard-%d.xcresult", cfg.testRunName(), i+1))
+		shardParams.TestParams.OnlyTesting = shard
+		shardParams.BeforeTestRunnerRetry = shardRunner.simulatorRetryPreparer(cfg, []destination.Device{devices[i]})
+
+		s.logger.Printf("Shard %d (%s): %d test class(es)", i+1, devices[i].Name, len(shard))
+
+		wg.Add(1)
+		go func(i int, params xcodebuild.TestRunParams) {
+			defer wg.Done()
+
+			testLog, exitCode, err := shardRunner.xcodebuild.TestWithoutBuilding(params)
+			shardResults[i] = shardResult{
+				xcresultPath: params.TestParams.TestOutputDir,
+				testLog:      testLog,
+				exitCode:     exitCode,
+				err:          err,
+			}
+
+			printMutex.Lock()
+			defer printMutex.Unlock()
+			s.logger.Println()
+			s.logger.Infof("Shard %d (%s) finished:", i+1, devices[i].Name)
+			s.logger.Printf("%s", strings.TrimRight(shardOutput.String(), "\n"))
+		}(i, shardParams)
+	}
+	wg.Wait()
+
+	var xcresultPaths, testLogs []string
+	var exitCode int
+	var testErr error
+	for i, shardResult := range shardResults {
+		xcresultPaths = append(xcresultPaths, xcodebuild.PreviousAttemptResultBundles(shardResult.xcresultPath)...)
+		xcresultPaths = append(xcresultPaths, shardResult.xcresultPath)
+		testLogs = append(testLogs, fmt.Sprintf("=== Shard %d ===\n%s", i+1, shardResult.testLog))
+
+		if shardResult.err != nil {
+			s.logger.Errorf("Shard %d failed: %s", i+1, shardResult.err)
+			if testErr == nil {
+				exitCode, testErr = shardResult.exitCode, fmt.Errorf("shard %d failed: %w", i+1, shardResult.err)
+			}
+		}
+	}
+
+	result.XcodebuildTestLog = strings.Join(testLogs, "\n")
+	result.VideoPaths = s.stopVideoRecordings(recordings, cfg.RecordVideo, testErr)
+	result.SimulatorAppLog = s.stopAppLogStreams(appLogStreams, cfg.CollectSimulatorAppLog, testErr)
+	if err := s.mergeXcresults(&result, xcresultPaths, testParams.TestParams.TestOutputDir); err != nil {
+		s.logger.Warnf("Failed to merge the test results of the shards, exporting the results of the first shard: %s", err)
+		result.XcresultPath = shardResults[0].xcresultPath
+	}
+
+	if testErr != nil || cfg.LogFormatter == XcodebuildTool {
+		s.utils.PrintLastLinesOfXcodebuildTestLog(result.XcodebuildTestLog, testErr == nil)
+	}
+
+	return result, exitCode, testErr
+}
+
+// withShardLog returns a copy of the test runner which logs the test run of a shard (and its retries) into the shard log.
+func (s XcodeTestRunner) withShardLog(shardLog *shardLog) XcodeTestRunner {
+	shardLogger := log.NewLogger(log.WithOutput(shardLog))
+	s.logger = shardLogger
+	s.xcodebuild = s.xcodebuild.WithCommandRunner(shardLogger, newBufferedCommandRunner(shardLogger, s.xcodebuildCommandFactory, shardLog))
+	return s
+}
+
+func (s XcodeTestRunner) collectTestClasses(cfg Config, testParams xcodebuild.TestRunParams, outputDir string) ([]testClass, error) {
+	var durations map[string]time.Duration
+	if cfg.ShardingHistoryPath != "" {
+		_, testSummary, err := s.xcresultProcessor.ParseTestResults(cfg.ShardingHistoryPath, false)
+		if err != nil {
+			s.logger.Warnf("Failed to read test durations from %s: %s", cfg.ShardingHistoryPath, err)
+		} else if testSummary != nil {
+			durations = testClassDurations(*testSummary)
+		}
+	}
+
+	tests, err := s.xcodebuild.EnumerateTests(testParams, filepath.Join(outputDir, "enumerated_tests.json"))
+	if err != nil {
+		if len(durations) == 0 {
+			return nil, fmt.Errorf("failed to discover test classes: %w", err)
+		}
+
+		s.logger.Warnf("Failed to enumerate tests, using the test classes of %s: %s", cfg.ShardingHistoryPath, err)
+		var testClasses []testClass
+		for identifier, duration := range durations {
+			testClasses = append(testClasses, testClass{identifier: identifier, duration: duration})
+		}
+		return testClasses, nil
+	}
+
+	var testClasses []testClass
+	seen := map[string]bool{}
+	for _, test := range tests {
+		identifier := testClassIdentifier(test)
+		if seen[identifier] {
+			continue
+		}
+		seen[identifier] = true
+
+		duration, ok := durations[identifier]
+		if !ok {
+			duration = defaultTestClassDuration
+		}
+		testClasses = append(testClasses, testClass{identifier: identifier, duration: duration})
+	}
+
+	return testClasses, nil
+}
+
+func (s XcodeTestRunner) cloneSimulators(device destination.Device, count int) ([]destination.Device, error) {
+	var devices []destination.Device
+	for i := 1; i <= count; i++ {
+		clone, err := s.simulatorManager.Clone(device, fmt.Sprintf("%s (shard %d)", device.Name, i))
+		if err != nil {
+			return devices, err
+		}
+		devices = append(devices, clone)
+	}
+
+	return devices, nil
+}
+
+func (s XcodeTestRunner) deleteSimulators(devices []destination.Device) {
+	for _, device := range devices {
+		if err := s.simulatorManager.Delete(device.UDID); err != nil {
+			s.logger.Warnf("Failed to delete simulator clone: %s", err)
+		}
+	}
+}
+
+// testClassIdentifier converts a <TestTarget>/<TestClass>/<TestMethod> test identifier to <TestTarget>/<TestClass>.
+func testClassIdentifier(testIdentifier string) string {
+	components := strings.Split(testIdentifier, "/")
+	if len(components) < 3 {
+		return testIdentifier
+	}
+	return strings.Join(components[:2], "/")
+}
+
+func testClassDurations(testSummary model3.TestSummary) map[string]time.Duration {
+	durations := map[string]time.Duration{}
+	for _, testPlan := range testSummary.TestPlans {
+		for _, testBundle := range testPlan.TestBundles {
+			for _, testSuite := range testBundle.TestSuites {
+				for _, testCase := range testSuite.TestCases {
+					identifier := fmt.Sprintf("%s/%s", testBundle.Name, testCase.ClassName)
+					durations[identifier] += testCase.Time
+				}
+			}
+		}
+	}
+
+	return durations
+}
+
+// createShards distributes the test classes into at most shardCount shards with the longest processing time first
+// algorithm: the longest test class goes into the shard with the shortest total duration.
+func createShards(testClasses []testClass, shardCount int) [][]string {
+	sorted := slices.Clone(testClasses)
+	slices.SortStableFunc(sorted, func(a, b testClass) int {
+		if a.duration != b.duration {
+			return cmp.Compare(b.duration, a.duration)
+		}
+		return strings.Compare(a.identifier, b.identifier)
+	})
+
+	if shardCount > len(sorted) {
+		shardCount = len(sorted)
+	}
+
+	shards := make([][]string, shardCount)
+	totals := make([]time.Duration, shardCount)
+	for _, class := range sorted {
+		shortest := 0
+		for i := range totals {
+			if totals[i] < totals[shortest] {
+				shortest = i
+			}
+		}
+
+		shards[shortest] = append(shards[shortest], class.identifier)
+		totals[shortest] += class.duration
+	}
+
+	return shards
+}
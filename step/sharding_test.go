@@ -0,0 +1,129 @@
+package step
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-io/go-xcode/v2/xcodecommand"
+	commonMocks "github.com/bitrise-steplib/steps-xcode-test/mocks"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_createShards(t *testing.T) {
+	testClasses := []testClass{
+		{identifier: "UnitTests/FastTests", duration: 1 * time.Second},
+		{identifier: "UITests/LoginTests", duration: 8 * time.Second},
+		{identifier: "UITests/SettingsTests", duration: 5 * time.Second},
+		{identifier: "UnitTests/ModelTests", duration: 4 * time.Second},
+		{identifier: "UnitTests/ParserTests", duration: 3 * time.Second},
+	}
+
+	require.Equal(t, [][]string{
+		{"UITests/LoginTests", "UnitTests/ParserTests"},
+		{"UITests/SettingsTests", "UnitTests/ModelTests", "UnitTests/FastTests"},
+	}, createShards(testClasses, 2))
+	require.Equal(t, [][]string{{"UnitTests/FastTests"}}, createShards(testClasses[:1], 3))
+}
+
+func Test_testClassDurations(t *testing.T) {
+	testSummary := model3.TestSummary{TestPlans: []model3.TestPlan{{TestBundles: []model3.TestBundle{{
+		Name: "UnitTests",
+		TestSuites: []model3.TestSuite{{Name: "ModelTests", TestCases: []model3.TestCaseWithRetries{
+			{TestCase: model3.TestCase{Name: "testA()", ClassName: "ModelTests", Time: time.Second}},
+			{TestCase: model3.TestCase{Name: "testB()", ClassName: "ModelTests", Time: 2 * time.Second}},
+		}}},
+	}}}}}
+
+	require.Equal(t, map[string]time.Duration{"UnitTests/ModelTests": 3 * time.Second}, testClassDurations(testSummary))
+	require.Equal(t, "UnitTests/ModelTests", testClassIdentifier("UnitTests/ModelTests/testA()"))
+}
+
+func Test_GivenShardingEnabled_WhenRunningTests_ThenRunsShardsOnClonesAndMergesResults(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	device := destination.Device{Name: "iPhone 15", UDID: "ORIGINAL"}
+	cfg := Config{Scheme: "BullsEye", Simulator: device, ParallelShards: 2, LogFormatter: "xcpretty"}
+	testParams := xcodebuild.TestRunParams{TestParams: xcodebuild.TestParams{TestOutputDir: "tmp/Test-BullsEye.xcresult"}}
+
+	mocks.xcodebuilder.On("EnumerateTests", mock.Anything, "tmp/enumerated_tests.json").Return([]string{
+		"UnitTests/ModelTests/testA()",
+		"UnitTests/ModelTests/testB()",
+		"UnitTests/ParserTests/testA()",
+	}, nil)
+	mocks.simulatorManager.On("Shutdown", "ORIGINAL").Return(nil)
+	mocks.simulatorManager.On("Clone", device, "iPhone 15 (shard 1)").Return(destination.Device{Name: "iPhone 15 (shard 1)", UDID: "CLONE-1"}, nil)
+	mocks.simulatorManager.On("Clone", device, "iPhone 15 (shard 2)").Return(destination.Device{Name: "iPhone 15 (shard 2)", UDID: "CLONE-2"}, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return params.TestParams.Destinations[0] == "id=CLONE-1" && params.TestParams.OnlyTesting[0] == "UnitTests/ModelTests"
+	})).Return("shard 1", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return params.TestParams.Destinations[0] == "id=CLONE-2" && params.TestParams.OnlyTesting[0] == "UnitTests/ParserTests"
+	})).Return("shard 2", 65, errors.New("exit status 65"))
+	mocks.xcresultProcessor.On("Merge", []string{"tmp/Test-BullsEye-shard-1.xcresult", "tmp/Test-BullsEye-shard-2.xcresult"}, "tmp/Test-BullsEye.xcresult").Return(nil)
+	mocks.xcodebuilder.On("WithCommandRunner", mock.Anything, mock.Anything).Return(mocks.xcodebuilder).Twice()
+	mocks.simulatorManager.On("Shutdown", "CLONE-1").Return(nil).Once()
+	mocks.simulatorManager.On("Shutdown", "CLONE-2").Return(nil).Once()
+
+	// When
+	result, exitCode, err := step.runShardedTests(cfg, testParams, Result{})
+
+	// Then
+	require.EqualError(t, err, "shard 2 failed: exit status 65")
+	require.Equal(t, 65, exitCode)
+	require.Equal(t, "tmp/Test-BullsEye.xcresult", result.XcresultPath)
+	require.Equal(t, "=== Shard 1 ===\nshard 1\n=== Shard 2 ===\nshard 2", result.XcodebuildTestLog)
+	require.Equal(t, []string{"CLONE-1", "CLONE-2"}, []string{result.ShardSimulators[0].UDID, result.ShardSimulators[1].UDID})
+	mocks.simulatorManager.AssertExpectations(t)
+	mocks.simulatorManager.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func Test_GivenShardLog_WhenRunningXcodebuild_ThenUsesTheXcodebuildCommandFactory(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	output := &shardLog{}
+	args := []string{"test-without-building", "-xctestrun", "BullsEye.xctestrun"}
+
+	var runner xcodecommand.Runner
+	mocks.xcodebuilder.On("WithCommandRunner", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		runner = args.Get(1).(xcodecommand.Runner)
+	}).Return(mocks.xcodebuilder).Once()
+
+	cmd := new(commonMocks.Command)
+	cmd.On("PrintableCommandArgs").Return("")
+	cmd.On("RunAndReturnExitCode").Return(0, nil)
+	mocks.xcodebuildCommandFactory.On("Create", "xcodebuild", args, mock.Anything).Return(cmd)
+
+	// When
+	step.withShardLog(output)
+	_, err := runner.Run("", args, nil)
+
+	// Then
+	require.NoError(t, err)
+	mocks.xcodebuildCommandFactory.AssertCalled(t, "Create", "xcodebuild", args, mock.Anything)
+	mocks.commandFactory.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_GivenShardLog_WhenRetryingTheShard_ThenLogsIntoTheShardLog(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	sim := destination.Device{Name: "iPhone 15 (shard 1)", UDID: "CLONE-1"}
+	output := &shardLog{}
+
+	mocks.xcodebuilder.On("WithCommandRunner", mock.Anything, mock.Anything).Return(mocks.xcodebuilder).Once()
+	mocks.simulatorManager.On("Shutdown", "CLONE-1").Return(nil).Once()
+	mocks.simulatorManager.On("Erase", "CLONE-1").Return(nil).Once()
+	mocks.simulatorManager.On("Boot", sim).Return(nil).Once()
+
+	// When
+	err := step.withShardLog(output).simulatorRetryPreparer(Config{}, []destination.Device{sim})(xcodebuild.RetryActionRetryAfterErase)
+
+	// Then
+	require.NoError(t, err)
+	require.Contains(t, output.String(), "Erasing simulator (CLONE-1)")
+	require.Contains(t, output.String(), "Booting simulator (CLONE-1)")
+}
@@ -0,0 +1,77 @@
+package step
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/errorfinder"
+	"github.com/bitrise-io/go-xcode/v2/xcodecommand"
+	version "github.com/hashicorp/go-version"
+)
+
+// shardLog collects the log of a shard, it is printed into the step log once the shard finishes.
+type shardLog struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (l *shardLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buffer.Write(p)
+}
+
+func (l *shardLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buffer.String()
+}
+
+/*
+bufferedCommandRunner runs xcodebuild without a log formatter and writes its output into the shard log instead of
+the step log, as the output of the concurrently running shards would be interleaved.
+*/
+type bufferedCommandRunner struct {
+	logger         log.Logger
+	commandFactory command.Factory
+	output         io.Writer
+}
+
+func newBufferedCommandRunner(logger log.Logger, commandFactory command.Factory, output io.Writer) xcodecommand.Runner {
+	return &bufferedCommandRunner{
+		logger:         logger,
+		commandFactory: commandFactory,
+		output:         output,
+	}
+}
+
+// Run runs xcodebuild and returns its raw output, which is also written into the shard log.
+func (r *bufferedCommandRunner) Run(workDir string, args []string, _ []string) (xcodecommand.Output, error) {
+	var outBuffer bytes.Buffer
+	// The same writer for stdout and stderr, so that they share a single pipe.
+	output := io.MultiWriter(&outBuffer, r.output)
+
+	cmd := r.commandFactory.Create("xcodebuild", args, &command.Opts{
+		Stdout:      output,
+		Stderr:      output,
+		Env:         []string{"NSUnbufferedIO=YES"},
+		Dir:         workDir,
+		ErrorFinder: errorfinder.FindXcodebuildErrors,
+	})
+
+	r.logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	exitCode, err := cmd.RunAndReturnExitCode()
+	return xcodecommand.Output{
+		RawOut:   outBuffer.Bytes(),
+		ExitCode: exitCode,
+	}, err
+}
+
+// CheckInstall does nothing as no log formatter is used.
+func (r *bufferedCommandRunner) CheckInstall() (*version.Version, error) {
+	return nil, nil
+}
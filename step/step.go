@@ -3,6 +3,7 @@ package step
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"slices"
@@ -17,26 +18,48 @@ import (
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/bitrise-io/go-xcode/v2/destination"
-	"github.com/bitrise-io/go-xcode/v2/simulator"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
 	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
 	"github.com/bitrise-io/go-xcode/v2/xcodecommand"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
 	"github.com/bitrise-steplib/steps-xcode-test/output"
+	"github.com/bitrise-steplib/steps-xcode-test/simulator"
 	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
 	"github.com/kballard/go-shellquote"
 )
 
 const simulatorShutdownState = "Shutdown"
 
+// defaultSimulatorBootTimeout is used if the Simulator Boot Timeout (simulator_boot_timeout) input is not set.
+const defaultSimulatorBootTimeout = 5 * time.Minute
+
 type Input struct {
-	ProjectPath string `env:"project_path,required"`
-	Scheme      string `env:"scheme,required"`
-	Destination string `env:"destination,required"`
-	TestPlan    string `env:"test_plan"`
+	ProjectPath   string `env:"project_path"`
+	Scheme        string `env:"scheme"`
+	XctestrunPath string `env:"xctestrun"`
+	Destination   string `env:"destination,required"`
+	TestPlan      string `env:"test_plan"`
+
+	OnlyTestConfiguration string `env:"only_test_configuration"`
+	OnlyTesting           string `env:"only_testing"`
+	SkipTesting           string `env:"skip_testing"`
 
 	// Test Repetition
-	TestRepetitionMode             string `env:"test_repetition_mode,opt[none,until_failure,retry_on_failure,up_until_maximum_repetitions]"`
+	TestRepetitionMode             string `env:"test_repetition_mode,opt[none,until_failure,retry_on_failure,up_until_maximum_repetitions,rerun_failed_tests]"`
 	MaximumTestRepetitions         int    `env:"maximum_test_repetitions,required"`
 	RelaunchTestsForEachRepetition bool   `env:"relaunch_tests_for_each_repetition,opt[yes,no]"`
+	RerunTestTags                  string `env:"rerun_test_tags"`
+
+	// Test Runner Retry
+	TestRunnerRetryPatterns  string `env:"test_runner_retry_patterns"`
+	TestRunnerRetryRulesPath string `env:"test_runner_retry_rules"`
+	MaximumTestRunnerRetries int    `env:"maximum_test_runner_retries"`
+	TestRunnerRetryBackoff   int    `env:"test_runner_retry_backoff"`
+
+	// Test Sharding
+	ParallelShards      int    `env:"parallel_shards"`
+	ShardingHistoryPath string `env:"sharding_history_path"`
 
 	// xcodebuild configuration
 	XCConfigContent    string `env:"xcconfig_content"`
@@ -53,9 +76,22 @@ type Input struct {
 
 	// Debugging
 	VerboseLog                  bool   `env:"verbose_log,opt[yes,no]"`
+	DryRun                      bool   `env:"dry_run,opt[yes,no]"`
 	QuarantinedTests            string `env:"quarantined_tests"`
+	QuarantineFile              string `env:"quarantine_file"`
+	RunQuarantinedTests         bool   `env:"run_quarantined_tests,opt[yes,no]"`
+	QuarantinedTestIterations   int    `env:"quarantined_test_iterations"`
 	CollectSimulatorDiagnostics string `env:"collect_simulator_diagnostics,opt[always,on_failure,never]"`
+	RecordVideo                 string `env:"record_video,opt[always,on_failure,never]"`
+	CollectSimulatorAppLog      string `env:"collect_simulator_app_log,opt[always,on_failure,never]"`
+	SimulatorAppLogProcesses    string `env:"simulator_app_log_processes"`
+	SimulatorAppLogSubsystems   string `env:"simulator_app_log_subsystems"`
+	CollectCrashReports         bool   `env:"collect_crash_reports,opt[yes,no]"`
 	HeadlessMode                bool   `env:"headless_mode,opt[yes,no]"`
+	SimulatorBootTimeout        int    `env:"simulator_boot_timeout"`
+	SimulatorLifecycle          string `env:"simulator_lifecycle,opt[reuse,erase_before,clone,ephemeral]"`
+	SimulatorSetup              string `env:"simulator_setup"`
+	ExportXcresultAttempts      bool   `env:"export_xcresult_attempts,opt[yes,no]"`
 
 	// Output export
 	DeployDir string `env:"BITRISE_DEPLOY_DIR"`
@@ -77,16 +113,43 @@ const (
 )
 
 type Config struct {
-	ProjectPath string
-	Scheme      string
-	TestPlan    string
+	ProjectPath   string
+	Scheme        string
+	XctestrunPath string
+	// TestPlans are the test plans to run one after the other, the Test Plan (test_plan) input entries are resolved in Run.
+	TestPlans []string
+	// TestPlan is the test plan of the current test run.
+	TestPlan string
+	// OnlyTestConfigurations limit the test runs to the given test plan configurations.
+	OnlyTestConfigurations []string
+	// OnlyTesting and SkipTesting select the tests of the test runs (<TestTarget>[/<TestClass>[/<TestMethod>]]).
+	OnlyTesting []string
+	SkipTesting []string
+	// OnlyTestTags and SkipTestTags select the Swift Testing tests of the test runs by their tags.
+	OnlyTestTags []string
+	SkipTestTags []string
 
 	Simulator         destination.Device
 	IsSimulatorBooted bool
+	// AdditionalSimulators are the simulators of the further destinations, the tests run on them concurrently.
+	AdditionalSimulators []destination.Device
+	// NonSimulatorDestinations are passed to xcodebuild as is (for example `platform=macOS,variant=Mac Catalyst`).
+	NonSimulatorDestinations []string
+	// ResolvedDestinations are the destination specifiers selected from the fallback chains, with the OS version of the matching simulator.
+	ResolvedDestinations []string
 
 	TestRepetitionMode            string
 	MaximumTestRepetitions        int
 	RelaunchTestForEachRepetition bool
+	// RerunTestTags limit the reruns of the rerun_failed_tests mode to the failed Swift Testing tests with any of the tags.
+	RerunTestTags []string
+
+	TestRunnerRetryRules     []xcodebuild.RetryRule
+	MaximumTestRunnerRetries int
+	TestRunnerRetryBackoff   time.Duration
+
+	ParallelShards      int
+	ShardingHistoryPath string
 
 	XCConfigContent    string
 	PerformCleanAction bool
@@ -97,11 +160,52 @@ type Config struct {
 
 	CacheLevel string
 
-	SkipTesting                 []string
+	// QuarantinedTests are skipped in the test runs (<TestTarget>[/<TestClass>[/<TestMethod>]]).
+	QuarantinedTests []string
+	// QuarantinedTestTags are the Swift Testing tags of the quarantined tests, the tests with these tags are skipped.
+	QuarantinedTestTags []string
+	// RunQuarantinedTests runs the quarantined tests in a separate, non-blocking test run after the test run.
+	RunQuarantinedTests       bool
+	QuarantinedTestIterations int
+
 	CollectSimulatorDiagnostics exportCondition
+	RecordVideo                 exportCondition
+	CollectSimulatorAppLog      exportCondition
+	CollectCrashReports         bool
 	HeadlessMode                bool
+	SimulatorBootTimeout        time.Duration
+	SimulatorLifecycle          simulatorLifecycle
+	// SimulatorAppLogPredicate filters the streamed simulator system log to the app under test.
+	SimulatorAppLogPredicate string
+	// SimulatorSetup is applied on every simulator after boot, nil if not set.
+	SimulatorSetup *simulator.Setup
+
+	ExportXcresultAttempts bool
+	DeployDir              string
+
+	// DryRun validates the inputs without running the tests.
+	DryRun bool
+}
 
-	DeployDir string
+// testRunName is the base name of the test run outputs, it includes the test plan if multiple test plans run.
+func (cfg Config) testRunName() string {
+	if len(cfg.TestPlans) > 1 {
+		return fmt.Sprintf("Test-%s-%s", cfg.Scheme, cfg.TestPlan)
+	}
+	return fmt.Sprintf("Test-%s", cfg.Scheme)
+}
+
+// hasSimulator returns true if any of the destinations is a simulator.
+func (cfg Config) hasSimulator() bool {
+	return cfg.Simulator.UDID != ""
+}
+
+// simulators returns the simulators of all destinations.
+func (cfg Config) simulators() []destination.Device {
+	if !cfg.hasSimulator() {
+		return nil
+	}
+	return append([]destination.Device{cfg.Simulator}, cfg.AdditionalSimulators...)
 }
 
 type XcodeTestConfigParser struct {
@@ -123,28 +227,34 @@ func NewXcodeTestConfigParser(inputParser stepconf.InputParser, logger log.Logge
 }
 
 type XcodeTestRunner struct {
-	logger           log.Logger
-	commandFactory   command.Factory
-	xcodebuild       xcodebuild.Xcodebuild
-	simulatorManager simulator.Manager
-	cache            cache.SwiftPackageCache
-	outputExporter   output.Exporter
-	pathModifier     pathutil.PathModifier
-	pathProvider     pathutil.PathProvider
-	utils            Utils
-}
-
-func NewXcodeTestRunner(logger log.Logger, commandFactory command.Factory, xcodebuild xcodebuild.Xcodebuild, simulatorManager simulator.Manager, cache cache.SwiftPackageCache, outputExporter output.Exporter, pathModifier pathutil.PathModifier, pathProvider pathutil.PathProvider, utils Utils) XcodeTestRunner {
+	logger         log.Logger
+	commandFactory command.Factory
+	// xcodebuildCommandFactory creates the xcodebuild commands of the runners created by the step (the shard runners),
+	// it wraps xcodebuild with the Build Cache CLI if enabled.
+	xcodebuildCommandFactory command.Factory
+	xcodebuild               xcodebuild.Xcodebuild
+	simulatorManager         simulator.Manager
+	cache                    cache.SwiftPackageCache
+	outputExporter           output.Exporter
+	xcresultProcessor        xcresult.Processor
+	pathModifier             pathutil.PathModifier
+	pathProvider             pathutil.PathProvider
+	utils                    Utils
+}
+
+func NewXcodeTestRunner(logger log.Logger, commandFactory command.Factory, xcodebuildCommandFactory command.Factory, xcodebuild xcodebuild.Xcodebuild, simulatorManager simulator.Manager, cache cache.SwiftPackageCache, outputExporter output.Exporter, xcresultProcessor xcresult.Processor, pathModifier pathutil.PathModifier, pathProvider pathutil.PathProvider, utils Utils) XcodeTestRunner {
 	return XcodeTestRunner{
-		logger:           logger,
-		commandFactory:   commandFactory,
-		xcodebuild:       xcodebuild,
-		simulatorManager: simulatorManager,
-		cache:            cache,
-		outputExporter:   outputExporter,
-		pathModifier:     pathModifier,
-		pathProvider:     pathProvider,
-		utils:            utils,
+		logger:                   logger,
+		commandFactory:           commandFactory,
+		xcodebuildCommandFactory: xcodebuildCommandFactory,
+		xcodebuild:               xcodebuild,
+		simulatorManager:         simulatorManager,
+		cache:                    cache,
+		outputExporter:           outputExporter,
+		xcresultProcessor:        xcresultProcessor,
+		pathModifier:             pathModifier,
+		pathProvider:             pathProvider,
+		utils:                    utils,
 	}
 }
 
@@ -158,19 +268,79 @@ func (s XcodeTestConfigParser) ProcessConfig() (Config, error) {
 	stepconf.Print(input)
 	s.logger.EnableDebugLog(input.VerboseLog)
 
-	// validate project path
-	projectPath, err := s.pathModifier.AbsPath(input.ProjectPath)
-	if err != nil {
-		return Config{}, fmt.Errorf("failed to get absolute project path: %w", err)
-	}
-	fileExtension := filepath.Ext(projectPath)
-	if fileExtension != ".xcodeproj" && fileExtension != ".xcworkspace" && filepath.Base(projectPath) != "Package.swift" {
-		return Config{}, fmt.Errorf("invalid project path: should be an .xcodeproj/.xcworkspace or Package.swift file (actual: %s)", projectPath)
+	var projectPath string
+	if input.XctestrunPath != "" {
+		// validate xctestrun path
+		xctestrunPath, err := s.pathModifier.AbsPath(input.XctestrunPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to get absolute xctestrun path: %w", err)
+		}
+		fileExtension := filepath.Ext(xctestrunPath)
+		if fileExtension != ".xctestrun" && fileExtension != ".zip" {
+			return Config{}, fmt.Errorf("invalid xctestrun path: should be an .xctestrun file or a .zip archive of the test bundle (actual: %s)", xctestrunPath)
+		}
+		if input.TestPlan != "" {
+			return Config{}, errors.New("the 'Test Plan' (test_plan) cannot be used together with 'Test Run file' (xctestrun), the test plan is selected when building the .xctestrun file")
+		}
+
+		input.XctestrunPath = xctestrunPath
+		if input.Scheme == "" {
+			input.Scheme = strings.TrimSuffix(filepath.Base(xctestrunPath), fileExtension)
+		}
+	} else {
+		if input.ProjectPath == "" {
+			return Config{}, errors.New("'Project path' (project_path) is required if 'Test Run file' (xctestrun) is not set")
+		}
+		if input.Scheme == "" {
+			return Config{}, errors.New("'Scheme' (scheme) is required if 'Test Run file' (xctestrun) is not set")
+		}
+
+		// validate project path
+		projectPath, err = s.pathModifier.AbsPath(input.ProjectPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to get absolute project path: %w", err)
+		}
+		fileExtension := filepath.Ext(projectPath)
+		if fileExtension != ".xcodeproj" && fileExtension != ".xcworkspace" && filepath.Base(projectPath) != "Package.swift" {
+			return Config{}, fmt.Errorf("invalid project path: should be an .xcodeproj/.xcworkspace or Package.swift file (actual: %s)", projectPath)
+		}
 	}
 
-	sim, err := s.getSimulatorForDestination(input.Destination)
-	if err != nil {
-		return Config{}, err
+	var sims []destination.Device
+	var nonSimulatorDestinations, resolvedDestinations []string
+	for _, destinationLine := range strings.Split(input.Destination, "\n") {
+		destinationLine = strings.TrimSpace(destinationLine)
+		if destinationLine == "" {
+			continue
+		}
+
+		candidates, err := parseDestinationFallbacks(destinationLine)
+		if err != nil {
+			return Config{}, err
+		}
+
+		destinationSpecifier := candidates[0]
+		if !isSimulatorDestination(destinationSpecifier) {
+			s.logger.Println()
+			s.logger.Infof("Destination:")
+			s.logger.Printf("%s (the tests run on the host machine, no simulator is used)", colorstring.Cyan(destinationSpecifier))
+			if len(candidates) > 1 {
+				s.logger.Warnf("Fallback destinations are ignored for macOS destinations")
+			}
+			nonSimulatorDestinations = append(nonSimulatorDestinations, destinationSpecifier)
+			resolvedDestinations = append(resolvedDestinations, destinationSpecifier)
+			continue
+		}
+
+		sim, resolvedDestination, err := s.getSimulatorForDestinationFallbacks(candidates)
+		if err != nil {
+			return Config{}, err
+		}
+		sims = append(sims, sim)
+		resolvedDestinations = append(resolvedDestinations, resolvedDestination)
+	}
+	if len(sims) == 0 && len(nonSimulatorDestinations) == 0 {
+		return Config{}, errors.New("no destination specifier provided in 'Device destination specifier' (destination)")
 	}
 
 	// validate test repetition related inputs
@@ -178,10 +348,66 @@ func (s XcodeTestConfigParser) ProcessConfig() (Config, error) {
 		return Config{}, fmt.Errorf("invalid number of Maximum Test Repetitions (maximum_test_repetitions): %d, should be more than 1", input.MaximumTestRepetitions)
 	}
 
+	if input.RunQuarantinedTests && input.QuarantinedTestIterations < 1 {
+		return Config{}, fmt.Errorf("invalid number of Quarantined Test Iterations (quarantined_test_iterations): %d, should be at least 1", input.QuarantinedTestIterations)
+	}
+
 	if input.RelaunchTestsForEachRepetition && input.TestRepetitionMode == xcodebuild.TestRepetitionNone {
 		return Config{}, errors.New("the 'Relaunch Tests for Each Repetition' (relaunch_tests_for_each_repetition) cannot be used if 'Test Repetition Mode' (test_repetition_mode) is 'none'")
 	}
 
+	if input.RelaunchTestsForEachRepetition && input.TestRepetitionMode == xcodebuild.TestRepetitionRerunFailedTests {
+		return Config{}, errors.New("the 'Relaunch Tests for Each Repetition' (relaunch_tests_for_each_repetition) cannot be used if 'Test Repetition Mode' (test_repetition_mode) is 'rerun_failed_tests'")
+	}
+
+	if len(splitLines(input.RerunTestTags)) > 0 && input.TestRepetitionMode != xcodebuild.TestRepetitionRerunFailedTests {
+		return Config{}, errors.New("the 'Rerun Test Tags' (rerun_test_tags) can only be used if 'Test Repetition Mode' (test_repetition_mode) is 'rerun_failed_tests'")
+	}
+
+	// validate test runner retry related inputs
+	if input.MaximumTestRunnerRetries < 0 {
+		return Config{}, fmt.Errorf("invalid number of Maximum Test Runner Retries (maximum_test_runner_retries): %d, should be a positive number", input.MaximumTestRunnerRetries)
+	}
+
+	if input.TestRunnerRetryBackoff < 0 {
+		return Config{}, fmt.Errorf("invalid Test Runner Retry Backoff (test_runner_retry_backoff): %d, should be a positive number of seconds", input.TestRunnerRetryBackoff)
+	}
+
+	retryRules, err := s.parseRetryRules(input.TestRunnerRetryRulesPath, input.TestRunnerRetryPatterns)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if input.SimulatorBootTimeout < 0 {
+		return Config{}, fmt.Errorf("invalid Simulator Boot Timeout (simulator_boot_timeout): %d, should be a positive number of seconds", input.SimulatorBootTimeout)
+	}
+
+	simulatorSetup, err := s.parseSimulatorSetup(input.SimulatorSetup)
+	if err != nil {
+		return Config{}, err
+	}
+	if simulatorSetup != nil && len(sims) == 0 {
+		s.logger.Warnf("The 'Simulator setup' (simulator_setup) is ignored, as none of the destinations is a simulator")
+		simulatorSetup = nil
+	}
+
+	// validate test sharding related inputs
+	if input.ParallelShards < 0 {
+		return Config{}, fmt.Errorf("invalid number of Parallel Shards (parallel_shards): %d, should be a positive number", input.ParallelShards)
+	}
+
+	if input.ParallelShards > 1 && input.TestRepetitionMode == xcodebuild.TestRepetitionRerunFailedTests {
+		return Config{}, errors.New("the 'Parallel Shards' (parallel_shards) cannot be used if 'Test Repetition Mode' (test_repetition_mode) is 'rerun_failed_tests'")
+	}
+
+	if input.ParallelShards > 1 && len(sims)+len(nonSimulatorDestinations) > 1 {
+		return Config{}, errors.New("the 'Parallel Shards' (parallel_shards) cannot be used with multiple destinations in 'Device destination specifier' (destination)")
+	}
+
+	if input.ParallelShards > 1 && len(sims) == 0 {
+		return Config{}, errors.New("the 'Parallel Shards' (parallel_shards) can only be used with a simulator destination in 'Device destination specifier' (destination)")
+	}
+
 	additionalOptions, err := shellquote.Split(input.XcodebuildOptions)
 	if err != nil {
 		return Config{}, fmt.Errorf("provided 'Additional options for the xcodebuild command' (xcodebuild_options) (%s) are not valid CLI parameters: %w", input.XcodebuildOptions, err)
@@ -200,12 +426,92 @@ func (s XcodeTestConfigParser) ProcessConfig() (Config, error) {
 		return Config{}, fmt.Errorf("`-xcconfig` option found in 'Additional options for the xcodebuild command' (xcodebuild_options), please clear 'Build settings (xcconfig)' (`xcconfig_content`) input as only one can be set")
 	}
 
-	skipTesting, err := s.processQuarantinedTests(input.QuarantinedTests)
+	quarantinedTests, err := s.processQuarantinedTests(input.QuarantinedTests)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to process quarentined tests: %w", err)
 	}
 
-	return s.utils.CreateConfig(input, projectPath, sim, additionalOptions, additionalLogFormatterOptions, skipTesting), nil
+	fileQuarantined, err := s.processQuarantineFile(input.QuarantineFile)
+	if err != nil {
+		return Config{}, err
+	}
+	quarantined := testSelection{Tests: quarantinedTests, Tags: fileQuarantined.Tags}
+	for _, test := range fileQuarantined.Tests {
+		if !slices.Contains(quarantined.Tests, test) {
+			quarantined.Tests = append(quarantined.Tests, test)
+		}
+	}
+
+	onlyTesting, err := parseTestSelection(input.OnlyTesting)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid 'Only Testing' (only_testing): %w", err)
+	}
+	skipTesting, err := parseTestSelection(input.SkipTesting)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid 'Skip Testing' (skip_testing): %w", err)
+	}
+	if err := s.validateTestSelection(onlyTesting, skipTesting, quarantined, additionalOptions); err != nil {
+		return Config{}, err
+	}
+	if len(onlyTesting.Tests) > 0 && len(splitLines(input.TestPlan)) > 1 {
+		s.logger.Warnf("'Only Testing' (only_testing) is applied to every test plan of 'Test Plan' (test_plan), the test plans not containing the selected tests fail")
+	}
+
+	return s.utils.CreateConfig(input, projectPath, sims, nonSimulatorDestinations, resolvedDestinations, additionalOptions, additionalLogFormatterOptions, quarantined, onlyTesting, skipTesting, retryRules, simulatorSetup), nil
+}
+
+// parseRetryRules collects the test runner retry rules of the rules file and the retry patterns inputs, the rules of the file come first.
+func (s XcodeTestConfigParser) parseRetryRules(rulesPath, patterns string) ([]xcodebuild.RetryRule, error) {
+	var rules []xcodebuild.RetryRule
+	if rulesPath != "" {
+		absRulesPath, err := s.pathModifier.AbsPath(rulesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute Test Runner Retry Rules (test_runner_retry_rules) path: %w", err)
+		}
+
+		content, err := os.ReadFile(absRulesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Test Runner Retry Rules (test_runner_retry_rules): %w", err)
+		}
+
+		fileRules, err := xcodebuild.ParseRetryRules(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Test Runner Retry Rules (test_runner_retry_rules): %w", err)
+		}
+		rules = append(rules, fileRules...)
+	}
+
+	patternRules, err := xcodebuild.ParseRetryPatterns(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Test Runner Retry Patterns (test_runner_retry_patterns): %w", err)
+	}
+
+	return append(rules, patternRules...), nil
+}
+
+// parseSimulatorSetup parses the Simulator setup input, the media and certificate paths are converted to absolute paths.
+func (s XcodeTestConfigParser) parseSimulatorSetup(content string) (*simulator.Setup, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+
+	setup, err := simulator.ParseSetup([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Simulator setup (simulator_setup): %w", err)
+	}
+
+	for i, pth := range setup.Media {
+		if setup.Media[i], err = s.pathModifier.AbsPath(pth); err != nil {
+			return nil, fmt.Errorf("failed to get absolute media path (%s): %w", pth, err)
+		}
+	}
+	for i, pth := range setup.RootCertificates {
+		if setup.RootCertificates[i], err = s.pathModifier.AbsPath(pth); err != nil {
+			return nil, fmt.Errorf("failed to get absolute root certificate path (%s): %w", pth, err)
+		}
+	}
+
+	return &setup, nil
 }
 
 /*
@@ -257,43 +563,125 @@ type Result struct {
 	Scheme    string
 	DeployDir string
 
-	XcresultPath             string
+	XcresultPath string
+	// TestData and TestSummary are the parsed test results of XcresultPath, nil if the bundle could not be parsed.
+	TestData                 *model3.TestData
+	TestSummary              *model3.TestSummary
 	XcodebuildBuildLog       string
 	XcodebuildTestLog        string
 	SimulatorDiagnosticsPath string
+	SimulatorAppLog          string
+	FailureCategory          xcodebuild.FailureCategory
+	// VideoPaths are the screen recordings of the simulators.
+	VideoPaths []string
+	// SimulatorBootDuration is the measured boot time of the simulator, zero if the step did not boot the simulator.
+	SimulatorBootDuration time.Duration
+	// ResolvedDestinations are the destination specifiers the tests ran on.
+	ResolvedDestinations []string
+	// CrashReports are the reports of the processes which crashed on the simulators during the test run.
+	CrashReports []crashreport.Report
+	// CrashReportPaths are the collected .ips files and their symbolicated summaries.
+	CrashReportPaths []string
+	// ShardSimulators are the clones of the simulator the test shards ran on, they are deleted at the end of Run.
+	ShardSimulators []destination.Device
+
+	// AttemptXcresultPaths are the result bundles of the test runs merged into XcresultPath.
+	AttemptXcresultPaths []string
+	// TestPlanResults are the results of the test plans merged into XcresultPath, if multiple test plans ran.
+	TestPlanResults []output.TestPlanResult
+	// QuarantinedTestResults are the results of the quarantined tests run, if Run Quarantined Tests is enabled.
+	QuarantinedTestResults []output.QuarantinedTestResult
 }
 
 func (s XcodeTestRunner) Run(cfg Config) (Result, error) {
-	enableSimulatorVerboseLog := cfg.CollectSimulatorDiagnostics != never
-	launchSimulator := !cfg.IsSimulatorBooted && !cfg.HeadlessMode
-	if err := s.prepareSimulator(enableSimulatorVerboseLog, cfg.Simulator, launchSimulator); err != nil {
+	testPlans, err := s.resolveTestPlans(cfg)
+	if err != nil {
 		return Result{}, err
 	}
+	cfg.TestPlans = testPlans
+
+	enableSimulatorVerboseLog := cfg.CollectSimulatorDiagnostics != never
+	var simulatorBootDuration time.Duration
+	if cfg.hasSimulator() {
+		lifecycleCfg, createdSims, err := s.applySimulatorLifecycle(cfg)
+		defer s.discardSimulators(createdSims)
+		if err != nil {
+			return s.failRun(cfg, Result{}, simulatorFailure(err), err)
+		}
+		cfg = lifecycleCfg
+
+		launchSimulator := !cfg.IsSimulatorBooted && !cfg.HeadlessMode
+		bootDuration, err := s.prepareSimulator(enableSimulatorVerboseLog, cfg.Simulator, launchSimulator, cfg.SimulatorBootTimeout, cfg.SimulatorSetup)
+		if err != nil {
+			return s.failRun(cfg, Result{}, simulatorFailure(err), err)
+		}
+		simulatorBootDuration = bootDuration
+		for _, sim := range cfg.AdditionalSimulators {
+			if enableSimulatorVerboseLog {
+				if err := s.bootWithVerboseLog(sim); err != nil {
+					return s.failRun(cfg, Result{}, simulatorFailure(err), err)
+				}
+			}
+			if cfg.SimulatorSetup != nil {
+				if err := s.setupSimulator(sim.UDID, *cfg.SimulatorSetup, cfg.SimulatorBootTimeout); err != nil {
+					return s.failRun(cfg, Result{}, simulatorFailure(err), err)
+				}
+			}
+		}
+	}
 
 	s.logger.Println()
 	var testErr error
 	var testExitCode int
-	result, code, err := s.runTests(cfg)
+	testStartTime := time.Now()
+	result, code, err := s.runTestPlans(cfg)
+	defer s.deleteSimulators(result.ShardSimulators)
 	if err != nil {
 		if code == -1 {
-			return result, err
+			failure := s.classifyFailure(result)
+			if failure.Category == xcodebuild.FailureCategoryUnknown {
+				failure = xcodebuild.ClassifyFailure(err.Error(), nil)
+			}
+			return s.failRun(cfg, result, failure, err)
 		}
 
 		testErr = err
 		testExitCode = code
 	}
+	result.SimulatorBootDuration = simulatorBootDuration
+	result.ResolvedDestinations = cfg.ResolvedDestinations
+	if result.XcresultPath != "" {
+		result.TestData, result.TestSummary = s.parseTestResults(result.XcresultPath)
+		s.warnUnmatchedTestSelection(cfg, result.TestSummary)
+	}
+	if !cfg.ExportXcresultAttempts {
+		result.AttemptXcresultPaths = nil
+	}
 
-	result.SimulatorDiagnosticsPath = s.teardownSimulator(cfg.Simulator.UDID, cfg.CollectSimulatorDiagnostics, cfg.IsSimulatorBooted, testErr)
+	if cfg.hasSimulator() {
+		if cfg.CollectCrashReports {
+			result.CrashReports, result.CrashReportPaths = s.collectCrashReports(cfg, result.ShardSimulators, testStartTime)
+		}
+		result.SimulatorDiagnosticsPath = s.teardownSimulator(cfg.Simulator.UDID, cfg.CollectSimulatorDiagnostics, cfg.IsSimulatorBooted, testErr)
+		for _, sim := range cfg.AdditionalSimulators {
+			s.shutdownSimulator(sim.UDID, cfg.CollectSimulatorDiagnostics, sim.State != simulatorShutdownState)
+		}
+	}
 
 	if testErr != nil {
 		s.logger.Println()
 		s.logger.Warnf("Xcode Test command exit code: %d", testExitCode)
 		s.logger.Errorf("Xcode Test command failed: %s", testErr)
+
+		failure := s.classifyFailure(result)
+		result.FailureCategory = failure.Category
+		s.printFailure(failure)
+
 		return result, testErr
 	}
 
 	// Cache swift PM
-	if cfg.CacheLevel == "swift_packages" {
+	if cfg.CacheLevel == "swift_packages" && cfg.ProjectPath != "" {
 		if err := s.cache.CollectSwiftPackages(cfg.ProjectPath); err != nil {
 			s.logger.Warnf("Failed to mark swift packages for caching: %s", err)
 		}
@@ -301,6 +689,9 @@ func (s XcodeTestRunner) Run(cfg Config) (Result, error) {
 
 	s.logger.Println()
 	s.logger.Infof("Xcode Test command succeeded.")
+	if result.SimulatorBootDuration > 0 {
+		s.logger.Printf("Simulator boot time: %s", result.SimulatorBootDuration.Round(time.Second))
+	}
 
 	return result, nil
 }
@@ -308,13 +699,53 @@ func (s XcodeTestRunner) Run(cfg Config) (Result, error) {
 func (s XcodeTestRunner) Export(result Result, testFailed bool) error {
 	// export test run status
 	s.outputExporter.ExportTestRunResult(testFailed)
+	if result.FailureCategory != "" {
+		s.outputExporter.ExportTestFailureCategory(string(result.FailureCategory))
+	}
+	if result.SimulatorBootDuration > 0 {
+		s.outputExporter.ExportSimulatorBootDuration(result.SimulatorBootDuration)
+	}
+	if len(result.ResolvedDestinations) > 0 {
+		s.outputExporter.ExportResolvedDestinations(result.ResolvedDestinations)
+	}
+
+	if len(result.TestPlanResults) > 0 {
+		if err := s.outputExporter.ExportTestPlanResults(result.DeployDir, result.XcresultPath, result.Scheme, result.TestPlanResults); err != nil {
+			s.logger.Warnf("Failed to export the test plan results: %s", err)
+		}
+	}
 
 	if result.XcresultPath != "" {
-		s.outputExporter.ExportXCResultBundle(result.DeployDir, result.XcresultPath, result.Scheme)
+		if len(result.TestPlanResults) == 0 {
+			s.outputExporter.ExportXCResultBundle(result.DeployDir, result.XcresultPath, result.Scheme)
+		}
 
-		if err := s.outputExporter.ExportFlakyTestCases(result.XcresultPath, false); err != nil {
+		if len(result.AttemptXcresultPaths) > 0 {
+			if err := s.outputExporter.ExportXCResultAttempts(result.DeployDir, result.AttemptXcresultPaths, result.Scheme); err != nil {
+				s.logger.Warnf("Failed to export the xcresult bundles of the test runs: %s", err)
+			}
+		}
+
+	}
+
+	if result.TestData != nil && result.TestSummary != nil {
+		if err := s.outputExporter.ExportFlakyTestCases(result.DeployDir, *result.TestData, *result.TestSummary); err != nil {
 			s.logger.Warnf("Failed to export flaky test cases: %s", err)
 		}
+
+		if err := s.outputExporter.ExportJUnitReport(result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports); err != nil {
+			s.logger.Warnf("Failed to export JUnit test report: %s", err)
+		}
+
+		if err := s.outputExporter.ExportTestSummary(result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports); err != nil {
+			s.logger.Warnf("Failed to export test summary: %s", err)
+		}
+	}
+
+	if len(result.QuarantinedTestResults) > 0 {
+		if err := s.outputExporter.ExportQuarantinedTestResults(result.DeployDir, result.QuarantinedTestResults); err != nil {
+			s.logger.Warnf("Failed to export the quarantined test results: %s", err)
+		}
 	}
 
 	// export xcodebuild build log
@@ -331,6 +762,27 @@ func (s XcodeTestRunner) Export(result Result, testFailed bool) error {
 		}
 	}
 
+	// export simulator app log
+	if result.SimulatorAppLog != "" {
+		if err := s.outputExporter.ExportSimulatorAppLog(result.DeployDir, result.SimulatorAppLog); err != nil {
+			s.logger.Warnf("Failed to export simulator app log: %s", err)
+		}
+	}
+
+	// export simulator screen recordings
+	if len(result.VideoPaths) > 0 {
+		if err := s.outputExporter.ExportSimulatorVideos(result.DeployDir, result.VideoPaths); err != nil {
+			s.logger.Warnf("Failed to export simulator screen recordings: %s", err)
+		}
+	}
+
+	// export crash reports
+	if len(result.CrashReportPaths) > 0 {
+		if err := s.outputExporter.ExportCrashReports(result.DeployDir, result.CrashReportPaths); err != nil {
+			s.logger.Warnf("Failed to export crash reports: %s", err)
+		}
+	}
+
 	// export simulator diagnostics log
 	if result.SimulatorDiagnosticsPath != "" {
 		diagnosticsName := filepath.Base(result.SimulatorDiagnosticsPath)
@@ -366,6 +818,19 @@ func (s XcodeTestConfigParser) parseAdditionalLogFormatterOptions(logFormatter,
 	}
 }
 
+// isSimulatorDestination returns false for the macOS destinations (including Mac Catalyst),
+// these run on the host machine without a simulator.
+func isSimulatorDestination(destinationSpecifier string) bool {
+	specifier, err := destination.NewSpecifier(destinationSpecifier)
+	if err != nil {
+		// Invalid specifiers are reported by the simulator destination parsing.
+		return true
+	}
+
+	platform, _ := specifier.Platform()
+	return platform != destination.MacOS
+}
+
 func (s XcodeTestConfigParser) getSimulatorForDestination(destinationSpecifier string) (destination.Device, error) {
 	simulatorDestination, err := destination.NewSimulator(destinationSpecifier)
 	if err != nil {
@@ -395,7 +860,11 @@ func (s XcodeTestConfigParser) getSimulatorForDestination(destinationSpecifier s
 	return device, nil
 }
 
-func (s XcodeTestRunner) prepareSimulator(enableSimulatorVerboseLog bool, simulator destination.Device, launchSimulator bool) error {
+/*
+prepareSimulator boots the simulator, applies the simulator setup (if any) and returns the measured boot time
+if the simulator was launched.
+*/
+func (s XcodeTestRunner) prepareSimulator(enableSimulatorVerboseLog bool, simulator destination.Device, launchSimulator bool, bootTimeout time.Duration, setup *simulator.Setup) (time.Duration, error) {
 	err := s.simulatorManager.ResetLaunchServices()
 	if err != nil {
 		s.logger.Warnf("Failed to apply simulator boot workaround: %s", err)
@@ -403,48 +872,179 @@ func (s XcodeTestRunner) prepareSimulator(enableSimulatorVerboseLog bool, simula
 
 	// Boot simulator
 	if enableSimulatorVerboseLog {
-		s.logger.Infof("Enabling Simulator verbose log for better diagnostics")
-		// Boot the simulator now, so verbose logging can be enabled, and it is kept booted after running tests.
-		if err := s.simulatorManager.Boot(simulator); err != nil {
-			return fmt.Errorf("%v", err)
+		if err := s.bootWithVerboseLog(simulator); err != nil {
+			return 0, err
 		}
-		if err := s.simulatorManager.EnableVerboseLog(simulator.UDID); err != nil {
-			return fmt.Errorf("%v", err)
+	}
+
+	var bootDuration time.Duration
+	if launchSimulator {
+		if bootDuration, err = s.bootSimulator(simulator.UDID, bootTimeout); err != nil {
+			return 0, err
 		}
+	}
 
-		s.logger.Println()
+	if setup != nil {
+		if err := s.setupSimulator(simulator.UDID, *setup, bootTimeout); err != nil {
+			return 0, err
+		}
 	}
 
-	if launchSimulator {
-		s.logger.Infof("Booting simulator (%s)...", simulator.UDID)
+	return bootDuration, nil
+}
+
+// bootSimulator launches the simulator and returns the measured boot time, the simulator is erased and rebooted if it fails to boot in time.
+func (s XcodeTestRunner) bootSimulator(simulatorID string, bootTimeout time.Duration) (time.Duration, error) {
+	s.logger.Infof("Booting simulator (%s)...", simulatorID)
+
+	bootStart := time.Now()
+	if err := s.launchSimulator(simulatorID, bootTimeout); err != nil {
+		s.logger.Warnf("%s", err)
+		s.logger.Warnf("Erasing and rebooting the simulator")
 
-		if err := s.simulatorManager.LaunchWithGUI(simulator.UDID); err != nil {
-			return fmt.Errorf("failed to boot simulator: %w", err)
+		// Only shut down simulators can be erased.
+		if err := s.simulatorManager.Shutdown(simulatorID); err != nil {
+			s.logger.Warnf("Failed to shut down simulator: %s", err)
+		}
+		if err := s.simulatorManager.Erase(simulatorID); err != nil {
+			return 0, fmt.Errorf("failed to erase simulator: %w", err)
 		}
 
-		progress.NewDefaultWrapper("Waiting for simulator boot").WrapAction(func() {
-			time.Sleep(60 * time.Second)
-		})
+		bootStart = time.Now()
+		if err := s.launchSimulator(simulatorID, bootTimeout); err != nil {
+			return 0, fmt.Errorf("simulator failed to boot: %w", err)
+		}
+	}
+	bootDuration := time.Since(bootStart)
 
-		s.logger.Println()
+	s.logger.Donef("Simulator booted in %s", bootDuration.Round(time.Second))
+	s.logger.Println()
+
+	return bootDuration, nil
+}
+
+// setupSimulator applies the simulator setup, the simulator is booted if it is not running yet.
+func (s XcodeTestRunner) setupSimulator(simulatorID string, setup simulator.Setup, bootTimeout time.Duration) error {
+	s.logger.Infof("Applying simulator setup (%s)", simulatorID)
+
+	// The setup operations need a booted simulator.
+	if err := s.simulatorManager.WaitForBootStatus(simulatorID, bootTimeout); err != nil {
+		return fmt.Errorf("failed to boot simulator for the setup: %w", err)
+	}
+
+	for _, certificatePath := range setup.RootCertificates {
+		if err := s.simulatorManager.AddRootCertificate(simulatorID, certificatePath); err != nil {
+			return err
+		}
+	}
+
+	if len(setup.Media) > 0 {
+		if err := s.simulatorManager.AddMedia(simulatorID, setup.Media); err != nil {
+			return err
+		}
+	}
+
+	for _, setting := range setup.Privacy {
+		if err := s.simulatorManager.SetPrivacy(simulatorID, setting); err != nil {
+			return err
+		}
+	}
+
+	if setup.Language != "" || setup.Locale != "" {
+		if err := s.simulatorManager.SetLanguage(simulatorID, setup.Language, setup.Locale); err != nil {
+			return err
+		}
+	}
+
+	if setup.Location != nil {
+		if err := s.simulatorManager.SetLocation(simulatorID, *setup.Location); err != nil {
+			return err
+		}
+	}
+
+	if setup.StatusBar != nil {
+		if err := s.simulatorManager.OverrideStatusBar(simulatorID, *setup.StatusBar); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Donef("Simulator setup applied")
+	s.logger.Println()
+
+	return nil
+}
+
+// launchSimulator launches the simulator with GUI and waits until it is ready to run tests.
+func (s XcodeTestRunner) launchSimulator(simulatorID string, timeout time.Duration) error {
+	if err := s.simulatorManager.LaunchWithGUI(simulatorID); err != nil {
+		return fmt.Errorf("failed to boot simulator: %w", err)
+	}
+
+	var err error
+	progress.NewDefaultWrapper("Waiting for simulator boot").WrapAction(func() {
+		err = s.waitForSimulatorBoot(simulatorID, timeout)
+	})
+
+	return err
+}
+
+/*
+waitForSimulatorBoot waits until `simctl bootstatus` reports the simulator booted,
+then until an app can be launched on the simulator (WaitForBootFinished), as the system services
+might still be starting when the boot status is already reported.
+*/
+func (s XcodeTestRunner) waitForSimulatorBoot(simulatorID string, timeout time.Duration) error {
+	start := time.Now()
+	if err := s.simulatorManager.WaitForBootStatus(simulatorID, timeout); err != nil {
+		return err
+	}
+
+	remaining := timeout - time.Since(start)
+	if remaining <= 0 {
+		return fmt.Errorf("simulator (%s) did not finish booting in %s", simulatorID, timeout)
+	}
+
+	return s.simulatorManager.WaitForBootFinished(simulatorID, remaining)
+}
+
+func (s XcodeTestRunner) bootWithVerboseLog(simulator destination.Device) error {
+	s.logger.Infof("Enabling Simulator verbose log for better diagnostics")
+	// Boot the simulator now, so verbose logging can be enabled, and it is kept booted after running tests.
+	if err := s.simulatorManager.Boot(simulator); err != nil {
+		return fmt.Errorf("%v", err)
 	}
+	if err := s.simulatorManager.EnableVerboseLog(simulator.UDID); err != nil {
+		return fmt.Errorf("%v", err)
+	}
+
+	s.logger.Println()
 
 	return nil
 }
 
 func (s XcodeTestRunner) runTests(cfg Config) (Result, int, error) {
-	// Run build
 	result := Result{
 		Scheme:    cfg.Scheme,
 		DeployDir: cfg.DeployDir,
 	}
 
-	// Run test
 	tempDir, err := s.pathProvider.CreateTempDir("XCUITestOutput")
 	if err != nil {
 		return result, -1, fmt.Errorf("could not create test output temporary directory: %w", err)
 	}
-	xcresultPath := path.Join(tempDir, fmt.Sprintf("Test-%s.xcresult", cfg.Scheme))
+	xcresultPath := path.Join(tempDir, cfg.testRunName()+".xcresult")
+
+	if cfg.XctestrunPath != "" {
+		xctestrunPath, err := s.prepareXctestrun(cfg.XctestrunPath, tempDir)
+		if err != nil {
+			return result, -1, err
+		}
+		cfg.XctestrunPath = xctestrunPath
+
+		testParams := s.utils.CreateTestParams(cfg, xcresultPath, "")
+		testParams.BeforeTestRunnerRetry = s.simulatorRetryPreparer(cfg, cfg.simulators())
+		return s.runBuiltTests(cfg, testParams, result)
+	}
 
 	swiftPackagesPath, err := s.cache.SwiftPackagesPath(cfg.ProjectPath)
 	if err != nil {
@@ -452,11 +1052,62 @@ func (s XcodeTestRunner) runTests(cfg Config) (Result, int, error) {
 	}
 
 	testParams := s.utils.CreateTestParams(cfg, xcresultPath, swiftPackagesPath)
+	testParams.BeforeTestRunnerRetry = s.simulatorRetryPreparer(cfg, cfg.simulators())
+
+	buildLog, exitCode, buildErr := s.xcodebuild.BuildForTesting(testParams)
+	result.XcodebuildBuildLog = buildLog
+
+	if buildErr != nil || cfg.LogFormatter == XcodebuildTool {
+		s.utils.PrintLastLinesOfXcodebuildBuildLog(buildLog, buildErr == nil)
+	}
+
+	if buildErr != nil {
+		return result, exitCode, fmt.Errorf("failed to build the tests: %w", buildErr)
+	}
+
+	s.logger.Println()
+	return s.runBuiltTests(cfg, testParams, result)
+}
+
+// runBuiltTests runs the built tests (sharded, if Parallel Shards is set), then the quarantined tests.
+func (s XcodeTestRunner) runBuiltTests(cfg Config, testParams xcodebuild.TestRunParams, result Result) (Result, int, error) {
+	var exitCode int
+	var testErr error
+	if cfg.ParallelShards > 1 {
+		result, exitCode, testErr = s.runShardedTests(cfg, testParams, result)
+	} else {
+		result, exitCode, testErr = s.testWithoutBuilding(cfg, testParams, result)
+	}
+	if exitCode == -1 {
+		return result, exitCode, testErr
+	}
+
+	result.QuarantinedTestResults = s.runQuarantinedTests(cfg, testParams)
+
+	return result, exitCode, testErr
+}
 
-	testLog, exitCode, testErr := s.xcodebuild.RunTest(testParams)
+func (s XcodeTestRunner) testWithoutBuilding(cfg Config, testParams xcodebuild.TestRunParams, result Result) (Result, int, error) {
+	xcresultPath := testParams.TestParams.TestOutputDir
+	recordings := s.startVideoRecordings(cfg, cfg.simulators(), filepath.Dir(xcresultPath))
+	appLogStreams := s.startAppLogStreams(cfg, cfg.simulators(), filepath.Dir(xcresultPath))
+	testLog, exitCode, testErr := s.xcodebuild.TestWithoutBuilding(testParams)
 	result.XcresultPath = xcresultPath
 	result.XcodebuildTestLog = testLog
 
+	attemptXcresultPaths := xcodebuild.PreviousAttemptResultBundles(xcresultPath)
+	if testErr != nil && cfg.TestRepetitionMode == xcodebuild.TestRepetitionRerunFailedTests {
+		result, exitCode, testErr = s.rerunFailedTests(cfg, testParams, result, exitCode, testErr)
+		testLog = result.XcodebuildTestLog
+	} else if len(attemptXcresultPaths) > 0 {
+		mergedXcresultPath := filepath.Join(filepath.Dir(xcresultPath), cfg.testRunName()+"-merged.xcresult")
+		if err := s.mergeXcresults(&result, append(attemptXcresultPaths, xcresultPath), mergedXcresultPath); err != nil {
+			s.logger.Warnf("Failed to merge the test results of the retried test runs, exporting the results of the last run: %s", err)
+		}
+	}
+	result.VideoPaths = s.stopVideoRecordings(recordings, cfg.RecordVideo, testErr)
+	result.SimulatorAppLog = s.stopAppLogStreams(appLogStreams, cfg.CollectSimulatorAppLog, testErr)
+
 	if testErr != nil || cfg.LogFormatter == XcodebuildTool {
 		s.utils.PrintLastLinesOfXcodebuildTestLog(testLog, testErr == nil)
 	}
@@ -464,6 +1115,114 @@ func (s XcodeTestRunner) runTests(cfg Config) (Result, int, error) {
 	return result, exitCode, testErr
 }
 
+/*
+simulatorRetryPreparer returns the callback erasing or rebooting the given simulators before an automatic test runner retry.
+The simulator setup is applied again after the boot, as erasing resets it and a reboot drops the status bar override.
+*/
+func (s XcodeTestRunner) simulatorRetryPreparer(cfg Config, sims []destination.Device) func(action xcodebuild.RetryAction) error {
+	if len(sims) == 0 {
+		return nil
+	}
+
+	return func(action xcodebuild.RetryAction) error {
+		for _, sim := range sims {
+			if err := s.simulatorManager.Shutdown(sim.UDID); err != nil {
+				// The simulator might be already shut down.
+				s.logger.Debugf("Failed to shut down simulator (%s): %s", sim.UDID, err)
+			}
+
+			if action == xcodebuild.RetryActionRetryAfterErase {
+				s.logger.Printf("Erasing simulator (%s)", sim.UDID)
+				if err := s.simulatorManager.Erase(sim.UDID); err != nil {
+					return err
+				}
+			}
+
+			s.logger.Printf("Booting simulator (%s)", sim.UDID)
+			if err := s.simulatorManager.Boot(sim); err != nil {
+				return err
+			}
+
+			if cfg.SimulatorSetup != nil {
+				if err := s.setupSimulator(sim.UDID, *cfg.SimulatorSetup, cfg.SimulatorBootTimeout); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// classifyFailure labels the failed test run based on the xcodebuild log of the failed phase and the failed tests of the xcresult bundle.
+func (s XcodeTestRunner) classifyFailure(result Result) xcodebuild.Failure {
+	xcodebuildLog := result.XcodebuildTestLog
+	if xcodebuildLog == "" {
+		xcodebuildLog = result.XcodebuildBuildLog
+	}
+
+	var failedTests []string
+	if result.TestSummary != nil {
+		failedTests, _ = failedTestIdentifiers(*result.TestSummary, xcresult.TestCaseTags(result.TestData), nil)
+	}
+
+	return xcodebuild.ClassifyFailure(xcodebuildLog, failedTests)
+}
+
+// failRun reports a test run that failed before xcodebuild could run the tests.
+func (s XcodeTestRunner) failRun(cfg Config, result Result, failure xcodebuild.Failure, err error) (Result, error) {
+	result.Scheme = cfg.Scheme
+	result.DeployDir = cfg.DeployDir
+	result.FailureCategory = failure.Category
+	s.printFailure(failure)
+
+	return result, err
+}
+
+// simulatorFailure labels the failed preparation of a Simulator as a Simulator boot failure.
+func simulatorFailure(err error) xcodebuild.Failure {
+	return xcodebuild.NewFailure(xcodebuild.FailureCategorySimulatorBootFailure, []string{err.Error()})
+}
+
+// parseTestResults parses the test results of the xcresult bundle for the test reports, nil values are returned if
+// the bundle can not be parsed.
+func (s XcodeTestRunner) parseTestResults(xcresultPath string) (*model3.TestData, *model3.TestSummary) {
+	testData, testSummary, err := s.xcresultProcessor.ParseTestResults(xcresultPath, false)
+	if err != nil {
+		s.logger.Warnf("Failed to parse the test results: %s", err)
+		return nil, nil
+	}
+	if testData == nil || testSummary == nil {
+		s.logger.Warnf("No test results found in %s", xcresultPath)
+		return nil, nil
+	}
+
+	return testData, testSummary
+}
+
+func (s XcodeTestRunner) printFailure(failure xcodebuild.Failure) {
+	s.logger.Println()
+	s.logger.Errorf("Failure category: %s", failure.Category)
+	s.logger.Warnf(failure.Summary)
+	if len(failure.Excerpt) > 0 {
+		s.logger.Printf("Relevant log lines:")
+		for _, line := range failure.Excerpt {
+			s.logger.Printf("  %s", line)
+		}
+	}
+}
+
+// mergeXcresults merges the given result bundles and updates the result to point to the merged bundle.
+func (s XcodeTestRunner) mergeXcresults(result *Result, xcresultPaths []string, mergedXcresultPath string) error {
+	if err := s.xcresultProcessor.Merge(xcresultPaths, mergedXcresultPath); err != nil {
+		return err
+	}
+
+	result.XcresultPath = mergedXcresultPath
+	result.AttemptXcresultPaths = xcresultPaths
+	return nil
+}
+
 func (s XcodeTestRunner) teardownSimulator(simulatorID string, simulatorDebug exportCondition, isSimulatorBooted bool, testErr error) string {
 	var simulatorDiagnosticsPath string
 
@@ -480,12 +1239,16 @@ func (s XcodeTestRunner) teardownSimulator(simulatorID string, simulatorDebug ex
 		}
 	}
 
+	s.shutdownSimulator(simulatorID, simulatorDebug, isSimulatorBooted)
+
+	return simulatorDiagnosticsPath
+}
+
+func (s XcodeTestRunner) shutdownSimulator(simulatorID string, simulatorDebug exportCondition, isSimulatorBooted bool) {
 	// Shut down the simulator if it was started by the step for diagnostic logs.
 	if !isSimulatorBooted && simulatorDebug != never {
 		if err := s.simulatorManager.Shutdown(simulatorID); err != nil {
 			s.logger.Warnf(err.Error())
 		}
 	}
-
-	return simulatorDiagnosticsPath
 }
@@ -1,16 +1,24 @@
 package step
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/crashreport"
 	commonMocks "github.com/bitrise-steplib/steps-xcode-test/mocks"
+	"github.com/bitrise-steplib/steps-xcode-test/simulator"
 	"github.com/bitrise-steplib/steps-xcode-test/step/mocks"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
 	"github.com/hashicorp/go-version"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,23 +31,27 @@ type configParserMocks struct {
 }
 
 type stepMocks struct {
-	commandFactory   *commonMocks.CommandFactory
-	xcodebuilder     *mocks.Xcodebuild
-	simulatorManager *mocks.SimulatorManager
-	cache            *mocks.SwiftPackageCache
-	outputExporter   *mocks.Exporter
-	pathModifier     *mocks.PathModifier
-	pathProvider     *mocks.PathProvider
+	commandFactory           *commonMocks.CommandFactory
+	xcodebuildCommandFactory *commonMocks.CommandFactory
+	xcodebuilder             *mocks.Xcodebuild
+	simulatorManager         *mocks.SimulatorManager
+	cache                    *mocks.SwiftPackageCache
+	outputExporter           *mocks.Exporter
+	xcresultProcessor        *mocks.XcresultProcessor
+	pathModifier             *mocks.PathModifier
+	pathProvider             *mocks.PathProvider
 }
 
 func Test_GivenStep_WhenRuns_ThenXcodebuildGetsCalled(t *testing.T) {
 	// Given
 	step, mocks := createStepAndMocks(t)
 
-	mocks.xcodebuilder.On("RunTest", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.Anything).Return("", 0, nil)
 	mocks.simulatorManager.On("ResetLaunchServices").Return(nil)
 	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
 	mocks.pathProvider.On("CreateTempDir", mock.Anything).Return("tmp_dir", nil)
+	mocks.xcresultProcessor.On("ParseTestResults", mock.Anything, false).Return(nil, nil, nil)
 
 	config := Config{
 		ProjectPath: "./project.xcodeproj",
@@ -66,7 +78,184 @@ func Test_GivenStep_WhenRuns_ThenXcodebuildGetsCalled(t *testing.T) {
 
 	// Then
 	require.NoError(t, err)
-	mocks.xcodebuilder.AssertCalled(t, "RunTest", mock.Anything)
+	mocks.xcodebuilder.AssertCalled(t, "BuildForTesting", mock.Anything)
+	mocks.xcodebuilder.AssertCalled(t, "TestWithoutBuilding", mock.Anything)
+}
+
+func Test_GivenMacOSDestination_WhenRuns_ThenSkipsTheSimulatorLifecycle(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return slices.Equal(params.TestParams.Destinations, []string{"platform=macOS,arch=arm64"}) && params.BeforeTestRunnerRetry == nil
+	})).Return("", 0, nil)
+	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
+	mocks.pathProvider.On("CreateTempDir", mock.Anything).Return("tmp_dir", nil)
+	mocks.xcresultProcessor.On("ParseTestResults", mock.Anything, false).Return(nil, nil, nil)
+
+	config := Config{
+		ProjectPath:              "./project.xcodeproj",
+		Scheme:                   "Project",
+		NonSimulatorDestinations: []string{"platform=macOS,arch=arm64"},
+		TestRepetitionMode:       "none",
+		LogFormatter:             "xcodebuild",
+
+		CollectSimulatorDiagnostics: always,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, result.SimulatorDiagnosticsPath)
+	mocks.xcodebuilder.AssertCalled(t, "TestWithoutBuilding", mock.Anything)
+	mocks.simulatorManager.AssertNotCalled(t, "ResetLaunchServices")
+	mocks.simulatorManager.AssertNotCalled(t, "CollectDiagnostics")
+}
+
+func Test_GivenSimulatorBootTimesOut_WhenRuns_ThenErasesAndReboots(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	simulatorID := "1234"
+	bootTimeout := 2 * time.Minute
+
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.Anything).Return("", 0, nil)
+	mocks.simulatorManager.On("ResetLaunchServices").Return(nil)
+	mocks.simulatorManager.On("LaunchWithGUI", simulatorID).Return(nil).Twice()
+	mocks.simulatorManager.On("WaitForBootStatus", simulatorID, bootTimeout).Return(errors.New("simulator (1234) did not finish booting in 2m0s")).Once()
+	mocks.simulatorManager.On("Shutdown", simulatorID).Return(nil)
+	mocks.simulatorManager.On("Erase", simulatorID).Return(nil)
+	mocks.simulatorManager.On("WaitForBootStatus", simulatorID, bootTimeout).Return(nil).Once()
+	mocks.simulatorManager.On("WaitForBootFinished", simulatorID, mock.Anything).Return(nil)
+	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
+	mocks.pathProvider.On("CreateTempDir", mock.Anything).Return("tmp_dir", nil)
+	mocks.xcresultProcessor.On("ParseTestResults", mock.Anything, false).Return(nil, nil, nil)
+
+	config := Config{
+		ProjectPath: "./project.xcodeproj",
+		Scheme:      "Project",
+
+		Simulator: destination.Device{UDID: simulatorID, State: "Shutdown"},
+
+		TestRepetitionMode: "none",
+		LogFormatter:       "xcodebuild",
+
+		CollectSimulatorDiagnostics: never,
+		SimulatorBootTimeout:        bootTimeout,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	require.Greater(t, result.SimulatorBootDuration, time.Duration(0))
+	mocks.simulatorManager.AssertCalled(t, "Erase", simulatorID)
+	mocks.simulatorManager.AssertNumberOfCalls(t, "LaunchWithGUI", 2)
+	mocks.simulatorManager.AssertNumberOfCalls(t, "WaitForBootFinished", 1)
+}
+
+func Test_GivenSimulatorFailsToBoot_WhenRunsAndExports_ThenExportsSimulatorBootFailure(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	simulatorID := "1234"
+	bootTimeout := 2 * time.Minute
+
+	mocks.simulatorManager.On("ResetLaunchServices").Return(nil)
+	mocks.simulatorManager.On("LaunchWithGUI", simulatorID).Return(nil)
+	mocks.simulatorManager.On("WaitForBootStatus", simulatorID, bootTimeout).Return(errors.New("simulator (1234) did not finish booting in 2m0s"))
+	mocks.simulatorManager.On("Shutdown", simulatorID).Return(nil)
+	mocks.simulatorManager.On("Erase", simulatorID).Return(nil)
+	mocks.outputExporter.On("ExportTestRunResult", true)
+	mocks.outputExporter.On("ExportTestFailureCategory", string(xcodebuild.FailureCategorySimulatorBootFailure))
+
+	config := Config{
+		ProjectPath: "./project.xcodeproj",
+		Scheme:      "Project",
+		DeployDir:   "deploy_dir",
+
+		Simulator: destination.Device{UDID: simulatorID, State: "Shutdown"},
+
+		TestRepetitionMode: "none",
+		LogFormatter:       "xcodebuild",
+
+		CollectSimulatorDiagnostics: never,
+		SimulatorBootTimeout:        bootTimeout,
+	}
+
+	// When
+	result, runErr := step.Run(config)
+	exportErr := step.Export(result, runErr != nil)
+
+	// Then
+	require.Error(t, runErr)
+	require.NoError(t, exportErr)
+	require.Equal(t, "Project", result.Scheme)
+	require.Equal(t, "deploy_dir", result.DeployDir)
+	mocks.xcodebuilder.AssertNotCalled(t, "BuildForTesting", mock.Anything)
+	mocks.outputExporter.AssertCalled(t, "ExportTestFailureCategory", string(xcodebuild.FailureCategorySimulatorBootFailure))
+}
+
+func Test_GivenStep_WhenBuildForTestingFails_ThenDoesNotRunTests(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("error: compile error\n** TEST BUILD FAILED **", 65, errors.New("exit status 65"))
+	mocks.simulatorManager.On("ResetLaunchServices").Return(nil)
+	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
+	mocks.pathProvider.On("CreateTempDir", mock.Anything).Return("tmp_dir", nil)
+
+	config := Config{
+		ProjectPath: "./project.xcodeproj",
+		Scheme:      "Project",
+
+		Simulator:         destination.Device{UDID: "1234"},
+		IsSimulatorBooted: true,
+
+		TestRepetitionMode: "none",
+		LogFormatter:       "xcodebuild",
+
+		CollectSimulatorDiagnostics: never,
+		HeadlessMode:                true,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.Error(t, err)
+	require.Equal(t, "error: compile error\n** TEST BUILD FAILED **", result.XcodebuildBuildLog)
+	require.Equal(t, xcodebuild.FailureCategoryCompileError, result.FailureCategory)
+	require.Empty(t, result.XcresultPath)
+	mocks.xcodebuilder.AssertNotCalled(t, "TestWithoutBuilding", mock.Anything)
+}
+
+func Test_GivenRetriedTestRun_WhenTestsFinish_ThenMergesTheResultBundlesOfTheAttempts(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	outputDir := t.TempDir()
+	xcresultPath := filepath.Join(outputDir, "Test-BullsEye.xcresult")
+	attemptXcresultPath := filepath.Join(outputDir, "Test-BullsEye-attempt-1.xcresult")
+	mergedXcresultPath := filepath.Join(outputDir, "Test-BullsEye-merged.xcresult")
+	require.NoError(t, os.MkdirAll(attemptXcresultPath, 0700))
+
+	cfg := Config{Scheme: "BullsEye", TestRepetitionMode: "none", LogFormatter: "xcpretty"}
+	testParams := xcodebuild.TestRunParams{TestParams: xcodebuild.TestParams{TestOutputDir: xcresultPath}}
+
+	mocks.xcodebuilder.On("TestWithoutBuilding", testParams).Return("", 0, nil)
+	mocks.xcresultProcessor.On("Merge", []string{attemptXcresultPath, xcresultPath}, mergedXcresultPath).Return(nil)
+
+	// When
+	result, exitCode, err := step.testWithoutBuilding(cfg, testParams, Result{})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, mergedXcresultPath, result.XcresultPath)
+	require.Equal(t, []string{attemptXcresultPath, xcresultPath}, result.AttemptXcresultPaths)
 }
 
 func Test_GivenStep_WhenInstallXcpretty_ThenInstallIt(t *testing.T) {
@@ -110,6 +299,20 @@ func Test_GivenConfigParser_WhenParsesConfig(t *testing.T) {
 				return config
 			},
 		},
+		{
+			name: "multiple destinations",
+			envsFunc: func() map[string]string {
+				envValues := defaultEnvValues()
+				envValues["destination"] = "platform=iOS Simulator,name=iPhone 8 Plus,OS=latest\n\nplatform=iOS Simulator,name=iPad Air,OS=latest\n"
+				return envValues
+			},
+			expectedConfig: func() Config {
+				config := defaultConfigs()
+				config.AdditionalSimulators = []destination.Device{defaultSimulator()}
+				config.ResolvedDestinations = append(config.ResolvedDestinations, "platform=iOS Simulator,name=iPad Air,OS=latest")
+				return config
+			},
+		},
 		{
 			name: "skip_tests",
 			envsFunc: func() map[string]string {
@@ -135,7 +338,7 @@ func Test_GivenConfigParser_WhenParsesConfig(t *testing.T) {
 			},
 			expectedConfig: func() Config {
 				config := defaultConfigs()
-				config.SkipTesting = []string{"Target2/Class2/Method1()", "Target2/Class2/Method2()"}
+				config.QuarantinedTests = []string{"Target2/Class2/Method1()", "Target2/Class2/Method2()"}
 				return config
 			},
 		},
@@ -160,6 +363,239 @@ func Test_GivenConfigParser_WhenParsesConfig(t *testing.T) {
 	}
 }
 
+func Test_GivenConfigParser_WhenMacCatalystDestination_ThenDoesNotLookUpSimulator(t *testing.T) {
+	// Given
+	envValues := defaultEnvValues()
+	envValues["destination"] = "platform=macOS,variant=Mac Catalyst"
+	configParser, mocks := createConfigParser(t, envValues)
+	mocks.pathModifier.On("AbsPath", mock.Anything).Return("/_tmp/BullsEye.xcworkspace", nil)
+
+	// When
+	config, err := configParser.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.False(t, config.hasSimulator())
+	require.False(t, config.IsSimulatorBooted)
+	require.Equal(t, []string{"platform=macOS,variant=Mac Catalyst"}, config.NonSimulatorDestinations)
+	mocks.deviceFinder.AssertNotCalled(t, "FindDevice", mock.Anything, mock.Anything)
+}
+
+func Test_GivenConfigParser_WhenXctestrunIsSet_ThenProjectIsNotRequired(t *testing.T) {
+	// Given
+	envValues := defaultEnvValues()
+	envValues["project_path"] = ""
+	envValues["scheme"] = ""
+	envValues["xctestrun"] = "./build/BullsEye_iphonesimulator17.5-arm64.xctestrun"
+	configParser, mocks := createConfigParser(t, envValues)
+	mocks.pathModifier.On("AbsPath", envValues["xctestrun"]).Return("/build/BullsEye_iphonesimulator17.5-arm64.xctestrun", nil)
+	mocks.deviceFinder.On("FindDevice", mock.Anything, mock.Anything).Return(defaultSimulator(), nil)
+
+	// When
+	config, err := configParser.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "", config.ProjectPath)
+	require.Equal(t, "BullsEye_iphonesimulator17.5-arm64", config.Scheme)
+	require.Equal(t, "/build/BullsEye_iphonesimulator17.5-arm64.xctestrun", config.XctestrunPath)
+}
+
+func Test_GivenConfigParser_WhenRetryRulesAreSet_ThenParsesTheRulesFileAndPatterns(t *testing.T) {
+	// Given
+	rulesPath := filepath.Join(t.TempDir(), "retry_rules.yml")
+	require.NoError(t, os.WriteFile(rulesPath, []byte("- pattern: Test runner never began executing tests\n  action: retry_after_reboot\n"), 0600))
+
+	envValues := defaultEnvValues()
+	envValues["test_runner_retry_rules"] = rulesPath
+	envValues["test_runner_retry_patterns"] = "Lost connection to test manager\n"
+	envValues["maximum_test_runner_retries"] = "3"
+	envValues["test_runner_retry_backoff"] = "10"
+	configParser, mocks := createConfigParser(t, envValues)
+	mocks.pathModifier.On("AbsPath", envValues["project_path"]).Return("/_tmp/BullsEye.xcworkspace", nil)
+	mocks.pathModifier.On("AbsPath", rulesPath).Return(rulesPath, nil)
+	mocks.deviceFinder.On("FindDevice", mock.Anything, mock.Anything).Return(defaultSimulator(), nil)
+
+	// When
+	config, err := configParser.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []xcodebuild.RetryRule{
+		{Pattern: "Test runner never began executing tests", Action: xcodebuild.RetryActionRetryAfterReboot},
+		{Pattern: "Lost connection to test manager", Action: xcodebuild.RetryActionRetry},
+	}, config.TestRunnerRetryRules)
+	require.Equal(t, 3, config.MaximumTestRunnerRetries)
+	require.Equal(t, 10*time.Second, config.TestRunnerRetryBackoff)
+}
+
+func Test_GivenConfigParser_WhenSimulatorSetupIsSet_ThenParsesItWithAbsolutePaths(t *testing.T) {
+	// Given
+	envValues := defaultEnvValues()
+	envValues["simulator_setup"] = `privacy:
+  - service: photos
+    bundle_id: io.bitrise.BullsEye
+media:
+  - ./fixtures/photo.jpg
+root_certificates:
+  - ./fixtures/proxy.pem
+`
+	configParser, mocks := createConfigParser(t, envValues)
+	mocks.pathModifier.On("AbsPath", envValues["project_path"]).Return("/_tmp/BullsEye.xcworkspace", nil)
+	mocks.pathModifier.On("AbsPath", "./fixtures/photo.jpg").Return("/_tmp/fixtures/photo.jpg", nil)
+	mocks.pathModifier.On("AbsPath", "./fixtures/proxy.pem").Return("/_tmp/fixtures/proxy.pem", nil)
+	mocks.deviceFinder.On("FindDevice", mock.Anything, mock.Anything).Return(defaultSimulator(), nil)
+
+	// When
+	config, err := configParser.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, &simulator.Setup{
+		Privacy:          []simulator.PrivacySetting{{Service: "photos", BundleID: "io.bitrise.BullsEye", Action: simulator.PrivacyActionGrant}},
+		Media:            []string{"/_tmp/fixtures/photo.jpg"},
+		RootCertificates: []string{"/_tmp/fixtures/proxy.pem"},
+	}, config.SimulatorSetup)
+}
+
+func Test_GivenSimulatorSetup_WhenRuns_ThenAppliesItBeforeTheTests(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	simulatorID := "1234"
+	setup := simulator.Setup{
+		Privacy:   []simulator.PrivacySetting{{Service: "location", BundleID: "io.bitrise.BullsEye", Action: simulator.PrivacyActionGrant}},
+		StatusBar: &simulator.StatusBarOverride{Time: "9:41"},
+		Language:  "de",
+		Location:  &simulator.Location{Latitude: 47.4979, Longitude: 19.0402},
+	}
+
+	mocks.simulatorManager.On("ResetLaunchServices").Return(nil)
+	mocks.simulatorManager.On("WaitForBootStatus", simulatorID, defaultSimulatorBootTimeout).Return(nil)
+	mocks.simulatorManager.On("SetPrivacy", simulatorID, setup.Privacy[0]).Return(nil)
+	mocks.simulatorManager.On("SetLanguage", simulatorID, "de", "").Return(nil)
+	mocks.simulatorManager.On("SetLocation", simulatorID, *setup.Location).Return(nil)
+	mocks.simulatorManager.On("OverrideStatusBar", simulatorID, *setup.StatusBar).Return(nil)
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.Anything).Return("", 0, nil)
+	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
+	mocks.pathProvider.On("CreateTempDir", mock.Anything).Return("tmp_dir", nil)
+	mocks.xcresultProcessor.On("ParseTestResults", mock.Anything, false).Return(nil, nil, nil)
+
+	config := Config{
+		ProjectPath: "./project.xcodeproj",
+		Scheme:      "Project",
+
+		Simulator:      destination.Device{UDID: simulatorID, State: "Shutdown"},
+		SimulatorSetup: &setup,
+
+		TestRepetitionMode: "none",
+		LogFormatter:       "xcodebuild",
+
+		CollectSimulatorDiagnostics: never,
+		HeadlessMode:                true,
+		SimulatorBootTimeout:        defaultSimulatorBootTimeout,
+	}
+
+	// When
+	_, err := step.Run(config)
+
+	// Then
+	require.NoError(t, err)
+	mocks.simulatorManager.AssertCalled(t, "OverrideStatusBar", simulatorID, *setup.StatusBar)
+	mocks.simulatorManager.AssertNotCalled(t, "AddMedia", mock.Anything, mock.Anything)
+}
+
+func Test_GivenSimulator_WhenPreparingRetryAfterErase_ThenErasesAndBootsIt(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	sim := defaultSimulator()
+	mocks.simulatorManager.On("Shutdown", sim.UDID).Return(errors.New("Unable to shutdown device in current state: Shutdown")).Once()
+	mocks.simulatorManager.On("Erase", sim.UDID).Return(nil).Once()
+	mocks.simulatorManager.On("Boot", sim).Return(nil).Once()
+
+	// When
+	err := step.simulatorRetryPreparer(Config{}, []destination.Device{sim})(xcodebuild.RetryActionRetryAfterErase)
+
+	// Then
+	require.NoError(t, err)
+}
+
+func Test_GivenSimulatorSetup_WhenPreparingRetryAfterErase_ThenAppliesTheSetupAgain(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	sim := defaultSimulator()
+	setup := &simulator.Setup{Language: "de", Locale: "de_DE"}
+	cfg := Config{SimulatorSetup: setup, SimulatorBootTimeout: time.Minute}
+	mocks.simulatorManager.On("Shutdown", sim.UDID).Return(nil).Once()
+	mocks.simulatorManager.On("Erase", sim.UDID).Return(nil).Once()
+	mocks.simulatorManager.On("Boot", sim).Return(nil).Once()
+	mocks.simulatorManager.On("WaitForBootStatus", sim.UDID, time.Minute).Return(nil).Once()
+	mocks.simulatorManager.On("SetLanguage", sim.UDID, "de", "de_DE").Return(nil).Once()
+
+	// When
+	err := step.simulatorRetryPreparer(cfg, []destination.Device{sim})(xcodebuild.RetryActionRetryAfterErase)
+
+	// Then
+	require.NoError(t, err)
+	mocks.simulatorManager.AssertExpectations(t)
+}
+
+func Test_GivenConfigParser_WhenInvalidProjectInputs_ThenFails(t *testing.T) {
+	tests := []struct {
+		name      string
+		envs      map[string]string
+		wantError string
+	}{
+		{
+			name:      "Missing project path",
+			envs:      map[string]string{"project_path": ""},
+			wantError: "'Project path' (project_path) is required if 'Test Run file' (xctestrun) is not set",
+		},
+		{
+			name:      "Missing scheme",
+			envs:      map[string]string{"scheme": ""},
+			wantError: "'Scheme' (scheme) is required if 'Test Run file' (xctestrun) is not set",
+		},
+		{
+			name:      "Test plan with xctestrun",
+			envs:      map[string]string{"xctestrun": "/build/BullsEye.xctestrun", "test_plan": "UnitTests"},
+			wantError: "the 'Test Plan' (test_plan) cannot be used together with 'Test Run file' (xctestrun), the test plan is selected when building the .xctestrun file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			envValues := defaultEnvValues()
+			for key, value := range tt.envs {
+				envValues[key] = value
+			}
+			configParser, mocks := createConfigParser(t, envValues)
+			mocks.pathModifier.On("AbsPath", mock.Anything).Return("/build/BullsEye.xctestrun", nil).Maybe()
+
+			// When
+			_, err := configParser.ProcessConfig()
+
+			// Then
+			require.EqualError(t, err, tt.wantError)
+		})
+	}
+}
+
+func Test_GivenZippedTestBundle_WhenFindingXctestrun_ThenReturnsTheSingleMatch(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	mocks.pathProvider.On("Glob", filepath.Join("tmp", "*.xctestrun")).Return([]string{}, nil)
+	mocks.pathProvider.On("Glob", filepath.Join("tmp", "*", "*.xctestrun")).Return([]string{"tmp/Build/BullsEye.xctestrun"}, nil)
+
+	// When
+	xctestrunPath, err := step.findXctestrun("tmp")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "tmp/Build/BullsEye.xctestrun", xctestrunPath)
+}
+
 func Test_GivenStep_WhenExportsTestResult_ThenSetsCorrectly(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -204,11 +640,20 @@ func Test_GivenStep_WhenExport_ThenExportsAllTestArtifacts(t *testing.T) {
 	diagnosticsName := filepath.Base(result.SimulatorDiagnosticsPath)
 
 	mocks.outputExporter.On("ExportTestRunResult", mock.Anything)
+	mocks.outputExporter.On("ExportTestFailureCategory", string(result.FailureCategory))
+	mocks.outputExporter.On("ExportSimulatorBootDuration", result.SimulatorBootDuration)
+	mocks.outputExporter.On("ExportResolvedDestinations", result.ResolvedDestinations)
 	mocks.outputExporter.On("ExportXCResultBundle", result.DeployDir, result.XcresultPath, result.Scheme)
-	mocks.outputExporter.On("ExportFlakyTestCases", result.XcresultPath, false).Return(nil)
+	mocks.outputExporter.On("ExportXCResultAttempts", result.DeployDir, result.AttemptXcresultPaths, result.Scheme).Return(nil)
+	mocks.outputExporter.On("ExportFlakyTestCases", result.DeployDir, *result.TestData, *result.TestSummary).Return(nil)
+	mocks.outputExporter.On("ExportJUnitReport", result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports).Return(nil)
+	mocks.outputExporter.On("ExportTestSummary", result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports).Return(nil)
 	mocks.outputExporter.On("ExportXcodebuildBuildLog", result.DeployDir, result.XcodebuildBuildLog).Return(nil)
 	mocks.outputExporter.On("ExportXcodebuildTestLog", result.DeployDir, result.XcodebuildTestLog).Return(nil)
 	mocks.outputExporter.On("ExportSimulatorDiagnostics", result.DeployDir, result.SimulatorDiagnosticsPath, diagnosticsName).Return(nil)
+	mocks.outputExporter.On("ExportSimulatorVideos", result.DeployDir, result.VideoPaths).Return(nil)
+	mocks.outputExporter.On("ExportSimulatorAppLog", result.DeployDir, result.SimulatorAppLog).Return(nil)
+	mocks.outputExporter.On("ExportCrashReports", result.DeployDir, result.CrashReportPaths).Return(nil)
 
 	// When
 	err := step.Export(result, false)
@@ -216,11 +661,20 @@ func Test_GivenStep_WhenExport_ThenExportsAllTestArtifacts(t *testing.T) {
 	// Then
 	assert.NoError(t, err)
 
+	mocks.outputExporter.AssertCalled(t, "ExportTestFailureCategory", string(result.FailureCategory))
+	mocks.outputExporter.AssertCalled(t, "ExportSimulatorBootDuration", result.SimulatorBootDuration)
+	mocks.outputExporter.AssertCalled(t, "ExportResolvedDestinations", result.ResolvedDestinations)
 	mocks.outputExporter.AssertCalled(t, "ExportXCResultBundle", result.DeployDir, result.XcresultPath, result.Scheme)
-	mocks.outputExporter.AssertCalled(t, "ExportFlakyTestCases", result.XcresultPath, false)
+	mocks.outputExporter.AssertCalled(t, "ExportXCResultAttempts", result.DeployDir, result.AttemptXcresultPaths, result.Scheme)
+	mocks.outputExporter.AssertCalled(t, "ExportFlakyTestCases", result.DeployDir, *result.TestData, *result.TestSummary)
+	mocks.outputExporter.AssertCalled(t, "ExportJUnitReport", result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports)
+	mocks.outputExporter.AssertCalled(t, "ExportTestSummary", result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports)
 	mocks.outputExporter.AssertCalled(t, "ExportXcodebuildBuildLog", result.DeployDir, result.XcodebuildBuildLog)
 	mocks.outputExporter.AssertCalled(t, "ExportXcodebuildTestLog", result.DeployDir, result.XcodebuildTestLog)
 	mocks.outputExporter.AssertCalled(t, "ExportSimulatorDiagnostics", result.DeployDir, result.SimulatorDiagnosticsPath, diagnosticsName)
+	mocks.outputExporter.AssertCalled(t, "ExportSimulatorVideos", result.DeployDir, result.VideoPaths)
+	mocks.outputExporter.AssertCalled(t, "ExportSimulatorAppLog", result.DeployDir, result.SimulatorAppLog)
+	mocks.outputExporter.AssertCalled(t, "ExportCrashReports", result.DeployDir, result.CrashReportPaths)
 }
 
 // Helpers
@@ -238,8 +692,16 @@ func defaultEnvValues() map[string]string {
 		"log_formatter":                      "xcpretty",
 		"cache_level":                        "swift_packages",
 		"verbose_log":                        "no",
+		"dry_run":                            "no",
+		"run_quarantined_tests":              "no",
+		"quarantined_test_iterations":        "3",
 		"collect_simulator_diagnostics":      "never",
 		"headless_mode":                      "yes",
+		"export_xcresult_attempts":           "no",
+		"simulator_lifecycle":                "reuse",
+		"record_video":                       "never",
+		"collect_simulator_app_log":          "never",
+		"collect_crash_reports":              "yes",
 	}
 }
 
@@ -248,8 +710,9 @@ func defaultConfigs() Config {
 		ProjectPath: "/_tmp/BullsEye.xcworkspace",
 		Scheme:      "BullsEye",
 
-		Simulator:         defaultSimulator(),
-		IsSimulatorBooted: false,
+		Simulator:            defaultSimulator(),
+		IsSimulatorBooted:    false,
+		ResolvedDestinations: []string{"platform=iOS Simulator,name=iPhone 8 Plus,OS=latest"},
 
 		TestRepetitionMode:            "none",
 		MaximumTestRepetitions:        3,
@@ -263,8 +726,16 @@ func defaultConfigs() Config {
 
 		CacheLevel: "swift_packages",
 
+		QuarantinedTestIterations: 3,
+
 		CollectSimulatorDiagnostics: never,
+		RecordVideo:                 never,
+		CollectSimulatorAppLog:      never,
+		SimulatorAppLogPredicate:    `process == "BullsEye"`,
+		CollectCrashReports:         true,
 		HeadlessMode:                true,
+		SimulatorBootTimeout:        defaultSimulatorBootTimeout,
+		SimulatorLifecycle:          simulatorLifecycleReuse,
 	}
 }
 func defaultSimulator() destination.Device {
@@ -280,9 +751,19 @@ func defaultResult() Result {
 		Scheme:                   "Scheme",
 		DeployDir:                "DeployDir",
 		XcresultPath:             "XcresultPath",
+		TestData:                 &model3.TestData{},
+		TestSummary:              testSummaryWithFailedTests("testFailing()"),
 		XcodebuildBuildLog:       "XcodebuildBuildLog",
 		XcodebuildTestLog:        "XcodebuildTestLog",
 		SimulatorDiagnosticsPath: "/testpath/SimulatorDiagnosticsPath",
+		FailureCategory:          xcodebuild.FailureCategoryTestFailures,
+		AttemptXcresultPaths:     []string{"XcresultPath-attempt-1", "XcresultPath-attempt-2"},
+		SimulatorBootDuration:    42 * time.Second,
+		VideoPaths:               []string{"/testpath/Test-Scheme-1234.mp4"},
+		SimulatorAppLog:          "SimulatorAppLog",
+		ResolvedDestinations:     []string{"platform=iOS Simulator,name=iPhone 15,OS=17.5"},
+		CrashReports:             []crashreport.Report{{Path: "/testpath/BullsEye-2024-05-02-101112.ips", Process: "BullsEye", PID: 12345}},
+		CrashReportPaths:         []string{"/testpath/BullsEye-2024-05-02-101112.ips", "/testpath/BullsEye-2024-05-02-101112.txt"},
 	}
 }
 
@@ -316,23 +797,27 @@ func createConfigParser(t *testing.T, envValues map[string]string) (XcodeTestCon
 func createStepAndMocks(t *testing.T) (XcodeTestRunner, stepMocks) {
 	logger := log.NewLogger()
 	commandFactory := new(commonMocks.CommandFactory)
+	xcodebuildCommandFactory := new(commonMocks.CommandFactory)
 	xcodebuilder := mocks.NewXcodebuild(t)
 	simulatorManager := mocks.NewSimulatorManager(t)
 	cache := mocks.NewSwiftPackageCache(t)
 	outputExporter := mocks.NewExporter(t)
+	xcresultProcessor := mocks.NewXcresultProcessor(t)
 	pathModifier := mocks.NewPathModifier(t)
 	pathProvider := mocks.NewPathProvider(t)
 	utils := NewUtils(logger)
 
-	step := NewXcodeTestRunner(logger, commandFactory, xcodebuilder, simulatorManager, cache, outputExporter, pathModifier, pathProvider, utils)
+	step := NewXcodeTestRunner(logger, commandFactory, xcodebuildCommandFactory, xcodebuilder, simulatorManager, cache, outputExporter, xcresultProcessor, pathModifier, pathProvider, utils)
 	mocks := stepMocks{
-		commandFactory:   commandFactory,
-		xcodebuilder:     xcodebuilder,
-		simulatorManager: simulatorManager,
-		cache:            cache,
-		outputExporter:   outputExporter,
-		pathModifier:     pathModifier,
-		pathProvider:     pathProvider,
+		commandFactory:           commandFactory,
+		xcodebuildCommandFactory: xcodebuildCommandFactory,
+		xcodebuilder:             xcodebuilder,
+		simulatorManager:         simulatorManager,
+		cache:                    cache,
+		outputExporter:           outputExporter,
+		xcresultProcessor:        xcresultProcessor,
+		pathModifier:             pathModifier,
+		pathProvider:             pathProvider,
 	}
 
 	return step, mocks
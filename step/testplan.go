@@ -0,0 +1,179 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xcode-test/output"
+)
+
+// allTestPlans selects every test plan of the scheme in the Test Plan (test_plan) input.
+const allTestPlans = "all"
+
+/*
+resolveTestPlans resolves the entries of the Test Plan (test_plan) input to test plan names.
+
+An entry is either a test plan name, a glob pattern (for example `*Tests`) or `all`. The test plans of the scheme are only
+listed (with `xcodebuild -showTestPlans`) if any of the entries is a pattern.
+*/
+func (s XcodeTestRunner) resolveTestPlans(cfg Config) ([]string, error) {
+	if !slices.ContainsFunc(cfg.TestPlans, isTestPlanPattern) {
+		return cfg.TestPlans, nil
+	}
+
+	available, err := s.listTestPlans(cfg.ProjectPath, cfg.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	testPlans, err := matchTestPlans(cfg.TestPlans, available, cfg.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Test plans:")
+	for _, testPlan := range testPlans {
+		s.logger.Printf("- %s", testPlan)
+	}
+
+	return testPlans, nil
+}
+
+func isTestPlanPattern(entry string) bool {
+	return entry == allTestPlans || strings.ContainsAny(entry, "*?[")
+}
+
+func (s XcodeTestRunner) listTestPlans(projectPath, scheme string) ([]string, error) {
+	out, err := s.runXcodebuildQuery(projectPath, "-showTestPlans", "-json", "-scheme", scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the test plans of the scheme (%s): %w", scheme, err)
+	}
+
+	return parseXcodebuildTestPlans(out)
+}
+
+// matchTestPlans returns the available test plans matching the entries in the order of the entries, without duplicates.
+func matchTestPlans(entries, available []string, scheme string) ([]string, error) {
+	var testPlans []string
+	for _, entry := range entries {
+		var matched bool
+		for _, testPlan := range available {
+			if entry != allTestPlans {
+				if ok, err := filepath.Match(entry, testPlan); err != nil {
+					return nil, fmt.Errorf("invalid 'Test Plan' (test_plan) pattern (%s): %w", entry, err)
+				} else if !ok {
+					continue
+				}
+			}
+
+			matched = true
+			if !slices.Contains(testPlans, testPlan) {
+				testPlans = append(testPlans, testPlan)
+			}
+		}
+
+		if !matched {
+			if len(available) == 0 {
+				return nil, fmt.Errorf("'Test Plan' (test_plan) %s is not found, the scheme (%s) has no test plans", entry, scheme)
+			}
+			return nil, fmt.Errorf("'Test Plan' (test_plan) %s is not found in the scheme (%s), available test plans: %s", entry, scheme, strings.Join(available, ", "))
+		}
+	}
+
+	return testPlans, nil
+}
+
+/*
+runTestPlans runs the tests of the test plans one after the other, every test plan is built and tested with its own
+result bundle. The result bundles are merged into a single one, so that the reports cover all the test plans.
+
+The tests are built for every test plan, as a test plan can change build settings (for example the sanitizers and
+the code coverage), which a shared build-for-testing would ignore. The DerivedData is shared, so the builds after the
+first one are incremental.
+
+If the result bundles can not be merged, the result has no xcresult path: the reports of the combined test results
+(xcresult, JUnit report, test summary and flaky tests) are not exported, only the result bundles of the test plans.
+
+A failing test plan does not stop the rest of the test plans, the error of the first failed test plan is returned.
+*/
+func (s XcodeTestRunner) runTestPlans(cfg Config) (Result, int, error) {
+	if len(cfg.TestPlans) <= 1 {
+		if len(cfg.TestPlans) == 1 {
+			cfg.TestPlan = cfg.TestPlans[0]
+		}
+		return s.runTests(cfg)
+	}
+
+	result := Result{
+		Scheme:    cfg.Scheme,
+		DeployDir: cfg.DeployDir,
+	}
+
+	var testErr error
+	var testExitCode int
+	var xcresultPaths []string
+	for _, testPlan := range cfg.TestPlans {
+		s.logger.Println()
+		s.logger.Infof("Running test plan: %s", testPlan)
+
+		cfg.TestPlan = testPlan
+		testPlanResult, exitCode, err := s.runTests(cfg)
+		result.ShardSimulators = append(result.ShardSimulators, testPlanResult.ShardSimulators...)
+		if err != nil && exitCode == -1 {
+			return result, exitCode, err
+		}
+
+		result.XcodebuildBuildLog = joinLogs(result.XcodebuildBuildLog, testPlanResult.XcodebuildBuildLog)
+		result.XcodebuildTestLog = joinLogs(result.XcodebuildTestLog, testPlanResult.XcodebuildTestLog)
+		result.SimulatorAppLog = joinLogs(result.SimulatorAppLog, testPlanResult.SimulatorAppLog)
+		result.VideoPaths = append(result.VideoPaths, testPlanResult.VideoPaths...)
+		result.AttemptXcresultPaths = append(result.AttemptXcresultPaths, testPlanResult.AttemptXcresultPaths...)
+		result.QuarantinedTestResults = append(result.QuarantinedTestResults, testPlanResult.QuarantinedTestResults...)
+		if testPlanResult.XcresultPath != "" {
+			xcresultPaths = append(xcresultPaths, testPlanResult.XcresultPath)
+			result.TestPlanResults = append(result.TestPlanResults, output.TestPlanResult{
+				TestPlan:     testPlan,
+				XcresultPath: testPlanResult.XcresultPath,
+				Failed:       err != nil,
+			})
+		}
+
+		if err != nil {
+			s.logger.Warnf("Test plan %s failed: %s", testPlan, err)
+			if testErr == nil {
+				testErr = fmt.Errorf("test plan %s: %w", testPlan, err)
+				testExitCode = exitCode
+			}
+		}
+	}
+
+	switch len(xcresultPaths) {
+	case 0:
+	case 1:
+		result.XcresultPath = xcresultPaths[0]
+	default:
+		tempDir, err := s.pathProvider.CreateTempDir("XCUITestOutput")
+		if err != nil {
+			return result, -1, fmt.Errorf("could not create test output temporary directory: %w", err)
+		}
+
+		mergedXcresultPath := filepath.Join(tempDir, fmt.Sprintf("Test-%s.xcresult", cfg.Scheme))
+		if err := s.xcresultProcessor.Merge(xcresultPaths, mergedXcresultPath); err != nil {
+			s.logger.Warnf("Failed to merge the test results of the test plans, only the results of the test plans are exported: %s", err)
+		} else {
+			result.XcresultPath = mergedXcresultPath
+		}
+	}
+
+	return result, testExitCode, testErr
+}
+
+func joinLogs(log, testPlanLog string) string {
+	if log == "" || testPlanLog == "" {
+		return log + testPlanLog
+	}
+	return log + "\n" + testPlanLog
+}
@@ -0,0 +1,176 @@
+package step
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/output"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_matchTestPlans(t *testing.T) {
+	available := []string{"FullTests", "SnapshotTests", "UITests", "UnitTests"}
+
+	tests := []struct {
+		name      string
+		entries   []string
+		want      []string
+		wantError string
+	}{
+		{
+			name:    "All test plans",
+			entries: []string{"all"},
+			want:    available,
+		},
+		{
+			name:    "Glob pattern and name in the order of the entries",
+			entries: []string{"UITests", "*nitTests", "U*"},
+			want:    []string{"UITests", "UnitTests"},
+		},
+		{
+			name:      "Missing test plan",
+			entries:   []string{"UnitTests", "Performance*"},
+			wantError: "'Test Plan' (test_plan) Performance* is not found in the scheme (BullsEye), available test plans: FullTests, SnapshotTests, UITests, UnitTests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchTestPlans(tt.entries, available, "BullsEye")
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_GivenTestPlanPattern_WhenRuns_ThenRunsEveryMatchingTestPlan(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	mockXcodebuildQuery(mocks, []string{"-showTestPlans", "-json", "-scheme", "BullsEye", "-project", "/project/BullsEye.xcodeproj"}, xcodebuildTestPlansOutput)
+	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
+	mocks.pathProvider.On("CreateTempDir", "XCUITestOutput").Return("tmp_dir", nil)
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", testPlanParams("FullTests")).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", testPlanParams("UnitTests")).Return("", 1, errors.New("exit status 65"))
+	mocks.xcresultProcessor.On("Merge", []string{"tmp_dir/Test-BullsEye-FullTests.xcresult", "tmp_dir/Test-BullsEye-UnitTests.xcresult"}, "tmp_dir/Test-BullsEye.xcresult").Return(nil)
+	mocks.xcresultProcessor.On("ParseTestResults", mock.Anything, false).Return(nil, nil, errors.New("no test results"))
+
+	config := Config{
+		ProjectPath: "/project/BullsEye.xcodeproj",
+		Scheme:      "BullsEye",
+		TestPlans:   []string{"all"},
+
+		NonSimulatorDestinations: []string{"platform=macOS"},
+
+		TestRepetitionMode:          "none",
+		LogFormatter:                "xcbeautify",
+		CollectSimulatorDiagnostics: never,
+	}
+
+	// When
+	result, err := step.Run(config)
+
+	// Then
+	require.EqualError(t, err, "test plan UnitTests: exit status 65")
+	require.Equal(t, "tmp_dir/Test-BullsEye.xcresult", result.XcresultPath)
+	require.Equal(t, []output.TestPlanResult{
+		{TestPlan: "FullTests", XcresultPath: "tmp_dir/Test-BullsEye-FullTests.xcresult"},
+		{TestPlan: "UnitTests", XcresultPath: "tmp_dir/Test-BullsEye-UnitTests.xcresult", Failed: true},
+	}, result.TestPlanResults)
+	mocks.xcodebuilder.AssertNumberOfCalls(t, "BuildForTesting", 2)
+}
+
+func Test_GivenTestPlanResults_WhenMergeFails_ThenHasNoCombinedResultBundle(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	mocks.cache.On("SwiftPackagesPath", mock.Anything).Return("", nil)
+	mocks.pathProvider.On("CreateTempDir", "XCUITestOutput").Return("tmp_dir", nil)
+	mocks.xcodebuilder.On("BuildForTesting", mock.Anything).Return("", 0, nil)
+	mocks.xcodebuilder.On("TestWithoutBuilding", mock.Anything).Return("", 0, nil)
+	mocks.xcresultProcessor.On("Merge", mock.Anything, "tmp_dir/Test-BullsEye.xcresult").Return(errors.New("xcresulttool failed"))
+
+	config := Config{
+		ProjectPath: "/project/BullsEye.xcodeproj",
+		Scheme:      "BullsEye",
+		TestPlans:   []string{"FullTests", "UnitTests"},
+
+		TestRepetitionMode: "none",
+		LogFormatter:       "xcbeautify",
+	}
+
+	// When
+	result, exitCode, err := step.runTestPlans(config)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Empty(t, result.XcresultPath)
+	require.Len(t, result.TestPlanResults, 2)
+}
+
+func Test_GivenTestPlanResultsWithoutCombinedResultBundle_WhenExport_ThenOnlyExportsTheTestPlans(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	result := Result{
+		Scheme:    "BullsEye",
+		DeployDir: "DeployDir",
+		TestPlanResults: []output.TestPlanResult{
+			{TestPlan: "FullTests", XcresultPath: "tmp_dir/Test-BullsEye-FullTests.xcresult"},
+			{TestPlan: "UnitTests", XcresultPath: "tmp_dir/Test-BullsEye-UnitTests.xcresult"},
+		},
+	}
+
+	mocks.outputExporter.On("ExportTestRunResult", false)
+	mocks.outputExporter.On("ExportTestPlanResults", result.DeployDir, "", result.Scheme, result.TestPlanResults).Return(nil)
+
+	// When
+	err := step.Export(result, false)
+
+	// Then
+	require.NoError(t, err)
+	mocks.outputExporter.AssertNotCalled(t, "ExportJUnitReport", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mocks.outputExporter.AssertNotCalled(t, "ExportTestSummary", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func testPlanParams(testPlan string) interface{} {
+	return mock.MatchedBy(func(params xcodebuild.TestRunParams) bool {
+		return params.TestParams.TestPlan == testPlan
+	})
+}
+
+func Test_GivenTestPlanResults_WhenExport_ThenExportsTheTestPlansInsteadOfTheResultBundle(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	result := Result{
+		Scheme:       "BullsEye",
+		DeployDir:    "DeployDir",
+		XcresultPath: "tmp_dir/Test-BullsEye.xcresult",
+		TestData:     &model3.TestData{},
+		TestSummary:  &model3.TestSummary{},
+		TestPlanResults: []output.TestPlanResult{
+			{TestPlan: "FullTests", XcresultPath: "tmp_dir/Test-BullsEye-FullTests.xcresult"},
+			{TestPlan: "UnitTests", XcresultPath: "tmp_dir/Test-BullsEye-UnitTests.xcresult"},
+		},
+	}
+
+	mocks.outputExporter.On("ExportTestRunResult", false)
+	mocks.outputExporter.On("ExportTestPlanResults", result.DeployDir, result.XcresultPath, result.Scheme, result.TestPlanResults).Return(nil)
+	mocks.outputExporter.On("ExportFlakyTestCases", result.DeployDir, *result.TestData, *result.TestSummary).Return(nil)
+	mocks.outputExporter.On("ExportJUnitReport", result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports).Return(nil)
+	mocks.outputExporter.On("ExportTestSummary", result.DeployDir, *result.TestData, *result.TestSummary, result.CrashReports).Return(nil)
+
+	// When
+	err := step.Export(result, false)
+
+	// Then
+	require.NoError(t, err)
+	mocks.outputExporter.AssertNotCalled(t, "ExportXCResultBundle", mock.Anything, mock.Anything, mock.Anything)
+}
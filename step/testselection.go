@@ -0,0 +1,227 @@
+package step
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/bitrise-steplib/steps-xcode-test/xcresult"
+)
+
+// testTagPrefix marks a Swift Testing tag in the Only Testing (only_testing) and Skip Testing (skip_testing) inputs.
+const testTagPrefix = "tag:"
+
+// testSelection is the tests and the Swift Testing tags of the Only Testing (only_testing) or Skip Testing (skip_testing) input.
+type testSelection struct {
+	Tests []string
+	Tags  []string
+}
+
+/*
+parseTestSelection parses the newline separated entries of a test selection input, for example:
+
+	BullsEyeTests/BullsEyeTests/testSlider
+	BullsEyeUITests/*
+	tag:critical
+
+An entry is either a test identifier (<TestTarget>[/<TestClass>[/<TestMethod>]]) or a Swift Testing tag prefixed with `tag:`.
+*/
+func parseTestSelection(input string) (testSelection, error) {
+	return parseTestSelectionEntries(splitLines(input))
+}
+
+// parseTestSelectionEntries parses the test identifier and `tag:` prefixed Swift Testing tag entries of a test selection.
+func parseTestSelectionEntries(entries []string) (testSelection, error) {
+	var selection testSelection
+	for _, entry := range entries {
+		if tag, isTag := strings.CutPrefix(entry, testTagPrefix); isTag {
+			tag = strings.TrimSpace(tag)
+			if tag == "" {
+				return testSelection{}, fmt.Errorf("empty tag: %s", entry)
+			}
+			if !slices.Contains(selection.Tags, tag) {
+				selection.Tags = append(selection.Tags, tag)
+			}
+			continue
+		}
+
+		test, err := parseTestIdentifier(entry)
+		if err != nil {
+			return testSelection{}, err
+		}
+		if !slices.Contains(selection.Tests, test) {
+			selection.Tests = append(selection.Tests, test)
+		}
+	}
+
+	return selection, nil
+}
+
+/*
+validateTestSelection checks that the Only Testing (only_testing) and Skip Testing (skip_testing) inputs don't conflict with
+each other and with the xcodebuild options. Selected tests which are quarantined are skipped, this is reported as a warning.
+*/
+func (s XcodeTestConfigParser) validateTestSelection(onlyTesting, skipTesting, quarantined testSelection, xcodebuildOptions []string) error {
+	for _, option := range []struct {
+		flag  string
+		set   bool
+		input string
+	}{
+		{flag: "-only-testing", set: len(onlyTesting.Tests) > 0, input: "'Only Testing' (only_testing)"},
+		{flag: "-skip-testing", set: len(skipTesting.Tests) > 0, input: "'Skip Testing' (skip_testing)"},
+		{flag: "-only-test-tags", set: len(onlyTesting.Tags) > 0, input: "'Only Testing' (only_testing)"},
+		{flag: "-skip-test-tags", set: len(skipTesting.Tags) > 0, input: "'Skip Testing' (skip_testing)"},
+		{flag: "-skip-test-tags", set: len(quarantined.Tags) > 0, input: "the quarantined tags of 'Quarantine file' (quarantine_file)"},
+	} {
+		if !option.set {
+			continue
+		}
+		if slices.ContainsFunc(xcodebuildOptions, func(xcodebuildOption string) bool {
+			return xcodebuildOption == option.flag || strings.HasPrefix(xcodebuildOption, option.flag+":")
+		}) {
+			return fmt.Errorf("`%s` option found in 'Additional options for the xcodebuild command' (xcodebuild_options), please clear %s input as only one can be set", option.flag, option.input)
+		}
+	}
+
+	for _, test := range onlyTesting.Tests {
+		if slices.Contains(skipTesting.Tests, test) {
+			return fmt.Errorf("%s is selected in 'Only Testing' (only_testing) and skipped in 'Skip Testing' (skip_testing) at the same time", test)
+		}
+	}
+	for _, tag := range onlyTesting.Tags {
+		if slices.Contains(skipTesting.Tags, tag) {
+			return fmt.Errorf("tag %s is selected in 'Only Testing' (only_testing) and skipped in 'Skip Testing' (skip_testing) at the same time", tag)
+		}
+	}
+
+	for _, test := range onlyTesting.Tests {
+		for _, quarantinedTest := range quarantined.Tests {
+			if isTestCaseOf(test, quarantinedTest) {
+				s.logger.Warnf("%s is selected in 'Only Testing' (only_testing), but it is skipped as %s is quarantined", test, quarantinedTest)
+				break
+			}
+		}
+	}
+	for _, tag := range onlyTesting.Tags {
+		if slices.Contains(quarantined.Tags, tag) {
+			s.logger.Warnf("tag %s is selected in 'Only Testing' (only_testing), but its tests are skipped as the tag is quarantined", tag)
+		}
+	}
+
+	return nil
+}
+
+// skippedTests are the tests of the Skip Testing (skip_testing) input and the quarantined tests.
+func (cfg Config) skippedTests() []string {
+	skippedTests := slices.Clone(cfg.SkipTesting)
+	for _, test := range cfg.QuarantinedTests {
+		if !slices.Contains(skippedTests, test) {
+			skippedTests = append(skippedTests, test)
+		}
+	}
+	return skippedTests
+}
+
+// skippedTestTags are the tags of the Skip Testing (skip_testing) input and the quarantined tags.
+func (cfg Config) skippedTestTags() []string {
+	skippedTestTags := slices.Clone(cfg.SkipTestTags)
+	for _, tag := range cfg.QuarantinedTestTags {
+		if !slices.Contains(skippedTestTags, tag) {
+			skippedTestTags = append(skippedTestTags, tag)
+		}
+	}
+	return skippedTestTags
+}
+
+/*
+warnUnmatchedTestSelection warns about the tests of the Only Testing (only_testing) input which are not found in the
+test results, and if no test ran at all.
+*/
+func (s XcodeTestRunner) warnUnmatchedTestSelection(cfg Config, testSummary *model3.TestSummary) {
+	if len(cfg.OnlyTesting) == 0 && len(cfg.OnlyTestTags) == 0 {
+		return
+	}
+
+	if testSummary == nil {
+		s.logger.Warnf("Failed to check the 'Only Testing' (only_testing) selection: no test results")
+		return
+	}
+
+	testCases := testCaseIdentifiers(*testSummary)
+	if len(testCases) == 0 {
+		s.logger.Warnf("The 'Only Testing' (only_testing) selection did not match any test")
+		return
+	}
+
+	for _, test := range cfg.OnlyTesting {
+		if !slices.ContainsFunc(testCases, func(testCase string) bool { return isTestCaseOf(testCase, test) }) {
+			s.logger.Warnf("'Only Testing' (only_testing) %s did not match any test in the test results", test)
+		}
+	}
+}
+
+// testCaseIdentifiers returns the test cases of the test results in the <TestTarget>/<TestClass>/<TestMethod> format.
+func testCaseIdentifiers(testSummary model3.TestSummary) []string {
+	var identifiers []string
+	for _, testPlan := range testSummary.TestPlans {
+		for _, testBundle := range testPlan.TestBundles {
+			for _, testSuite := range testBundle.TestSuites {
+				for _, testCase := range testSuite.TestCases {
+					identifiers = append(identifiers, testCaseIdentifier(testBundle.Name, testCase.ClassName, testCase.Name))
+				}
+			}
+		}
+	}
+	return identifiers
+}
+
+/*
+testCaseIdentifier returns the <TestTarget>/<TestClass>/<TestMethod> identifier of a test case. The argument combinations
+of a parameterized Swift Testing test case share the identifier of the test function, as xcodebuild selects them together.
+
+xcodebuild selects XCTest methods without the parentheses (testFailing) and Swift Testing functions with their
+argument labels (isEven(number:)). XCTest methods are recognized by the test prefix and the missing arguments.
+*/
+func testCaseIdentifier(bundle, className, name string) string {
+	name, _ = xcresult.SplitTestCaseName(name)
+	if strings.HasPrefix(name, "test") && strings.HasSuffix(name, "()") {
+		name = strings.TrimSuffix(name, "()")
+	}
+	return fmt.Sprintf("%s/%s/%s", bundle, className, name)
+}
+
+/*
+parseTestIdentifier validates a test identifier for the `-only-testing` and `-skip-testing` xcodebuild options:
+<TestTarget>[/<TestClass>[/<TestMethod>]].
+
+Wildcards are supported at the class or target level: `BullsEyeTests/*` selects the test target and
+`BullsEyeTests/BullsEyeTests/*` selects the test class.
+*/
+func parseTestIdentifier(test string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(test), "/")
+	for len(parts) > 1 && parts[len(parts)-1] == "*" {
+		parts = parts[:len(parts)-1]
+	}
+
+	if len(parts) > 3 {
+		return "", fmt.Errorf("invalid test identifier (%s), should be <TestTarget>[/<TestClass>[/<TestMethod>]]", test)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("invalid test identifier (%s), should be <TestTarget>[/<TestClass>[/<TestMethod>]]", test)
+		}
+		if strings.ContainsAny(part, "*?") {
+			return "", fmt.Errorf("invalid test identifier (%s), wildcards are only supported at the class or target level (for example BullsEyeTests/* or BullsEyeTests/BullsEyeTests/*)", test)
+		}
+	}
+
+	return strings.Join(parts, "/"), nil
+}
+
+// isTestCaseOf reports whether the test (<TestTarget>[/<TestClass>[/<TestMethod>]]) is the given test or belongs to it.
+func isTestCaseOf(test, identifier string) bool {
+	test = strings.TrimSuffix(test, "()")
+	identifier = strings.TrimSuffix(identifier, "()")
+	return test == identifier || strings.HasPrefix(test, identifier+"/")
+}
@@ -0,0 +1,184 @@
+package step
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTestIdentifier(t *testing.T) {
+	tests := []struct {
+		test      string
+		want      string
+		wantError string
+	}{
+		{test: "BullsEyeTests/BullsEyeTests/testFlakyAnimation", want: "BullsEyeTests/BullsEyeTests/testFlakyAnimation"},
+		{test: "BullsEyeTests/BullsEyeTests/*", want: "BullsEyeTests/BullsEyeTests"},
+		{test: "BullsEyeTests/*", want: "BullsEyeTests"},
+		{test: "BullsEyeTests/*/*", want: "BullsEyeTests"},
+		{test: "*", wantError: "invalid test identifier (*), wildcards are only supported at the class or target level (for example BullsEyeTests/* or BullsEyeTests/BullsEyeTests/*)"},
+		{test: "BullsEyeTests/*/testFlakyAnimation", wantError: "invalid test identifier (BullsEyeTests/*/testFlakyAnimation), wildcards are only supported at the class or target level (for example BullsEyeTests/* or BullsEyeTests/BullsEyeTests/*)"},
+		{test: "BullsEyeTests//testFlakyAnimation", wantError: "invalid test identifier (BullsEyeTests//testFlakyAnimation), should be <TestTarget>[/<TestClass>[/<TestMethod>]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.test, func(t *testing.T) {
+			got, err := parseTestIdentifier(tt.test)
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseTestSelection(t *testing.T) {
+	selection, err := parseTestSelection("BullsEyeTests/BullsEyeTests/testSlider\n\nBullsEyeUITests/*\n tag:critical \ntag:critical\nBullsEyeUITests")
+
+	require.NoError(t, err)
+	require.Equal(t, testSelection{
+		Tests: []string{"BullsEyeTests/BullsEyeTests/testSlider", "BullsEyeUITests"},
+		Tags:  []string{"critical"},
+	}, selection)
+
+	_, err = parseTestSelection("tag: ")
+	require.EqualError(t, err, "empty tag: tag:")
+}
+
+func Test_GivenTestSelection_WhenValidating_ThenReportsConflicts(t *testing.T) {
+	tests := []struct {
+		name              string
+		onlyTesting       testSelection
+		skipTesting       testSelection
+		xcodebuildOptions []string
+		wantError         string
+	}{
+		{
+			name:        "Valid selection",
+			onlyTesting: testSelection{Tests: []string{"BullsEyeTests"}, Tags: []string{"critical"}},
+			skipTesting: testSelection{Tests: []string{"BullsEyeTests/BullsEyeTests/testSlow"}},
+		},
+		{
+			name:              "Only testing in the xcodebuild options",
+			onlyTesting:       testSelection{Tests: []string{"BullsEyeTests"}},
+			xcodebuildOptions: []string{"-only-testing:BullsEyeUITests"},
+			wantError:         "`-only-testing` option found in 'Additional options for the xcodebuild command' (xcodebuild_options), please clear 'Only Testing' (only_testing) input as only one can be set",
+		},
+		{
+			name:              "Skip test tags in the xcodebuild options",
+			skipTesting:       testSelection{Tags: []string{"slow"}},
+			xcodebuildOptions: []string{"-skip-test-tags", "networking"},
+			wantError:         "`-skip-test-tags` option found in 'Additional options for the xcodebuild command' (xcodebuild_options), please clear 'Skip Testing' (skip_testing) input as only one can be set",
+		},
+		{
+			name:              "Quarantined tags and skip test tags in the xcodebuild options",
+			xcodebuildOptions: []string{"-skip-test-tags", "networking"},
+			wantError:         "`-skip-test-tags` option found in 'Additional options for the xcodebuild command' (xcodebuild_options), please clear the quarantined tags of 'Quarantine file' (quarantine_file) input as only one can be set",
+		},
+		{
+			name:        "Selected and skipped test",
+			onlyTesting: testSelection{Tests: []string{"BullsEyeTests"}},
+			skipTesting: testSelection{Tests: []string{"BullsEyeTests"}},
+			wantError:   "BullsEyeTests is selected in 'Only Testing' (only_testing) and skipped in 'Skip Testing' (skip_testing) at the same time",
+		},
+		{
+			name:        "Selected and skipped tag",
+			onlyTesting: testSelection{Tags: []string{"critical"}},
+			skipTesting: testSelection{Tags: []string{"critical"}},
+			wantError:   "tag critical is selected in 'Only Testing' (only_testing) and skipped in 'Skip Testing' (skip_testing) at the same time",
+		},
+	}
+
+	quarantined := testSelection{Tests: []string{"BullsEyeTests/BullsEyeTests"}, Tags: []string{"flaky"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configParser := XcodeTestConfigParser{logger: log.NewLogger()}
+
+			err := configParser.validateTestSelection(tt.onlyTesting, tt.skipTesting, quarantined, tt.xcodebuildOptions)
+			if tt.wantError != "" {
+				require.EqualError(t, err, tt.wantError)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_GivenTestSelectionInputs_WhenProcessConfig_ThenMergesTheSkippedTestsWithTheQuarantinedTests(t *testing.T) {
+	// Given
+	envValues := defaultEnvValues()
+	envValues["quarantined_tests"] = `[{"testCaseName": "testFlaky()", "testSuiteName": ["BullsEyeTests"], "className": "BullsEyeTests"}]`
+	envValues["only_testing"] = "BullsEyeTests\ntag:critical"
+	envValues["skip_testing"] = "BullsEyeTests/BullsEyeSlowTests/*\ntag:networking"
+	configParser, mocks := createConfigParser(t, envValues)
+	mocks.pathModifier.On("AbsPath", mock.Anything).Return("/_tmp/BullsEye.xcworkspace", nil)
+	mocks.deviceFinder.On("FindDevice", mock.Anything, mock.Anything).Return(defaultSimulator(), nil)
+
+	// When
+	config, err := configParser.ProcessConfig()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{"BullsEyeTests"}, config.OnlyTesting)
+	require.Equal(t, []string{"BullsEyeTests/BullsEyeSlowTests"}, config.SkipTesting)
+	require.Equal(t, []string{"critical"}, config.OnlyTestTags)
+	require.Equal(t, []string{"networking"}, config.SkipTestTags)
+
+	testParams := NewUtils(nil).CreateTestParams(config, "Test-BullsEye.xcresult", "")
+	require.Equal(t, xcodebuild.TestParams{
+		ProjectPath:            config.ProjectPath,
+		Scheme:                 config.Scheme,
+		Destinations:           []string{defaultSimulator().XcodebuildDestination()},
+		TestOutputDir:          "Test-BullsEye.xcresult",
+		TestRepetitionMode:     config.TestRepetitionMode,
+		MaximumTestRepetitions: config.MaximumTestRepetitions,
+		OnlyTesting:            []string{"BullsEyeTests"},
+		SkipTesting:            []string{"BullsEyeTests/BullsEyeSlowTests", "BullsEyeTests/BullsEyeTests/testFlaky()"},
+		OnlyTestTags:           []string{"critical"},
+		SkipTestTags:           []string{"networking"},
+		AdditionalOptions:      config.XcodebuildOptions,
+	}, testParams.TestParams)
+}
+
+func Test_testCaseIdentifier(t *testing.T) {
+	require.Equal(t, "BullsEyeTests/BullsEyeTests/testFailing", testCaseIdentifier("BullsEyeTests", "BullsEyeTests", "testFailing()"))
+	require.Equal(t, "BullsEyeTests/NumberTests/isEven(number:)", testCaseIdentifier("BullsEyeTests", "NumberTests", "isEven(number:) (arguments: 3)"))
+	require.Equal(t, "BullsEyeTests/NumberTests/isZero()", testCaseIdentifier("BullsEyeTests", "NumberTests", "isZero()"))
+}
+
+func Test_testCaseIdentifiers(t *testing.T) {
+	identifiers := testCaseIdentifiers(*testSummaryWithFailedTests("testFailing()"))
+
+	require.Equal(t, []string{"BullsEyeTests/BullsEyeTests/testPassing", "BullsEyeTests/BullsEyeTests/testFailing"}, identifiers)
+	require.True(t, isTestCaseOf(identifiers[1], "BullsEyeTests/BullsEyeTests/testFailing"))
+	require.True(t, isTestCaseOf(identifiers[1], "BullsEyeTests"))
+	require.False(t, isTestCaseOf(identifiers[1], "BullsEye"))
+}
+
+func Test_GivenNoTestResults_WhenParsed_ThenWarnsAboutTheMissingResults(t *testing.T) {
+	// Given
+	step, mocks := createStepAndMocks(t)
+	var logs bytes.Buffer
+	step.logger = log.NewLogger(log.WithOutput(&logs))
+	cfg := Config{OnlyTesting: []string{"BullsEyeTests/BullsEyeTests"}}
+
+	mocks.xcresultProcessor.On("ParseTestResults", "tmp/Test-BullsEye.xcresult", false).Return(nil, nil, nil).Once()
+
+	// When
+	testData, testSummary := step.parseTestResults("tmp/Test-BullsEye.xcresult")
+	step.warnUnmatchedTestSelection(cfg, testSummary)
+
+	// Then
+	require.Nil(t, testData)
+	require.Nil(t, testSummary)
+	require.Contains(t, logs.String(), "No test results found in tmp/Test-BullsEye.xcresult")
+	require.NotContains(t, logs.String(), "%!s(<nil>)")
+}
@@ -2,17 +2,21 @@ package step
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/stringutil"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/simulator"
 	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
 )
 
 type Utils interface {
 	PrintLastLinesOfXcodebuildTestLog(rawXcodebuildOutput string, isRunSuccess bool)
-	CreateConfig(input Input, projectPath string, sim destination.Device, additionalOptions, additionalLogFormatterOptions []string, skipTesting []string) Config
+	PrintLastLinesOfXcodebuildBuildLog(rawXcodebuildOutput string, isRunSuccess bool)
+	CreateConfig(input Input, projectPath string, sims []destination.Device, nonSimulatorDestinations, resolvedDestinations []string, additionalOptions, additionalLogFormatterOptions []string, quarantined, onlyTesting, skipTesting testSelection, retryRules []xcodebuild.RetryRule, simulatorSetup *simulator.Setup) Config
 	CreateTestParams(cfg Config, xcresultPath, swiftPackagesPath string) xcodebuild.TestRunParams
 }
 
@@ -27,6 +31,14 @@ func NewUtils(logger log.Logger) Utils {
 }
 
 func (u utils) PrintLastLinesOfXcodebuildTestLog(rawXcodebuildOutput string, isRunSuccess bool) {
+	u.printLastLinesOfXcodebuildLog(rawXcodebuildOutput, isRunSuccess, "xcodebuild_test.log", "BITRISE_XCODEBUILD_TEST_LOG_PATH")
+}
+
+func (u utils) PrintLastLinesOfXcodebuildBuildLog(rawXcodebuildOutput string, isRunSuccess bool) {
+	u.printLastLinesOfXcodebuildLog(rawXcodebuildOutput, isRunSuccess, "xcodebuild_build.log", "BITRISE_XCODEBUILD_BUILD_LOG_PATH")
+}
+
+func (u utils) printLastLinesOfXcodebuildLog(rawXcodebuildOutput string, isRunSuccess bool, logFileName, logPathEnvKey string) {
 	const lastLines = "\nLast lines of the build log:"
 	if !isRunSuccess {
 		u.logger.Errorf(lastLines)
@@ -37,33 +49,62 @@ func (u utils) PrintLastLinesOfXcodebuildTestLog(rawXcodebuildOutput string, isR
 	fmt.Println(stringutil.LastNLines(rawXcodebuildOutput, 20))
 
 	if !isRunSuccess {
-		u.logger.Warnf("If you can't find the reason of the error in the log, please check the xcodebuild_test.log.")
+		u.logger.Warnf("If you can't find the reason of the error in the log, please check the %s.", logFileName)
 	}
 
-	u.logger.Infof(colorstring.Magenta(`
+	u.logger.Infof(colorstring.Magenta(fmt.Sprintf(`
 The log file is stored in $BITRISE_DEPLOY_DIR, and its full path
-is available in the $BITRISE_XCODEBUILD_TEST_LOG_PATH environment variable.
+is available in the $%s environment variable.
 
 If you have the Deploy to Bitrise.io step (after this step),
-that will attach the file to your build as an artifact!`))
-
+that will attach the file to your build as an artifact!`, logPathEnvKey)))
 }
 
 func (u utils) CreateConfig(input Input,
 	projectPath string,
-	sim destination.Device,
-	additionalOptions, additionalLogFormatterOptions []string, skipTesting []string) Config {
+	sims []destination.Device,
+	nonSimulatorDestinations, resolvedDestinations []string,
+	additionalOptions, additionalLogFormatterOptions []string, quarantined, onlyTesting, skipTesting testSelection, retryRules []xcodebuild.RetryRule, simulatorSetup *simulator.Setup) Config {
+	var sim destination.Device
+	var additionalSims []destination.Device
+	if len(sims) > 0 {
+		sim = sims[0]
+		additionalSims = sims[1:]
+	}
+	if len(additionalSims) == 0 {
+		additionalSims = nil
+	}
+
 	return Config{
-		ProjectPath: projectPath,
-		Scheme:      input.Scheme,
-		TestPlan:    input.TestPlan,
+		ProjectPath:   projectPath,
+		Scheme:        input.Scheme,
+		XctestrunPath: input.XctestrunPath,
+
+		TestPlans:              splitLines(input.TestPlan),
+		OnlyTestConfigurations: splitLines(input.OnlyTestConfiguration),
+		OnlyTesting:            onlyTesting.Tests,
+		SkipTesting:            skipTesting.Tests,
+		OnlyTestTags:           onlyTesting.Tags,
+		SkipTestTags:           skipTesting.Tags,
+
+		Simulator:            sim,
+		IsSimulatorBooted:    len(sims) > 0 && sim.State != simulatorShutdownState,
+		AdditionalSimulators: additionalSims,
 
-		Simulator:         sim,
-		IsSimulatorBooted: sim.State != simulatorShutdownState,
+		NonSimulatorDestinations: nonSimulatorDestinations,
+		ResolvedDestinations:     resolvedDestinations,
 
 		TestRepetitionMode:            input.TestRepetitionMode,
 		MaximumTestRepetitions:        input.MaximumTestRepetitions,
 		RelaunchTestForEachRepetition: input.RelaunchTestsForEachRepetition,
+		RerunTestTags:                 splitLines(input.RerunTestTags),
+
+		TestRunnerRetryRules:     retryRules,
+		MaximumTestRunnerRetries: input.MaximumTestRunnerRetries,
+		TestRunnerRetryBackoff:   time.Duration(input.TestRunnerRetryBackoff) * time.Second,
+
+		ParallelShards:      input.ParallelShards,
+		ShardingHistoryPath: input.ShardingHistoryPath,
 
 		XCConfigContent:    input.XCConfigContent,
 		PerformCleanAction: input.PerformCleanAction,
@@ -74,19 +115,39 @@ func (u utils) CreateConfig(input Input,
 
 		CacheLevel: input.CacheLevel,
 
-		SkipTesting:                 skipTesting,
+		QuarantinedTests:            quarantined.Tests,
+		QuarantinedTestTags:         quarantined.Tags,
+		RunQuarantinedTests:         input.RunQuarantinedTests,
+		QuarantinedTestIterations:   input.QuarantinedTestIterations,
 		CollectSimulatorDiagnostics: exportCondition(input.CollectSimulatorDiagnostics),
+		RecordVideo:                 exportCondition(input.RecordVideo),
+		CollectSimulatorAppLog:      exportCondition(input.CollectSimulatorAppLog),
+		SimulatorAppLogPredicate:    appLogPredicate(input),
+		CollectCrashReports:         input.CollectCrashReports,
 		HeadlessMode:                input.HeadlessMode,
+		SimulatorBootTimeout:        simulatorBootTimeout(input.SimulatorBootTimeout),
+		SimulatorLifecycle:          simulatorLifecycle(input.SimulatorLifecycle),
+		SimulatorSetup:              simulatorSetup,
+
+		ExportXcresultAttempts: input.ExportXcresultAttempts,
+		DeployDir:              input.DeployDir,
 
-		DeployDir: input.DeployDir,
+		DryRun: input.DryRun,
 	}
 }
 
 func (u utils) CreateTestParams(cfg Config, xcresultPath, swiftPackagesPath string) xcodebuild.TestRunParams {
+	var destinations []string
+	for _, sim := range cfg.simulators() {
+		destinations = append(destinations, sim.XcodebuildDestination())
+	}
+	destinations = append(destinations, cfg.NonSimulatorDestinations...)
+
 	testParams := xcodebuild.TestParams{
 		ProjectPath:                    cfg.ProjectPath,
 		Scheme:                         cfg.Scheme,
-		Destination:                    cfg.Simulator.XcodebuildDestination(),
+		XctestrunPath:                  cfg.XctestrunPath,
+		Destinations:                   destinations,
 		TestPlan:                       cfg.TestPlan,
 		TestOutputDir:                  xcresultPath,
 		TestRepetitionMode:             cfg.TestRepetitionMode,
@@ -94,7 +155,11 @@ func (u utils) CreateTestParams(cfg Config, xcresultPath, swiftPackagesPath stri
 		RelaunchTestsForEachRepetition: cfg.RelaunchTestForEachRepetition,
 		XCConfigContent:                cfg.XCConfigContent,
 		PerformCleanAction:             cfg.PerformCleanAction,
-		SkipTesting:                    cfg.SkipTesting,
+		OnlyTesting:                    cfg.OnlyTesting,
+		SkipTesting:                    cfg.skippedTests(),
+		OnlyTestTags:                   cfg.OnlyTestTags,
+		SkipTestTags:                   cfg.skippedTestTags(),
+		OnlyTestConfigurations:         cfg.OnlyTestConfigurations,
 		AdditionalOptions:              cfg.XcodebuildOptions,
 	}
 
@@ -104,5 +169,39 @@ func (u utils) CreateTestParams(cfg Config, xcresultPath, swiftPackagesPath stri
 		RetryOnTestRunnerError:             true,
 		RetryOnSwiftPackageResolutionError: true,
 		SwiftPackagesPath:                  swiftPackagesPath,
+		RetryRules:                         cfg.TestRunnerRetryRules,
+		MaximumTestRunnerRetries:           cfg.MaximumTestRunnerRetries,
+		TestRunnerRetryBackoff:             cfg.TestRunnerRetryBackoff,
 	}
 }
+
+// simulatorBootTimeout converts the Simulator Boot Timeout (simulator_boot_timeout) input to a duration, 0 means the default timeout.
+func simulatorBootTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return defaultSimulatorBootTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// appLogPredicate builds the simulator app log predicate of the app log inputs, the scheme is used as the process name by default.
+func appLogPredicate(input Input) string {
+	processes := splitLines(input.SimulatorAppLogProcesses)
+	subsystems := splitLines(input.SimulatorAppLogSubsystems)
+	if len(processes) == 0 && len(subsystems) == 0 && input.Scheme != "" {
+		processes = []string{input.Scheme}
+	}
+
+	return simulator.LogPredicate(processes, subsystems)
+}
+
+// splitLines returns the non-empty, trimmed lines of a newline separated list input.
+func splitLines(list string) []string {
+	var lines []string
+	for _, line := range strings.Split(list, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
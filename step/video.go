@@ -0,0 +1,69 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/simulator"
+)
+
+type videoRecording struct {
+	path      string
+	recording simulator.VideoRecording
+}
+
+/*
+startVideoRecordings starts recording the screen of the given simulators into outputDir, if the Record video (record_video)
+input is enabled. The simulators are booted if they are not running yet, as only booted simulators can be recorded.
+
+A failing recording does not fail the test run.
+*/
+func (s XcodeTestRunner) startVideoRecordings(cfg Config, sims []destination.Device, outputDir string) []videoRecording {
+	if cfg.RecordVideo == "" || cfg.RecordVideo == never || len(sims) == 0 {
+		return nil
+	}
+
+	var recordings []videoRecording
+	for _, sim := range sims {
+		if err := s.simulatorManager.WaitForBootStatus(sim.UDID, cfg.SimulatorBootTimeout); err != nil {
+			s.logger.Warnf("Failed to boot simulator for the screen recording: %s", err)
+			continue
+		}
+
+		pth := filepath.Join(outputDir, fmt.Sprintf("%s-%s.mp4", cfg.testRunName(), sim.UDID))
+		recording, err := s.simulatorManager.StartVideoRecording(sim.UDID, pth)
+		if err != nil {
+			s.logger.Warnf("Failed to record the simulator screen: %s", err)
+			continue
+		}
+
+		s.logger.Printf("Recording the screen of %s (%s)", sim.Name, sim.UDID)
+		recordings = append(recordings, videoRecording{path: pth, recording: recording})
+	}
+
+	return recordings
+}
+
+// stopVideoRecordings stops the screen recordings and returns the videos to export based on the Record video (record_video) input.
+func (s XcodeTestRunner) stopVideoRecordings(recordings []videoRecording, condition exportCondition, testErr error) []string {
+	var videoPaths []string
+	for _, recording := range recordings {
+		if err := recording.recording.Stop(); err != nil {
+			s.logger.Warnf("%s", err)
+			continue
+		}
+
+		if condition == onFailure && testErr == nil {
+			if err := os.Remove(recording.path); err != nil {
+				s.logger.Debugf("Failed to remove screen recording: %s", err)
+			}
+			continue
+		}
+
+		videoPaths = append(videoPaths, recording.path)
+	}
+
+	return videoPaths
+}
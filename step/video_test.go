@@ -0,0 +1,64 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/destination"
+	"github.com/bitrise-steplib/steps-xcode-test/step/mocks"
+	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GivenRecordVideoOnFailure_WhenTestsFail_ThenKeepsTheRecording(t *testing.T) {
+	// Given
+	step, stepMocks := createStepAndMocks(t)
+	outputDir := t.TempDir()
+	sim := destination.Device{Name: "iPhone 15", UDID: "1234"}
+	videoPath := filepath.Join(outputDir, "Test-Project-1234.mp4")
+
+	recording := mocks.NewVideoRecording(t)
+	recording.On("Stop").Return(nil)
+	stepMocks.simulatorManager.On("WaitForBootStatus", sim.UDID, defaultSimulatorBootTimeout).Return(nil)
+	stepMocks.simulatorManager.On("StartVideoRecording", sim.UDID, videoPath).Return(recording, nil)
+	stepMocks.xcodebuilder.On("TestWithoutBuilding", mock.Anything).Return("", 65, errors.New("exit status 65"))
+
+	cfg := Config{Scheme: "Project", Simulator: sim, RecordVideo: onFailure, LogFormatter: XcodebuildTool, SimulatorBootTimeout: defaultSimulatorBootTimeout}
+	testParams := xcodebuild.TestRunParams{TestParams: xcodebuild.TestParams{TestOutputDir: filepath.Join(outputDir, "Test-Project.xcresult")}}
+
+	// When
+	result, _, err := step.testWithoutBuilding(cfg, testParams, Result{})
+
+	// Then
+	require.Error(t, err)
+	require.Equal(t, []string{videoPath}, result.VideoPaths)
+}
+
+func Test_GivenRecordVideoOnFailure_WhenTestsSucceed_ThenRemovesTheRecording(t *testing.T) {
+	// Given
+	step, stepMocks := createStepAndMocks(t)
+	outputDir := t.TempDir()
+	videoPath := filepath.Join(outputDir, "Test-Project-1234.mp4")
+	require.NoError(t, os.WriteFile(videoPath, []byte("video"), 0600))
+
+	recording := mocks.NewVideoRecording(t)
+	recording.On("Stop").Return(nil)
+	failingRecording := mocks.NewVideoRecording(t)
+	failingRecording.On("Stop").Return(errors.New("screen recording of Simulator (5678) failed"))
+
+	recordings := []videoRecording{
+		{path: videoPath, recording: recording},
+		{path: filepath.Join(outputDir, "Test-Project-5678.mp4"), recording: failingRecording},
+	}
+
+	// When
+	videoPaths := step.stopVideoRecordings(recordings, onFailure, nil)
+
+	// Then
+	require.Empty(t, videoPaths)
+	require.NoFileExists(t, videoPath)
+	stepMocks.simulatorManager.AssertNotCalled(t, "StartVideoRecording", mock.Anything, mock.Anything)
+}
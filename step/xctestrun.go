@@ -0,0 +1,50 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/ziputil"
+)
+
+/*
+prepareXctestrun returns the path of the .xctestrun file to run the tests with.
+
+If the input is a zip archive (for example the test bundle exported by a previous build-for-testing step),
+it is extracted into outputDir and the .xctestrun file is looked up either in the root of the archive
+or in its first level directories.
+*/
+func (s XcodeTestRunner) prepareXctestrun(xctestrunPath, outputDir string) (string, error) {
+	if filepath.Ext(xctestrunPath) != ".zip" {
+		return xctestrunPath, nil
+	}
+
+	extractDir := filepath.Join(outputDir, "xctestrun")
+	s.logger.Infof("Extracting test bundle (%s)", xctestrunPath)
+	if err := ziputil.UnZip(xctestrunPath, extractDir); err != nil {
+		return "", fmt.Errorf("failed to extract test bundle (%s): %w", xctestrunPath, err)
+	}
+
+	return s.findXctestrun(extractDir)
+}
+
+func (s XcodeTestRunner) findXctestrun(dir string) (string, error) {
+	for _, pattern := range []string{"*.xctestrun", "*/*.xctestrun"} {
+		matches, err := s.pathProvider.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return "", fmt.Errorf("failed to search for .xctestrun file: %w", err)
+		}
+
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			s.logger.Printf("Using %s", matches[0])
+			return matches[0], nil
+		default:
+			return "", fmt.Errorf("multiple .xctestrun files found in the test bundle: %v", matches)
+		}
+	}
+
+	return "", fmt.Errorf("no .xctestrun file found in the test bundle")
+}
@@ -0,0 +1,234 @@
+package xcodebuild
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/errorfinder"
+	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
+)
+
+// FailureCategory ...
+type FailureCategory string
+
+// FailureCategory values
+const (
+	FailureCategoryCompileError           FailureCategory = "compile_error"
+	FailureCategoryCodeSigningError       FailureCategory = "code_signing_error"
+	FailureCategoryTestFailures           FailureCategory = "test_failures"
+	FailureCategoryTestRunnerCrash        FailureCategory = "test_runner_crash"
+	FailureCategorySimulatorBootFailure   FailureCategory = "simulator_boot_failure"
+	FailureCategorySwiftPackageResolution FailureCategory = "spm_resolution_failure"
+	FailureCategoryTimeout                FailureCategory = "timeout"
+	FailureCategoryUnknown                FailureCategory = "unknown"
+)
+
+// maxFailureExcerptLines limits the number of log lines attached to a Failure.
+const maxFailureExcerptLines = 10
+
+// Failure is the classified reason of a failed xcodebuild run.
+type Failure struct {
+	Category FailureCategory
+	// Summary is a short, actionable description of the failure.
+	Summary string
+	// Excerpt contains the log lines the classification is based on.
+	Excerpt []string
+}
+
+type failureRule struct {
+	category FailureCategory
+	summary  string
+	patterns []string
+}
+
+// failureRules are evaluated in order, the first rule with a pattern found in the log wins.
+// Build and setup related rules come first, as a failing build or setup also fails the test run.
+var failureRules = []failureRule{
+	{
+		category: FailureCategorySwiftPackageResolution,
+		summary:  "Swift Package dependencies could not be resolved. Check the package URLs, versions and the access to the package repositories; if the Swift Package cache is enabled, it might be outdated.",
+		patterns: []string{
+			cache.SwiftPackagesStateInvalid,
+			`Failed to resolve dependencies`,
+			`xcodebuild: error: Could not resolve package dependencies`,
+		},
+	},
+	{
+		category: FailureCategoryCodeSigningError,
+		summary:  "Code signing failed while building the tests. Check the signing settings of the test targets; tests running on Simulators usually do not need a provisioning profile.",
+		patterns: []string{
+			`Code ?Signing Error`,
+			`requires a provisioning profile`,
+			`requires a development team`,
+			`No signing certificate`,
+			`No profiles for '.*' were found`,
+			`errSecInternalComponent`,
+		},
+	},
+	{
+		category: FailureCategoryCompileError,
+		summary:  "The tests could not be compiled. Fix the compiler errors listed below.",
+		patterns: []string{
+			`\*\* (TEST )?BUILD FAILED \*\*`,
+			`The following build commands failed:`,
+			`Testing cancelled because the build failed`,
+		},
+	},
+	{
+		category: FailureCategorySimulatorBootFailure,
+		summary:  "The Simulator failed to boot. Check that the destination is available on the selected stack; retrying the build usually helps with this kind of issues.",
+		patterns: []string{
+			timeOutMessageIPhoneSimulator,
+			`Unable to boot (the )?(Simulator|device)`,
+			`Failed to boot`,
+			`CoreSimulatorService connection (became invalid|interrupted)`,
+		},
+	},
+	{
+		category: FailureCategoryTimeout,
+		summary:  "A test did not finish in time. Check the tests for deadlocks and long running operations, or increase the execution time allowance of the tests.",
+		patterns: []string{
+			timeOutMessageUITest,
+			`exceeded execution time allowance`,
+			`UI Testing Failure - Failed to receive completion for`,
+		},
+	},
+	{
+		category: FailureCategoryTestRunnerCrash,
+		summary:  "The test runner crashed or failed to bootstrap before the tests could finish. Check the crash logs of the test runner, the Simulator diagnostics (`collect_simulator_diagnostics`) can help with the investigation.",
+		patterns: append([]string{
+			`Restarting after unexpected exit,? crash`,
+			`Test crashed with signal`,
+			`encountered an error \(`,
+		}, testRunnerErrorPatterns...),
+	},
+	{
+		category: FailureCategoryTestFailures,
+		summary:  "Some tests failed. Check the failing assertions listed below and the test report.",
+		patterns: []string{
+			`Test Case '.*' failed`,
+			`: error: -\[.*\] : `,
+		},
+	},
+}
+
+var compilerErrorRegexp = regexp.MustCompile(`\.(swift|m|mm|c|cc|cpp|h|xib|storyboard):\d+(:\d+)?: error: `)
+
+/*
+ClassifyFailure labels a failed xcodebuild run based on its log and the failed test cases
+found in its xcresult bundle (`<TestTarget>/<TestClass>/<TestMethod>` identifiers).
+
+The log lines matching the detected category (or the errors found by errorfinder.FindXcodebuildErrors)
+are returned as the excerpt of the failure.
+*/
+func ClassifyFailure(xcodebuildLog string, failedTests []string) Failure {
+	xcodebuildErrors := errorfinder.FindXcodebuildErrors(xcodebuildLog)
+
+	for _, rule := range failureRules {
+		excerpt := matchingLines(xcodebuildLog, rule.patterns)
+		if len(excerpt) == 0 {
+			continue
+		}
+
+		if rule.category == FailureCategoryCompileError {
+			if compilerErrors := compilerErrorLines(xcodebuildErrors); len(compilerErrors) > 0 {
+				excerpt = compilerErrors
+			}
+		}
+		if rule.category == FailureCategoryTestFailures && len(failedTests) > 0 {
+			excerpt = failedTests
+		}
+
+		return newFailure(rule, excerpt)
+	}
+
+	// Compiler errors without the build summary, for example in a truncated log.
+	if compilerErrors := compilerErrorLines(xcodebuildErrors); len(compilerErrors) > 0 {
+		return newFailure(findFailureRule(FailureCategoryCompileError), compilerErrors)
+	}
+
+	if len(failedTests) > 0 {
+		return newFailure(findFailureRule(FailureCategoryTestFailures), failedTests)
+	}
+
+	return Failure{
+		Category: FailureCategoryUnknown,
+		Summary:  "The reason of the failure could not be detected. Check the xcodebuild log for details.",
+		Excerpt:  limitLines(xcodebuildErrors),
+	}
+}
+
+// NewFailure returns a Failure of the given category with the summary of the category.
+func NewFailure(category FailureCategory, excerpt []string) Failure {
+	return newFailure(findFailureRule(category), excerpt)
+}
+
+func findFailureRule(category FailureCategory) failureRule {
+	for _, rule := range failureRules {
+		if rule.category == category {
+			return rule
+		}
+	}
+
+	return failureRule{category: category}
+}
+
+func newFailure(rule failureRule, excerpt []string) Failure {
+	return Failure{
+		Category: rule.category,
+		Summary:  rule.summary,
+		Excerpt:  limitLines(excerpt),
+	}
+}
+
+func matchingLines(log string, patterns []string) []string {
+	var regexps []*regexp.Regexp
+	for _, pattern := range patterns {
+		regexps = append(regexps, regexp.MustCompile("(?i)"+pattern))
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(log))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, r := range regexps {
+			if r.MatchString(line) {
+				lines = append(lines, strings.TrimSpace(line))
+				break
+			}
+		}
+	}
+
+	return lines
+}
+
+func compilerErrorLines(xcodebuildErrors []string) []string {
+	var lines []string
+	for _, xcodebuildError := range xcodebuildErrors {
+		if compilerErrorRegexp.MatchString(xcodebuildError) {
+			lines = append(lines, xcodebuildError)
+		}
+	}
+
+	return lines
+}
+
+func limitLines(lines []string) []string {
+	var unique []string
+	seen := map[string]bool{}
+	for _, line := range lines {
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		unique = append(unique, line)
+
+		if len(unique) == maxFailureExcerptLines {
+			break
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,136 @@
+package xcodebuild
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyFailure_SampleLogs(t *testing.T) {
+	tests := []struct {
+		name            string
+		samplePath      string
+		failedTests     []string
+		wantCategory    FailureCategory
+		wantExcerptLine string
+	}{
+		{
+			name:            "Compile error",
+			samplePath:      "../_samples/xcodebuild-compile-error.txt",
+			wantCategory:    FailureCategoryCompileError,
+			wantExcerptLine: "/Users/bitrise/develop/sample-apps-ios-with-bitrise-yml/BitriseSampleWithYML/AppDelegate.swift:20:9: error: use of unresolved identifier 'let2'",
+		},
+		{
+			name:         "Simulator boot timeout",
+			samplePath:   "../_samples/xcodebuild-iPhoneSimulator-timeout.txt",
+			wantCategory: FailureCategorySimulatorBootFailure,
+		},
+		{
+			name:         "UI test timeout",
+			samplePath:   "../_samples/xcodebuild-UITest-timeout.txt",
+			wantCategory: FailureCategoryTimeout,
+		},
+		{
+			name:         "Early unexpected exit",
+			samplePath:   "../_samples/xcodebuild-early-unexpected-exit_1.txt",
+			wantCategory: FailureCategoryTestRunnerCrash,
+		},
+		{
+			name:         "Failed to background test runner",
+			samplePath:   "../_samples/xcodebuild-failed-to-background-test-runner.txt",
+			wantCategory: FailureCategoryTestRunnerCrash,
+		},
+		{
+			name:         "Test runner failed to initialize",
+			samplePath:   "../_samples/xcodebuild-test-runner-failed-to-initialize-for-ui-testing.txt",
+			wantCategory: FailureCategoryTestRunnerCrash,
+		},
+		{
+			name:         "Failure attempting to launch",
+			samplePath:   "../_samples/xcodebuild-failure-attempting-tolaunch.txt",
+			wantCategory: FailureCategoryTestRunnerCrash,
+		},
+		{
+			name:            "Failed tests without log pattern",
+			samplePath:      "../_samples/xcodebuild-ok.txt",
+			failedTests:     []string{"BullsEyeTests/BullsEyeTests/testFailing()"},
+			wantCategory:    FailureCategoryTestFailures,
+			wantExcerptLine: "BullsEyeTests/BullsEyeTests/testFailing()",
+		},
+		{
+			name:         "No failure reason",
+			samplePath:   "../_samples/xcodebuild-ok.txt",
+			wantCategory: FailureCategoryUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, err := loadFileContent(tt.samplePath)
+			require.NoError(t, err)
+
+			failure := ClassifyFailure(log, tt.failedTests)
+
+			require.Equal(t, tt.wantCategory, failure.Category)
+			require.NotEmpty(t, failure.Summary)
+			require.LessOrEqual(t, len(failure.Excerpt), maxFailureExcerptLines)
+			if tt.wantExcerptLine != "" {
+				require.Contains(t, failure.Excerpt, tt.wantExcerptLine)
+			}
+		})
+	}
+}
+
+func TestClassifyFailure_BuildLogs(t *testing.T) {
+	tests := []struct {
+		name         string
+		log          string
+		wantCategory FailureCategory
+		wantExcerpt  []string
+	}{
+		{
+			name: "Swift Package resolution failure",
+			log: `Resolve Package Graph
+xcodebuild: error: Could not resolve package dependencies:
+  Failed to clone repository https://github.com/example/package.git`,
+			wantCategory: FailureCategorySwiftPackageResolution,
+			wantExcerpt:  []string{"xcodebuild: error: Could not resolve package dependencies:"},
+		},
+		{
+			name: "Code signing error",
+			log: `/Users/vagrant/git/BullsEye.xcodeproj: error: Signing for "BullsEyeUITests" requires a development team. Select a development team in the Signing & Capabilities editor. (in target 'BullsEyeUITests' from project 'BullsEye')
+error: No signing certificate "iOS Development" found: No "iOS Development" signing certificate matching team ID "72SA8V3WYL" with a private key was found. (in target 'BullsEye' from project 'BullsEye')
+** TEST BUILD FAILED **`,
+			wantCategory: FailureCategoryCodeSigningError,
+			wantExcerpt: []string{
+				`/Users/vagrant/git/BullsEye.xcodeproj: error: Signing for "BullsEyeUITests" requires a development team. Select a development team in the Signing & Capabilities editor. (in target 'BullsEyeUITests' from project 'BullsEye')`,
+				`error: No signing certificate "iOS Development" found: No "iOS Development" signing certificate matching team ID "72SA8V3WYL" with a private key was found. (in target 'BullsEye' from project 'BullsEye')`,
+			},
+		},
+		{
+			name: "Test assertion failures",
+			log: `Test Case '-[BullsEyeTests.BullsEyeTests testScoreIsComputed]' started.
+/Users/vagrant/git/BullsEyeTests/BullsEyeTests.swift:42: error: -[BullsEyeTests.BullsEyeTests testScoreIsComputed] : XCTAssertEqual failed: ("95") is not equal to ("96")
+Test Case '-[BullsEyeTests.BullsEyeTests testScoreIsComputed]' failed (0.004 seconds).
+** TEST FAILED **`,
+			wantCategory: FailureCategoryTestFailures,
+			wantExcerpt: []string{
+				`/Users/vagrant/git/BullsEyeTests/BullsEyeTests.swift:42: error: -[BullsEyeTests.BullsEyeTests testScoreIsComputed] : XCTAssertEqual failed: ("95") is not equal to ("96")`,
+				`Test Case '-[BullsEyeTests.BullsEyeTests testScoreIsComputed]' failed (0.004 seconds).`,
+			},
+		},
+		{
+			name:         "Test execution time allowance",
+			log:          `Test Case '-[BullsEyeUITests.BullsEyeUITests testGameStarts]' exceeded execution time allowance of 60 seconds.`,
+			wantCategory: FailureCategoryTimeout,
+			wantExcerpt:  []string{`Test Case '-[BullsEyeUITests.BullsEyeUITests testGameStarts]' exceeded execution time allowance of 60 seconds.`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := ClassifyFailure(tt.log, nil)
+
+			require.Equal(t, tt.wantCategory, failure.Category)
+			require.Equal(t, tt.wantExcerpt, failure.Excerpt)
+		})
+	}
+}
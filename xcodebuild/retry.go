@@ -0,0 +1,109 @@
+package xcodebuild
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetryAction is the action taken when a RetryRule pattern is found in the log of a failed test run.
+type RetryAction string
+
+// RetryAction values
+const (
+	RetryActionRetry            RetryAction = "retry"
+	RetryActionRetryAfterErase  RetryAction = "retry_after_erase"
+	RetryActionRetryAfterReboot RetryAction = "retry_after_reboot"
+	RetryActionFailFast         RetryAction = "fail_fast"
+)
+
+const defaultMaximumTestRunnerRetries = 1
+
+// RetryRule maps a regex pattern of the xcodebuild log to a RetryAction.
+type RetryRule struct {
+	Pattern string      `yaml:"pattern"`
+	Action  RetryAction `yaml:"action"`
+}
+
+// defaultRetryRules are the built-in test runner error patterns, they are evaluated after the user defined rules.
+func defaultRetryRules() []RetryRule {
+	var rules []RetryRule
+	for _, pattern := range testRunnerErrorPatterns {
+		rules = append(rules, RetryRule{Pattern: pattern, Action: RetryActionRetry})
+	}
+	return rules
+}
+
+/*
+ParseRetryRules parses a YAML (or JSON) list of retry rules, for example:
+
+  - pattern: "Test runner never began executing tests"
+    action: retry_after_reboot
+  - pattern: "Lost connection to the test manager service"
+    action: fail_fast
+
+The action defaults to `retry` if not set.
+*/
+func ParseRetryRules(content []byte) ([]RetryRule, error) {
+	var rules []RetryRule
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse retry rules: %w", err)
+	}
+
+	for i, rule := range rules {
+		if rule.Action == "" {
+			rules[i].Action = RetryActionRetry
+		}
+		if err := rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid retry rule (%d): %w", i+1, err)
+		}
+	}
+
+	return rules, nil
+}
+
+// ParseRetryPatterns converts newline separated regex patterns to retry rules with the `retry` action.
+func ParseRetryPatterns(patterns string) ([]RetryRule, error) {
+	var rules []RetryRule
+	for _, pattern := range strings.Split(patterns, "\n") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		rule := RetryRule{Pattern: pattern, Action: RetryActionRetry}
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (r RetryRule) validate() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern is empty")
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern (%s): %w", r.Pattern, err)
+	}
+
+	switch r.Action {
+	case RetryActionRetry, RetryActionRetryAfterErase, RetryActionRetryAfterReboot, RetryActionFailFast:
+		return nil
+	default:
+		return fmt.Errorf("invalid action (%s), should be one of: %s, %s, %s, %s", r.Action, RetryActionRetry, RetryActionRetryAfterErase, RetryActionRetryAfterReboot, RetryActionFailFast)
+	}
+}
+
+// retryBackoff returns the wait time before the given (1-based) retry, the backoff doubles with every retry.
+func retryBackoff(backoff time.Duration, retry int) time.Duration {
+	if backoff <= 0 || retry < 1 {
+		return 0
+	}
+	return backoff << (retry - 1)
+}
@@ -0,0 +1,72 @@
+package xcodebuild
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []RetryRule
+		wantErr string
+	}{
+		{
+			name: "YAML rules",
+			content: `- pattern: "Test runner never began executing tests"
+  action: retry_after_reboot
+- pattern: "Lost connection to the test manager service"`,
+			want: []RetryRule{
+				{Pattern: "Test runner never began executing tests", Action: RetryActionRetryAfterReboot},
+				{Pattern: "Lost connection to the test manager service", Action: RetryActionRetry},
+			},
+		},
+		{
+			name:    "JSON rules",
+			content: `[{"pattern": "Failed to install the app", "action": "fail_fast"}]`,
+			want:    []RetryRule{{Pattern: "Failed to install the app", Action: RetryActionFailFast}},
+		},
+		{
+			name:    "Invalid action",
+			content: `[{"pattern": "Failed to install the app", "action": "ignore"}]`,
+			wantErr: "invalid retry rule (1): invalid action (ignore), should be one of: retry, retry_after_erase, retry_after_reboot, fail_fast",
+		},
+		{
+			name:    "Invalid pattern",
+			content: `[{"pattern": "Failed to (install"}]`,
+			wantErr: "invalid retry rule (1): invalid pattern (Failed to (install): error parsing regexp: missing closing ): `Failed to (install`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetryRules([]byte(tt.content))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRetryPatterns(t *testing.T) {
+	rules, err := ParseRetryPatterns("Lost connection to test manager\n\n  Test runner exited .* before starting test execution  \n")
+
+	require.NoError(t, err)
+	require.Equal(t, []RetryRule{
+		{Pattern: "Lost connection to test manager", Action: RetryActionRetry},
+		{Pattern: "Test runner exited .* before starting test execution", Action: RetryActionRetry},
+	}, rules)
+}
+
+func Test_retryBackoff(t *testing.T) {
+	require.Equal(t, time.Duration(0), retryBackoff(0, 1))
+	require.Equal(t, 10*time.Second, retryBackoff(10*time.Second, 1))
+	require.Equal(t, 20*time.Second, retryBackoff(10*time.Second, 2))
+	require.Equal(t, 40*time.Second, retryBackoff(10*time.Second, 3))
+}
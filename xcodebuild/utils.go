@@ -1,9 +1,14 @@
 package xcodebuild
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
 )
@@ -44,7 +49,8 @@ var testRunnerErrorPatterns = []string{
 type TestParams struct {
 	ProjectPath                    string
 	Scheme                         string
-	Destination                    string
+	XctestrunPath                  string
+	Destinations                   []string
 	TestPlan                       string
 	TestOutputDir                  string
 	TestRepetitionMode             string
@@ -52,11 +58,18 @@ type TestParams struct {
 	RelaunchTestsForEachRepetition bool
 	XCConfigContent                string
 	PerformCleanAction             bool
+	TestWithoutBuilding            bool
+	OnlyTesting                    []string
 	SkipTesting                    []string
-	AdditionalOptions              []string
+	// OnlyTestTags and SkipTestTags select the Swift Testing tests by their tags.
+	OnlyTestTags []string
+	SkipTestTags []string
+	// OnlyTestConfigurations limit the test run to the given configurations of the test plan.
+	OnlyTestConfigurations []string
+	AdditionalOptions      []string
 }
 
-func (b *xcodebuild) createXcodebuildTestArgs(params TestParams) ([]string, error) {
+func createProjectArgs(params TestParams) []string {
 	var xcodebuildArgs []string
 
 	fileExtension := filepath.Ext(params.ProjectPath)
@@ -67,14 +80,122 @@ func (b *xcodebuild) createXcodebuildTestArgs(params TestParams) ([]string, erro
 	}
 	xcodebuildArgs = append(xcodebuildArgs, "-scheme", params.Scheme)
 
+	return xcodebuildArgs
+}
+
+// createDestinationArgs returns a `-destination` option for every destination, xcodebuild runs the tests on them concurrently.
+func createDestinationArgs(destinations []string) []string {
+	var xcodebuildArgs []string
+	for _, destination := range destinations {
+		xcodebuildArgs = append(xcodebuildArgs, "-destination", destination)
+	}
+	return xcodebuildArgs
+}
+
+func createTestTagArgs(params TestParams) []string {
+	var xcodebuildArgs []string
+	for _, tag := range params.OnlyTestTags {
+		xcodebuildArgs = append(xcodebuildArgs, "-only-test-tags", tag)
+	}
+	for _, tag := range params.SkipTestTags {
+		xcodebuildArgs = append(xcodebuildArgs, "-skip-test-tags", tag)
+	}
+	return xcodebuildArgs
+}
+
+func createTestConfigurationArgs(configurations []string) []string {
+	var xcodebuildArgs []string
+	for _, configuration := range configurations {
+		xcodebuildArgs = append(xcodebuildArgs, "-only-test-configuration", configuration)
+	}
+	return xcodebuildArgs
+}
+
+func (b *xcodebuild) createXcodebuildBuildForTestingArgs(params TestParams) ([]string, error) {
+	xcodebuildArgs := createProjectArgs(params)
+
 	if params.PerformCleanAction {
 		xcodebuildArgs = append(xcodebuildArgs, "clean")
 	}
 
-	xcodebuildArgs = append(xcodebuildArgs, "test", "-destination", params.Destination)
+	xcodebuildArgs = append(xcodebuildArgs, "build-for-testing")
+	xcodebuildArgs = append(xcodebuildArgs, createDestinationArgs(params.Destinations)...)
 	if params.TestPlan != "" {
 		xcodebuildArgs = append(xcodebuildArgs, "-testPlan", params.TestPlan)
 	}
+
+	if params.XCConfigContent != "" {
+		xcconfigPath, err := b.xcconfigWriter.Write(params.XCConfigContent)
+		if err != nil {
+			return nil, err
+		}
+		xcodebuildArgs = append(xcodebuildArgs, "-xcconfig", xcconfigPath)
+	}
+
+	xcodebuildArgs = append(xcodebuildArgs, params.AdditionalOptions...)
+
+	return xcodebuildArgs, nil
+}
+
+func createTestActionArgs(params TestParams) []string {
+	if params.XctestrunPath != "" {
+		// The .xctestrun file describes the test bundles, so the project, scheme and test plan are not needed.
+		return append([]string{"test-without-building", "-xctestrun", params.XctestrunPath}, createDestinationArgs(params.Destinations)...)
+	}
+
+	xcodebuildArgs := createProjectArgs(params)
+
+	action := "test"
+	if params.TestWithoutBuilding {
+		action = "test-without-building"
+	} else if params.PerformCleanAction {
+		xcodebuildArgs = append(xcodebuildArgs, "clean")
+	}
+
+	xcodebuildArgs = append(xcodebuildArgs, action)
+	xcodebuildArgs = append(xcodebuildArgs, createDestinationArgs(params.Destinations)...)
+	if params.TestPlan != "" {
+		xcodebuildArgs = append(xcodebuildArgs, "-testPlan", params.TestPlan)
+	}
+
+	return xcodebuildArgs
+}
+
+func (b *xcodebuild) createXcodebuildEnumerateTestsArgs(params TestParams, outputPath string) ([]string, error) {
+	params.TestWithoutBuilding = true
+	xcodebuildArgs := createTestActionArgs(params)
+	xcodebuildArgs = append(xcodebuildArgs,
+		"-enumerate-tests",
+		"-test-enumeration-style", "flat",
+		"-test-enumeration-format", "json",
+		"-test-enumeration-output-path", outputPath,
+	)
+
+	if params.XCConfigContent != "" {
+		xcconfigPath, err := b.xcconfigWriter.Write(params.XCConfigContent)
+		if err != nil {
+			return nil, err
+		}
+		xcodebuildArgs = append(xcodebuildArgs, "-xcconfig", xcconfigPath)
+	}
+
+	for _, test := range params.OnlyTesting {
+		xcodebuildArgs = append(xcodebuildArgs, fmt.Sprintf("-only-testing:%s", test))
+	}
+
+	for _, test := range params.SkipTesting {
+		xcodebuildArgs = append(xcodebuildArgs, fmt.Sprintf("-skip-testing:%s", test))
+	}
+
+	xcodebuildArgs = append(xcodebuildArgs, createTestTagArgs(params)...)
+	xcodebuildArgs = append(xcodebuildArgs, createTestConfigurationArgs(params.OnlyTestConfigurations)...)
+	xcodebuildArgs = append(xcodebuildArgs, params.AdditionalOptions...)
+
+	return xcodebuildArgs, nil
+}
+
+func (b *xcodebuild) createXcodebuildTestArgs(params TestParams) ([]string, error) {
+	xcodebuildArgs := createTestActionArgs(params)
 	xcodebuildArgs = append(xcodebuildArgs, "-resultBundlePath", params.TestOutputDir)
 
 	switch params.TestRepetitionMode {
@@ -84,7 +205,7 @@ func (b *xcodebuild) createXcodebuildTestArgs(params TestParams) ([]string, erro
 		xcodebuildArgs = append(xcodebuildArgs, "-retry-tests-on-failure")
 	}
 
-	if params.TestRepetitionMode != TestRepetitionNone {
+	if params.TestRepetitionMode != TestRepetitionNone && params.TestRepetitionMode != TestRepetitionRerunFailedTests {
 		xcodebuildArgs = append(xcodebuildArgs, "-test-iterations", strconv.Itoa(params.MaximumTestRepetitions))
 	}
 
@@ -100,10 +221,16 @@ func (b *xcodebuild) createXcodebuildTestArgs(params TestParams) ([]string, erro
 		xcodebuildArgs = append(xcodebuildArgs, "-xcconfig", xcconfigPath)
 	}
 
+	for _, test := range params.OnlyTesting {
+		xcodebuildArgs = append(xcodebuildArgs, fmt.Sprintf("-only-testing:%s", test))
+	}
+
 	for _, test := range params.SkipTesting {
 		xcodebuildArgs = append(xcodebuildArgs, fmt.Sprintf("-skip-testing:%s", test))
 	}
 
+	xcodebuildArgs = append(xcodebuildArgs, createTestTagArgs(params)...)
+	xcodebuildArgs = append(xcodebuildArgs, createTestConfigurationArgs(params.OnlyTestConfigurations)...)
 	xcodebuildArgs = append(xcodebuildArgs, params.AdditionalOptions...)
 
 	return xcodebuildArgs, nil
@@ -124,7 +251,7 @@ func (b *xcodebuild) runTest(params TestRunParams) (string, int, error) {
 	output, testErr := b.xcodeCommandRunner.Run(workDir, xcodebuildArgs, params.LogFormatterOptions)
 
 	if output.ExitCode != 0 {
-		fmt.Println("Exit code: ", output.ExitCode)
+		b.logger.Printf("Exit code: %d", output.ExitCode)
 	}
 
 	if testErr != nil {
@@ -134,6 +261,91 @@ func (b *xcodebuild) runTest(params TestRunParams) (string, int, error) {
 	return string(output.RawOut), output.ExitCode, nil
 }
 
+func (b *xcodebuild) buildForTesting(params TestRunParams) (string, int, error) {
+	xcodebuildArgs, err := b.createXcodebuildBuildForTestingArgs(params.TestParams)
+	if err != nil {
+		return "", 1, err
+	}
+
+	b.logger.Donef("Building the tests...")
+
+	workDir := filepath.Dir(params.TestParams.ProjectPath)
+	output, buildErr := b.xcodeCommandRunner.Run(workDir, xcodebuildArgs, params.LogFormatterOptions)
+
+	if output.ExitCode != 0 {
+		b.logger.Printf("Exit code: %d", output.ExitCode)
+	}
+
+	if buildErr != nil {
+		return b.handleBuildForTestingError(params, testRunResult{xcodebuildLog: string(output.RawOut), exitCode: output.ExitCode, err: buildErr})
+	}
+
+	return string(output.RawOut), output.ExitCode, nil
+}
+
+// handleBuildForTestingError only retries the build if the Swift packages cache is invalid,
+// compile errors never trigger the test runner error retry.
+func (b *xcodebuild) handleBuildForTestingError(prevRunParams TestRunParams, prevRunResult testRunResult) (string, int, error) {
+	if prevRunParams.RetryOnSwiftPackageResolutionError && prevRunParams.SwiftPackagesPath != "" && isStringFoundInOutput(cache.SwiftPackagesStateInvalid, prevRunResult.xcodebuildLog) {
+		b.logger.Warnf("swift packages cache is in an invalid state")
+		if err := b.fileManager.RemoveAll(prevRunParams.SwiftPackagesPath); err != nil {
+			b.logger.Errorf("failed to remove Swift package caches: %s", err)
+			return prevRunResult.xcodebuildLog, prevRunResult.exitCode, prevRunResult.err
+		}
+
+		prevRunParams.RetryOnSwiftPackageResolutionError = false
+		return b.buildForTesting(prevRunParams)
+	}
+
+	return prevRunResult.xcodebuildLog, prevRunResult.exitCode, prevRunResult.err
+}
+
+type testEnumeration struct {
+	Values []struct {
+		EnabledTests []struct {
+			Identifier string `json:"identifier"`
+		} `json:"enabledTests"`
+	} `json:"values"`
+}
+
+func (b *xcodebuild) enumerateTests(params TestRunParams, outputPath string) ([]string, error) {
+	xcodebuildArgs, err := b.createXcodebuildEnumerateTestsArgs(params.TestParams, outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logger.Donef("Enumerating the tests...")
+
+	workDir := filepath.Dir(params.TestParams.ProjectPath)
+	output, err := b.xcodeCommandRunner.Run(workDir, xcodebuildArgs, params.LogFormatterOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate tests (exit code %d): %w", output.ExitCode, err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test enumeration: %w", err)
+	}
+
+	return parseTestEnumeration(content)
+}
+
+func parseTestEnumeration(content []byte) ([]string, error) {
+	var enumeration testEnumeration
+	if err := json.Unmarshal(content, &enumeration); err != nil {
+		return nil, fmt.Errorf("failed to parse test enumeration: %w", err)
+	}
+
+	var identifiers []string
+	for _, value := range enumeration.Values {
+		for _, test := range value.EnabledTests {
+			identifiers = append(identifiers, test.Identifier)
+		}
+	}
+
+	return identifiers, nil
+}
+
 type testRunResult struct {
 	xcodebuildLog string
 	exitCode      int
@@ -141,13 +353,40 @@ type testRunResult struct {
 }
 
 func (b *xcodebuild) cleanOutputDirAndRerunTest(params TestRunParams) (string, int, error) {
-	// Clean output directory, otherwise after retry test run, xcodebuild fails with `error: Existing file at -resultBundlePath "..."`
-	if err := b.fileManager.RemoveAll(params.TestParams.TestOutputDir); err != nil {
-		return "", 1, fmt.Errorf("failed to clean test output directory: %s: %w", params.TestParams.TestOutputDir, err)
+	// Move away the output directory, otherwise after retry test run, xcodebuild fails with `error: Existing file at -resultBundlePath "..."`
+	// The result bundle of the previous attempt is kept, so that it can be merged with the result of the retry.
+	testOutputDir := params.TestParams.TestOutputDir
+	if _, err := os.Stat(testOutputDir); err == nil {
+		attemptPath := attemptResultBundlePath(testOutputDir, len(PreviousAttemptResultBundles(testOutputDir))+1)
+		if err := os.Rename(testOutputDir, attemptPath); err != nil {
+			b.logger.Warnf("Failed to keep the result bundle of the previous attempt: %s", err)
+
+			if err := b.fileManager.RemoveAll(testOutputDir); err != nil {
+				return "", 1, fmt.Errorf("failed to clean test output directory: %s: %w", testOutputDir, err)
+			}
+		}
 	}
 	return b.runTest(params)
 }
 
+// PreviousAttemptResultBundles returns the result bundles of the automatically retried test runs
+// of the given result bundle (<name>-attempt-<n>.xcresult) in the order of the attempts.
+func PreviousAttemptResultBundles(testOutputDir string) []string {
+	var paths []string
+	for attempt := 1; ; attempt++ {
+		path := attemptResultBundlePath(testOutputDir, attempt)
+		if _, err := os.Stat(path); err != nil {
+			return paths
+		}
+		paths = append(paths, path)
+	}
+}
+
+func attemptResultBundlePath(testOutputDir string, attempt int) string {
+	ext := filepath.Ext(testOutputDir)
+	return fmt.Sprintf("%s-attempt-%d%s", strings.TrimSuffix(testOutputDir, ext), attempt, ext)
+}
+
 func (b *xcodebuild) handleTestRunError(prevRunParams TestRunParams, prevRunResult testRunResult) (string, int, error) {
 	if prevRunParams.RetryOnSwiftPackageResolutionError && prevRunParams.SwiftPackagesPath != "" && isStringFoundInOutput(cache.SwiftPackagesStateInvalid, prevRunResult.xcodebuildLog) {
 		b.logger.Warnf("xcode-test", "swift-packages-cache-invalid", nil, "swift packages cache is in an invalid state")
@@ -160,19 +399,47 @@ func (b *xcodebuild) handleTestRunError(prevRunParams TestRunParams, prevRunResu
 		return b.cleanOutputDirAndRerunTest(prevRunParams)
 	}
 
-	for _, errorPattern := range testRunnerErrorPatterns {
-		if isStringFoundInOutput(errorPattern, prevRunResult.xcodebuildLog) {
-			b.logger.Warnf("Automatic retry reason found in log: %s", errorPattern)
-			if prevRunParams.RetryOnTestRunnerError {
-				b.logger.Printf("Automatic retry is enabled - retrying...")
-
-				prevRunParams.RetryOnTestRunnerError = false
-				return b.cleanOutputDirAndRerunTest(prevRunParams)
-			}
+	for _, rule := range slices.Concat(prevRunParams.RetryRules, defaultRetryRules()) {
+		if !isStringFoundInOutput(rule.Pattern, prevRunResult.xcodebuildLog) {
+			continue
+		}
 
+		b.logger.Warnf("Automatic retry reason found in log: %s", rule.Pattern)
+		if rule.Action == RetryActionFailFast {
+			b.logger.Errorf("Retry rule action is %s, stopping the test!", rule.Action)
+			return prevRunResult.xcodebuildLog, prevRunResult.exitCode, prevRunResult.err
+		}
+		if !prevRunParams.RetryOnTestRunnerError {
 			b.logger.Errorf("Automatic retry is disabled, no more retry, stopping the test!")
 			return prevRunResult.xcodebuildLog, prevRunResult.exitCode, prevRunResult.err
 		}
+
+		maximumRetries := prevRunParams.MaximumTestRunnerRetries
+		if maximumRetries < 1 {
+			maximumRetries = defaultMaximumTestRunnerRetries
+		}
+		if prevRunParams.testRunnerRetries >= maximumRetries {
+			b.logger.Errorf("Reached the maximum number of automatic retries (%d), stopping the test!", maximumRetries)
+			return prevRunResult.xcodebuildLog, prevRunResult.exitCode, prevRunResult.err
+		}
+
+		prevRunParams.testRunnerRetries++
+		b.logger.Printf("Automatic retry is enabled - retrying (%d/%d)...", prevRunParams.testRunnerRetries, maximumRetries)
+
+		if rule.Action == RetryActionRetryAfterErase || rule.Action == RetryActionRetryAfterReboot {
+			if prevRunParams.BeforeTestRunnerRetry == nil {
+				b.logger.Warnf("Retry rule action %s is not supported for the destination, retrying without it", rule.Action)
+			} else if err := prevRunParams.BeforeTestRunnerRetry(rule.Action); err != nil {
+				b.logger.Warnf("Failed to prepare the retry (%s): %s", rule.Action, err)
+			}
+		}
+
+		if wait := retryBackoff(prevRunParams.TestRunnerRetryBackoff, prevRunParams.testRunnerRetries); wait > 0 {
+			b.logger.Printf("Waiting %s before retrying...", wait)
+			time.Sleep(wait)
+		}
+
+		return b.cleanOutputDirAndRerunTest(prevRunParams)
 	}
 
 	return prevRunResult.xcodebuildLog, prevRunResult.exitCode, prevRunResult.err
@@ -1,6 +1,8 @@
 package xcodebuild
 
 import (
+	"time"
+
 	"github.com/bitrise-io/go-utils/v2/fileutil"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/v2/xcconfig"
@@ -12,13 +14,20 @@ const (
 	TestRepetitionNone           = "none"
 	TestRepetitionUntilFailure   = "until_failure"
 	TestRepetitionRetryOnFailure = "retry_on_failure"
+	// TestRepetitionRerunFailedTests is handled by the step: only the failed tests are rerun with `-only-testing`.
+	TestRepetitionRerunFailedTests = "rerun_failed_tests"
 )
 
 // Xcodebuild ....
 type Xcodebuild interface {
-	RunTest(params TestRunParams) (string, int, error)
+	BuildForTesting(params TestRunParams) (string, int, error)
+	TestWithoutBuilding(params TestRunParams) (string, int, error)
+	EnumerateTests(params TestRunParams, outputPath string) ([]string, error)
+	BuildForTestingArgs(params TestRunParams) ([]string, error)
+	TestWithoutBuildingArgs(params TestRunParams) ([]string, error)
 	GetXcodeCommadRunner() xcodecommand.Runner
 	SetXcodeCommandRunner(runner xcodecommand.Runner)
+	WithCommandRunner(logger log.Logger, runner xcodecommand.Runner) Xcodebuild
 }
 
 type xcodebuild struct {
@@ -45,13 +54,49 @@ type TestRunParams struct {
 	RetryOnTestRunnerError             bool
 	RetryOnSwiftPackageResolutionError bool
 	SwiftPackagesPath                  string
+
+	// RetryRules are evaluated before the built-in test runner error patterns.
+	RetryRules []RetryRule
+	// MaximumTestRunnerRetries is the number of automatic retries on test runner errors, defaults to 1.
+	MaximumTestRunnerRetries int
+	// TestRunnerRetryBackoff is the wait time before the first retry, it doubles with every further retry.
+	TestRunnerRetryBackoff time.Duration
+	// BeforeTestRunnerRetry prepares the destination for the retry_after_erase and retry_after_reboot actions.
+	BeforeTestRunnerRetry func(action RetryAction) error
+
+	testRunnerRetries int
+}
+
+// BuildForTesting builds the test bundles without running them (`xcodebuild build-for-testing`).
+func (b *xcodebuild) BuildForTesting(params TestRunParams) (string, int, error) {
+	return b.buildForTesting(params)
 }
 
-// RunTest ...
-func (b *xcodebuild) RunTest(params TestRunParams) (string, int, error) {
+// TestWithoutBuilding runs the tests built by a previous BuildForTesting call (`xcodebuild test-without-building`).
+func (b *xcodebuild) TestWithoutBuilding(params TestRunParams) (string, int, error) {
+	params.TestParams.TestWithoutBuilding = true
+	// Swift packages are resolved in the build phase.
+	params.RetryOnSwiftPackageResolutionError = false
 	return b.runTest(params)
 }
 
+// EnumerateTests lists the identifiers (<TestTarget>/<TestClass>/<TestMethod>) of the tests built by a previous BuildForTesting call.
+// The enumeration is written to outputPath.
+func (b *xcodebuild) EnumerateTests(params TestRunParams, outputPath string) ([]string, error) {
+	return b.enumerateTests(params, outputPath)
+}
+
+// BuildForTestingArgs returns the arguments of the `xcodebuild build-for-testing` command run by BuildForTesting.
+func (b *xcodebuild) BuildForTestingArgs(params TestRunParams) ([]string, error) {
+	return b.createXcodebuildBuildForTestingArgs(params.TestParams)
+}
+
+// TestWithoutBuildingArgs returns the arguments of the `xcodebuild test-without-building` command run by TestWithoutBuilding.
+func (b *xcodebuild) TestWithoutBuildingArgs(params TestRunParams) ([]string, error) {
+	params.TestParams.TestWithoutBuilding = true
+	return b.createXcodebuildTestArgs(params.TestParams)
+}
+
 func (b *xcodebuild) GetXcodeCommadRunner() xcodecommand.Runner {
 	return b.xcodeCommandRunner
 }
@@ -59,3 +104,14 @@ func (b *xcodebuild) GetXcodeCommadRunner() xcodecommand.Runner {
 func (b *xcodebuild) SetXcodeCommandRunner(runner xcodecommand.Runner) {
 	b.xcodeCommandRunner = runner
 }
+
+// WithCommandRunner returns a copy logging with the given logger and running xcodebuild with the given runner,
+// so that concurrent test runs don't write into the same log.
+func (b *xcodebuild) WithCommandRunner(logger log.Logger, runner xcodecommand.Runner) Xcodebuild {
+	return &xcodebuild{
+		logger:             logger,
+		fileManager:        b.fileManager,
+		xcconfigWriter:     b.xcconfigWriter,
+		xcodeCommandRunner: runner,
+	}
+}
@@ -3,6 +3,8 @@ package xcodebuild
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -12,6 +14,7 @@ import (
 	commonMocks "github.com/bitrise-steplib/steps-xcode-test/mocks"
 	"github.com/bitrise-steplib/steps-xcode-test/xcodebuild/mocks"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 const xcconfigPath = "xcconfigPath"
@@ -71,12 +74,74 @@ func Test_GivenXcodebuild_WhenInvoked_ThenUsesCorrectArguments(t *testing.T) {
 				return parameters
 			},
 		},
+		{
+			name: "Rerun failed tests mode does not set xcodebuild repetition options",
+			input: func() TestRunParams {
+				parameters := runParameters()
+				parameters.TestParams.TestRepetitionMode = "rerun_failed_tests"
+				parameters.TestParams.RelaunchTestsForEachRepetition = false
+
+				return parameters
+			},
+		},
+		{
+			name: "Test without building only the selected tests",
+			input: func() TestRunParams {
+				parameters := runParameters()
+				parameters.TestParams.PerformCleanAction = true
+				parameters.TestParams.TestWithoutBuilding = true
+				parameters.TestParams.OnlyTesting = []string{"TestTarget1/TestClass1/testMethod1()", "TestTarget1/TestClass2/testMethod1()"}
+
+				return parameters
+			},
+		},
+		{
+			name: "Test with xctestrun file",
+			input: func() TestRunParams {
+				parameters := runParameters()
+				parameters.TestParams.XctestrunPath = "BullsEye_iphonesimulator17.5-arm64.xctestrun"
+				parameters.TestParams.TestPlan = ""
+				parameters.TestParams.PerformCleanAction = true
+				parameters.TestParams.SkipTesting = []string{"TestTarget1/TestClass1/testMethod1()"}
+
+				return parameters
+			},
+		},
+		{
+			name: "Multiple destinations",
+			input: func() TestRunParams {
+				parameters := runParameters()
+				parameters.TestParams.Destinations = []string{"id=E8C36A8B", "id=3F1C2D4E"}
+
+				return parameters
+			},
+		},
 		{
 			name: "Skip tests",
 			input: func() TestRunParams {
 				parameters := runParameters()
 				parameters.TestParams.SkipTesting = []string{"TestTarget1/TestClass1", "TestTarget2"}
 
+				return parameters
+			},
+		},
+		{
+			name: "Swift Testing tags",
+			input: func() TestRunParams {
+				parameters := runParameters()
+				parameters.TestParams.OnlyTesting = []string{"TestTarget1"}
+				parameters.TestParams.OnlyTestTags = []string{"critical"}
+				parameters.TestParams.SkipTestTags = []string{"slow", "networking"}
+
+				return parameters
+			},
+		},
+		{
+			name: "Only test configurations",
+			input: func() TestRunParams {
+				parameters := runParameters()
+				parameters.TestParams.OnlyTestConfigurations = []string{"English", "German"}
+
 				return parameters
 			},
 		},
@@ -93,18 +158,20 @@ func runArgumentsTest(t *testing.T, input TestRunParams) {
 	// Given
 	xcodebuild, mocks := createXcodebuildAndMocks(t)
 
-	arguments := argumentsFromRunParameters(input)
+	testWithoutBuildingInput := input
+	testWithoutBuildingInput.TestParams.TestWithoutBuilding = true
+	arguments := argumentsFromRunParameters(testWithoutBuildingInput)
 	mocks.xcodeCommandRunner.On("Run", mock.Anything, arguments, []string{}).
 		Return(xcodecommand.Output{}, nil)
 
 	// When
-	_, _, _ = xcodebuild.RunTest(input)
+	_, _, _ = xcodebuild.TestWithoutBuilding(input)
 
 	// Then
 	mocks.xcodeCommandRunner.AssertExpectations(t)
 }
 
-func Test_GivenTestRunError_WhenSwiftPackageError_ThenRetries(t *testing.T) {
+func Test_GivenBuildForTestingError_WhenSwiftPackageError_ThenRetries(t *testing.T) {
 	// Given
 	const swiftPMErrMsg = "Could not resolve package dependencies:"
 	parameters := runParameters()
@@ -117,14 +184,40 @@ func Test_GivenTestRunError_WhenSwiftPackageError_ThenRetries(t *testing.T) {
 		}, errors.New("some error"))
 
 	mocks.fileManager.On("RemoveAll", parameters.SwiftPackagesPath).Return(nil)
-	mocks.fileManager.On("RemoveAll", parameters.TestParams.TestOutputDir).Return(nil)
 
 	// When
-	_, _, _ = xcodebuild.RunTest(parameters)
+	_, _, _ = xcodebuild.BuildForTesting(parameters)
 
 	// Then
 	mocks.xcodeCommandRunner.AssertNumberOfCalls(t, "Run", 2)
-	mocks.fileManager.AssertNumberOfCalls(t, "RemoveAll", 2)
+	mocks.fileManager.AssertNumberOfCalls(t, "RemoveAll", 1)
+}
+
+func Test_GivenTestRunError_WhenRetried_ThenKeepsTheResultBundleOfThePreviousAttempt(t *testing.T) {
+	// Given
+	outputDir := t.TempDir()
+	parameters := runParameters()
+	parameters.RetryOnSwiftPackageResolutionError = false
+	parameters.TestParams.TestOutputDir = filepath.Join(outputDir, "Test-BullsEye.xcresult")
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	mocks.xcodeCommandRunner.On("Run", ".", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			require.NoError(t, os.MkdirAll(parameters.TestParams.TestOutputDir, 0700))
+		}).
+		Return(xcodecommand.Output{
+			ExitCode: 1,
+			RawOut:   []byte(testRunnerFailedToInitializeForUITesting),
+		}, errors.New("some error"))
+
+	// When
+	_, _, _ = xcodebuild.TestWithoutBuilding(parameters)
+
+	// Then
+	mocks.xcodeCommandRunner.AssertNumberOfCalls(t, "Run", 2)
+	mocks.fileManager.AssertNotCalled(t, "RemoveAll", mock.Anything)
+	require.Equal(t, []string{filepath.Join(outputDir, "Test-BullsEye-attempt-1.xcresult")}, PreviousAttemptResultBundles(parameters.TestParams.TestOutputDir))
+	require.DirExists(t, parameters.TestParams.TestOutputDir)
 }
 
 func Test_GivenTestRunError_WhenOneOfTheNamedErrorsHappened_ThenActsBasedOnTheConfig(t *testing.T) {
@@ -167,6 +260,53 @@ func Test_GivenTestRunError_WhenOneOfTheNamedErrorsHappened_ThenActsBasedOnTheCo
 	}
 }
 
+func Test_GivenTestRunError_WhenRetryRuleMatches_ThenRetriesUpToTheMaximumWithTheRuleAction(t *testing.T) {
+	// Given
+	parameters := runParameters()
+	parameters.RetryRules = []RetryRule{{Pattern: "Lost connection to test manager", Action: RetryActionRetryAfterReboot}}
+	parameters.MaximumTestRunnerRetries = 3
+	var actions []RetryAction
+	parameters.BeforeTestRunnerRetry = func(action RetryAction) error {
+		actions = append(actions, action)
+		return nil
+	}
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	mocks.xcodeCommandRunner.On("Run", ".", mock.Anything, mock.Anything).
+		Return(xcodecommand.Output{
+			ExitCode: 1,
+			RawOut:   []byte("error: Lost connection to test manager service"),
+		}, errors.New("some error"))
+
+	// When
+	_, _, err := xcodebuild.TestWithoutBuilding(parameters)
+
+	// Then
+	require.Error(t, err)
+	mocks.xcodeCommandRunner.AssertNumberOfCalls(t, "Run", 4)
+	require.Equal(t, []RetryAction{RetryActionRetryAfterReboot, RetryActionRetryAfterReboot, RetryActionRetryAfterReboot}, actions)
+}
+
+func Test_GivenTestRunError_WhenFailFastRuleMatches_ThenDoesNotRetry(t *testing.T) {
+	// Given
+	parameters := runParameters()
+	parameters.RetryRules = []RetryRule{{Pattern: "Early unexpected exit", Action: RetryActionFailFast}}
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	mocks.xcodeCommandRunner.On("Run", ".", mock.Anything, mock.Anything).
+		Return(xcodecommand.Output{
+			ExitCode: 1,
+			RawOut:   []byte(earlyUnexpectedExit),
+		}, errors.New("some error"))
+
+	// When
+	_, _, err := xcodebuild.TestWithoutBuilding(parameters)
+
+	// Then
+	require.Error(t, err)
+	mocks.xcodeCommandRunner.AssertNumberOfCalls(t, "Run", 1)
+}
+
 func Test_GivenTestRunError_WhenAnUnknownErrorHappened_ThenActsBasedOnTheConfig(t *testing.T) {
 	const xcodeOutput = "unknown error: we are definitely not prepared for this"
 
@@ -203,13 +343,135 @@ func runRunnerErrorTests(t *testing.T, expectedNumberOfCreateCalls int, paramete
 	mocks.fileManager.On("RemoveAll", parameters.TestParams.TestOutputDir).Return(nil)
 
 	// When
-	_, _, _ = xcodebuild.RunTest(parameters)
+	_, _, _ = xcodebuild.TestWithoutBuilding(parameters)
 
 	// Then
 	mocks.xcodeCommandRunner.AssertNumberOfCalls(t, "Run", expectedNumberOfCreateCalls)
 	mocks.xcodeCommandRunner.AssertExpectations(t)
 }
 
+func Test_GivenXcodebuild_WhenBuildForTesting_ThenUsesCorrectArguments(t *testing.T) {
+	// Given
+	parameters := runParameters()
+	parameters.TestParams.PerformCleanAction = true
+	parameters.TestParams.TestRepetitionMode = TestRepetitionRetryOnFailure
+	parameters.TestParams.SkipTesting = []string{"TestTarget1/TestClass1/testMethod1()"}
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	arguments := []string{
+		"-project", parameters.TestParams.ProjectPath,
+		"-scheme", parameters.TestParams.Scheme,
+		"clean", "build-for-testing",
+		"-destination", parameters.TestParams.Destinations[0],
+		"-testPlan", parameters.TestParams.TestPlan,
+		"-xcconfig", xcconfigPath,
+		"AdditionalOptions",
+	}
+	mocks.xcodeCommandRunner.On("Run", ".", arguments, []string{}).
+		Return(xcodecommand.Output{}, nil)
+
+	// When
+	_, _, err := xcodebuild.BuildForTesting(parameters)
+
+	// Then
+	require.NoError(t, err)
+	mocks.xcodeCommandRunner.AssertExpectations(t)
+}
+
+func Test_GivenBuildForTestingError_WhenTestRunnerErrorHappened_ThenDoesNotRetry(t *testing.T) {
+	// Given
+	parameters := runParameters()
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	mocks.xcodeCommandRunner.On("Run", ".", mock.Anything, mock.Anything).
+		Return(xcodecommand.Output{
+			ExitCode: 65,
+			RawOut:   []byte(earlyUnexpectedExit),
+		}, errors.New("some error"))
+
+	// When
+	_, exitCode, err := xcodebuild.BuildForTesting(parameters)
+
+	// Then
+	require.Error(t, err)
+	require.Equal(t, 65, exitCode)
+	mocks.xcodeCommandRunner.AssertNumberOfCalls(t, "Run", 1)
+}
+
+func Test_GivenTestWithoutBuilding_WhenSwiftPackageError_ThenDoesNotRetry(t *testing.T) {
+	// Given
+	parameters := runParameters()
+	parameters.RetryOnTestRunnerError = false
+	parameters.TestParams.PerformCleanAction = true
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	testWithoutBuildingParameters := parameters
+	testWithoutBuildingParameters.TestParams.TestWithoutBuilding = true
+	mocks.xcodeCommandRunner.On("Run", ".", argumentsFromRunParameters(testWithoutBuildingParameters), mock.Anything).
+		Return(xcodecommand.Output{
+			ExitCode: 1,
+			RawOut:   []byte("Could not resolve package dependencies:"),
+		}, errors.New("some error"))
+
+	// When
+	_, _, _ = xcodebuild.TestWithoutBuilding(parameters)
+
+	// Then
+	mocks.xcodeCommandRunner.AssertNumberOfCalls(t, "Run", 1)
+	mocks.fileManager.AssertNotCalled(t, "RemoveAll", mock.Anything)
+}
+
+func Test_GivenXcodebuild_WhenTestWithoutBuildingArgs_ThenReturnsTheArgumentsOfTheTestRun(t *testing.T) {
+	// Given
+	parameters := runParameters()
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	testWithoutBuildingParameters := parameters
+	testWithoutBuildingParameters.TestParams.TestWithoutBuilding = true
+
+	// When
+	args, err := xcodebuild.TestWithoutBuildingArgs(parameters)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, argumentsFromRunParameters(testWithoutBuildingParameters), args)
+	mocks.xcodeCommandRunner.AssertNotCalled(t, "Run", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_GivenXctestrun_WhenEnumeratingTests_ThenUsesCorrectArguments(t *testing.T) {
+	// Given
+	parameters := runParameters()
+	parameters.TestParams.XctestrunPath = "BullsEye.xctestrun"
+	parameters.TestParams.XCConfigContent = ""
+	parameters.TestParams.SkipTesting = []string{"TestTarget1/TestClass1/testMethod1()"}
+	xcodebuild, mocks := createXcodebuildAndMocks(t)
+
+	outputPath := filepath.Join(t.TempDir(), "tests.json")
+	arguments := []string{
+		"test-without-building", "-xctestrun", "BullsEye.xctestrun",
+		"-destination", parameters.TestParams.Destinations[0],
+		"-enumerate-tests",
+		"-test-enumeration-style", "flat",
+		"-test-enumeration-format", "json",
+		"-test-enumeration-output-path", outputPath,
+		"-skip-testing:TestTarget1/TestClass1/testMethod1()",
+		"AdditionalOptions",
+	}
+	mocks.xcodeCommandRunner.On("Run", ".", arguments, []string{}).
+		Run(func(args mock.Arguments) {
+			content := `{"values":[{"testPlan":"BullsEye","enabledTests":[{"identifier":"TestTarget1/TestClass1/testMethod2()"}],"disabledTests":[]}]}`
+			require.NoError(t, os.WriteFile(outputPath, []byte(content), 0600))
+		}).
+		Return(xcodecommand.Output{}, nil)
+
+	// When
+	tests, err := xcodebuild.EnumerateTests(parameters, outputPath)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []string{"TestTarget1/TestClass1/testMethod2()"}, tests)
+}
+
 func Test_GivenXcprettyFormatter_WhenEnabled_ThenUsesCorrectArguments(t *testing.T) {
 	// Given
 	outputPath := "path/to/output"
@@ -223,7 +485,7 @@ func Test_GivenXcprettyFormatter_WhenEnabled_ThenUsesCorrectArguments(t *testing
 		Return(xcodecommand.Output{}, nil)
 
 	// When
-	_, _, _ = xcodebuild.RunTest(parameters)
+	_, _, _ = xcodebuild.TestWithoutBuilding(parameters)
 
 	// Then
 	mocks.xcodeCommandRunner.AssertExpectations(t)
@@ -252,7 +514,7 @@ func runParameters() TestRunParams {
 	testParams := TestParams{
 		ProjectPath:                    "ProjectPath.xcodeproj",
 		Scheme:                         "Scheme",
-		Destination:                    "Destination",
+		Destinations:                   []string{"Destination"},
 		TestPlan:                       "TestPlan",
 		TestOutputDir:                  "TestOutputDir",
 		TestRepetitionMode:             "none",
@@ -275,20 +537,33 @@ func runParameters() TestRunParams {
 func argumentsFromRunParameters(parameters TestRunParams) []string {
 	var arguments []string
 
-	if !strings.HasSuffix(parameters.TestParams.ProjectPath, "Package.swift") {
-		arguments = append(arguments, "-project", parameters.TestParams.ProjectPath)
-	}
+	if parameters.TestParams.XctestrunPath != "" {
+		arguments = append(arguments, "test-without-building", "-xctestrun", parameters.TestParams.XctestrunPath)
+		for _, destination := range parameters.TestParams.Destinations {
+			arguments = append(arguments, "-destination", destination)
+		}
+	} else {
+		if !strings.HasSuffix(parameters.TestParams.ProjectPath, "Package.swift") {
+			arguments = append(arguments, "-project", parameters.TestParams.ProjectPath)
+		}
 
-	arguments = append(arguments, "-scheme", parameters.TestParams.Scheme)
+		arguments = append(arguments, "-scheme", parameters.TestParams.Scheme)
 
-	if parameters.TestParams.PerformCleanAction {
-		arguments = append(arguments, "clean")
-	}
+		action := "test"
+		if parameters.TestParams.TestWithoutBuilding {
+			action = "test-without-building"
+		} else if parameters.TestParams.PerformCleanAction {
+			arguments = append(arguments, "clean")
+		}
 
-	arguments = append(arguments, "test", "-destination", parameters.TestParams.Destination)
+		arguments = append(arguments, action)
+		for _, destination := range parameters.TestParams.Destinations {
+			arguments = append(arguments, "-destination", destination)
+		}
 
-	if parameters.TestParams.TestPlan != "" {
-		arguments = append(arguments, "-testPlan", parameters.TestParams.TestPlan)
+		if parameters.TestParams.TestPlan != "" {
+			arguments = append(arguments, "-testPlan", parameters.TestParams.TestPlan)
+		}
 	}
 
 	arguments = append(arguments, "-resultBundlePath", parameters.TestParams.TestOutputDir)
@@ -300,7 +575,7 @@ func argumentsFromRunParameters(parameters TestRunParams) []string {
 		arguments = append(arguments, "-retry-tests-on-failure")
 	}
 
-	if parameters.TestParams.TestRepetitionMode != TestRepetitionNone {
+	if parameters.TestParams.TestRepetitionMode != TestRepetitionNone && parameters.TestParams.TestRepetitionMode != TestRepetitionRerunFailedTests {
 		arguments = append(arguments, "-test-iterations", strconv.Itoa(parameters.TestParams.MaximumTestRepetitions))
 	}
 
@@ -312,10 +587,26 @@ func argumentsFromRunParameters(parameters TestRunParams) []string {
 		arguments = append(arguments, "-xcconfig", xcconfigPath)
 	}
 
+	for _, test := range parameters.TestParams.OnlyTesting {
+		arguments = append(arguments, fmt.Sprintf("-only-testing:%s", test))
+	}
+
 	for _, test := range parameters.TestParams.SkipTesting {
 		arguments = append(arguments, fmt.Sprintf("-skip-testing:%s", test))
 	}
 
+	for _, tag := range parameters.TestParams.OnlyTestTags {
+		arguments = append(arguments, "-only-test-tags", tag)
+	}
+
+	for _, tag := range parameters.TestParams.SkipTestTags {
+		arguments = append(arguments, "-skip-test-tags", tag)
+	}
+
+	for _, configuration := range parameters.TestParams.OnlyTestConfigurations {
+		arguments = append(arguments, "-only-test-configuration", configuration)
+	}
+
 	arguments = append(arguments, parameters.TestParams.AdditionalOptions...)
 
 	return arguments
@@ -0,0 +1,150 @@
+package xcresult
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+)
+
+// argumentsNameSeparator separates the test case name and the arguments of a parameterized Swift Testing test case.
+const argumentsNameSeparator = " (arguments: "
+
+/*
+expandArguments splits the parameterized Swift Testing test cases into a test case per argument combination, so that
+every argument combination has its own result in the test summary, for example `isEven(number:) (arguments: 3)`.
+
+The test case runs of an argument combination are the children of an `Arguments` node, the tags of the test case
+are kept on every argument combination.
+*/
+func expandArguments(nodes []model3.TestNode) []model3.TestNode {
+	var expanded []model3.TestNode
+	for _, node := range nodes {
+		if node.Type != model3.TestNodeTypeTestCase {
+			node.Children = expandArguments(node.Children)
+			expanded = append(expanded, node)
+			continue
+		}
+
+		var argumentsNodes []model3.TestNode
+		for _, child := range node.Children {
+			if child.Type == model3.TestNodeTypeArguments {
+				argumentsNodes = append(argumentsNodes, child)
+			}
+		}
+		if len(argumentsNodes) == 0 {
+			expanded = append(expanded, node)
+			continue
+		}
+
+		for _, argumentsNode := range argumentsNodes {
+			var children []model3.TestNode
+			for _, child := range argumentsNode.Children {
+				if child.Type != model3.TestNodeTypeTestValue {
+					children = append(children, child)
+				}
+			}
+
+			expanded = append(expanded, model3.TestNode{
+				Identifier: node.Identifier,
+				Type:       model3.TestNodeTypeTestCase,
+				Name:       node.Name + argumentsNameSeparator + argumentsName(argumentsNode) + ")",
+				Details:    node.Details,
+				Duration:   argumentsNode.Duration,
+				Result:     argumentsNode.Result,
+				Tags:       node.Tags,
+				Children:   children,
+			})
+		}
+	}
+
+	return expanded
+}
+
+// argumentsName is the name of the Arguments node, or the names of its test values if the node has no name.
+func argumentsName(argumentsNode model3.TestNode) string {
+	if argumentsNode.Name != "" {
+		return argumentsNode.Name
+	}
+
+	var values []string
+	for _, child := range argumentsNode.Children {
+		if child.Type == model3.TestNodeTypeTestValue {
+			values = append(values, child.Name)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// SplitTestCaseName returns the name of the test case and the arguments of a parameterized Swift Testing test case.
+func SplitTestCaseName(name string) (string, string) {
+	testCaseName, arguments, found := strings.Cut(name, argumentsNameSeparator)
+	if !found {
+		return name, ""
+	}
+	return testCaseName, strings.TrimSuffix(arguments, ")")
+}
+
+// TestCaseKey identifies a test case of the test summary: <TestBundle>/<TestClass>/<TestCase>.
+func TestCaseKey(bundle, className, name string) string {
+	return fmt.Sprintf("%s/%s/%s", bundle, className, name)
+}
+
+/*
+TestCaseTags returns the Swift Testing tags of the test cases (including the tags of their test suites) by the
+TestCaseKey of the test cases. The test data can be nil.
+*/
+func TestCaseTags(data *model3.TestData) map[string][]string {
+	tags := map[string][]string{}
+	if data == nil {
+		return tags
+	}
+
+	for _, testPlanNode := range data.TestNodes {
+		for _, testBundleNode := range testPlanNode.Children {
+			collectTestCaseTags(testBundleNode.Children, testBundleNode.Name, testBundleNode.Name, nil, tags)
+		}
+	}
+
+	return tags
+}
+
+/*
+collectTestCaseTags collects the tags of the test cases of a test bundle or test suite. The fallback class name is the
+name of the top level test suite (or the test bundle), as test cases of nested test suites are reported in it.
+*/
+func collectTestCaseTags(nodes []model3.TestNode, bundle, fallbackClassName string, suiteTags []string, tags map[string][]string) {
+	for _, node := range nodes {
+		switch node.Type {
+		case model3.TestNodeTypeTestSuite:
+			suiteFallbackClassName := fallbackClassName
+			if fallbackClassName == bundle {
+				suiteFallbackClassName = node.Name
+			}
+			collectTestCaseTags(node.Children, bundle, suiteFallbackClassName, mergeTags(suiteTags, node.Tags), tags)
+		case model3.TestNodeTypeTestCase:
+			testCaseTags := mergeTags(suiteTags, node.Tags)
+			if len(testCaseTags) == 0 {
+				continue
+			}
+
+			// The class name of the test case is the first component of its identifier, as in the test summary.
+			className := strings.Split(node.Identifier, "/")[0]
+			if className == "" {
+				className = fallbackClassName
+			}
+			tags[TestCaseKey(bundle, className, node.Name)] = testCaseTags
+		}
+	}
+}
+
+func mergeTags(tags, otherTags []string) []string {
+	merged := slices.Clone(tags)
+	for _, tag := range otherTags {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,133 @@
+package xcresult
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+	"github.com/stretchr/testify/require"
+)
+
+func parameterizedTestData() *model3.TestData {
+	return &model3.TestData{TestNodes: []model3.TestNode{{
+		Type: model3.TestNodeTypeTestPlan,
+		Name: "BullsEye",
+		Children: []model3.TestNode{{
+			Type: model3.TestNodeTypeUnitTestBundle,
+			Name: "BullsEyeTests",
+			Children: []model3.TestNode{{
+				Type: model3.TestNodeTypeTestSuite,
+				Name: "NumberTests",
+				Tags: []string{"numbers"},
+				Children: []model3.TestNode{
+					{
+						Identifier: "NumberTests/isEven(number:)",
+						Type:       model3.TestNodeTypeTestCase,
+						Name:       "isEven(number:)",
+						Result:     model3.TestResultFailed,
+						Tags:       []string{"critical"},
+						Children: []model3.TestNode{
+							{
+								Type:     model3.TestNodeTypeArguments,
+								Name:     "2",
+								Duration: "1s",
+								Result:   model3.TestResultPassed,
+								Children: []model3.TestNode{{Type: model3.TestNodeTypeTestValue, Name: "2"}},
+							},
+							{
+								Type:     model3.TestNodeTypeArguments,
+								Duration: "2s",
+								Result:   model3.TestResultFailed,
+								Children: []model3.TestNode{
+									{Type: model3.TestNodeTypeTestValue, Name: "3"},
+									{Type: model3.TestNodeTypeFailureMessage, Name: "NumberTests.swift:12: Expectation failed"},
+								},
+							},
+						},
+					},
+					{
+						Identifier: "NumberTests/isZero()",
+						Type:       model3.TestNodeTypeTestCase,
+						Name:       "isZero()",
+						Result:     model3.TestResultPassed,
+					},
+				},
+			}},
+		}},
+	}}}
+}
+
+func Test_expandArguments(t *testing.T) {
+	data := parameterizedTestData()
+
+	nodes := expandArguments(data.TestNodes)
+
+	testCases := nodes[0].Children[0].Children[0].Children
+	require.Equal(t, []model3.TestNode{
+		{
+			Identifier: "NumberTests/isEven(number:)",
+			Type:       model3.TestNodeTypeTestCase,
+			Name:       "isEven(number:) (arguments: 2)",
+			Duration:   "1s",
+			Result:     model3.TestResultPassed,
+			Tags:       []string{"critical"},
+		},
+		{
+			Identifier: "NumberTests/isEven(number:)",
+			Type:       model3.TestNodeTypeTestCase,
+			Name:       "isEven(number:) (arguments: 3)",
+			Duration:   "2s",
+			Result:     model3.TestResultFailed,
+			Tags:       []string{"critical"},
+			Children:   []model3.TestNode{{Type: model3.TestNodeTypeFailureMessage, Name: "NumberTests.swift:12: Expectation failed"}},
+		},
+		{
+			Identifier: "NumberTests/isZero()",
+			Type:       model3.TestNodeTypeTestCase,
+			Name:       "isZero()",
+			Result:     model3.TestResultPassed,
+		},
+	}, testCases)
+}
+
+func Test_GivenParameterizedTestCase_WhenConverted_ThenEveryArgumentCombinationIsATestCase(t *testing.T) {
+	// Given
+	data := parameterizedTestData()
+	data.TestNodes = expandArguments(data.TestNodes)
+
+	// When
+	testSummary, _, err := model3.Convert(data)
+
+	// Then
+	require.NoError(t, err)
+	testCases := testSummary.TestPlans[0].TestBundles[0].TestSuites[0].TestCases
+	require.Len(t, testCases, 3)
+	require.Equal(t, "isEven(number:) (arguments: 2)", testCases[0].Name)
+	require.Equal(t, model3.TestResultPassed, testCases[0].Result)
+	require.Equal(t, "isEven(number:) (arguments: 3)", testCases[1].Name)
+	require.Equal(t, model3.TestResultFailed, testCases[1].Result)
+	require.Equal(t, "NumberTests.swift:12: Expectation failed", testCases[1].Message)
+}
+
+func TestSplitTestCaseName(t *testing.T) {
+	name, arguments := SplitTestCaseName("isEven(number:) (arguments: 3)")
+	require.Equal(t, "isEven(number:)", name)
+	require.Equal(t, "3", arguments)
+
+	name, arguments = SplitTestCaseName("testSlider()")
+	require.Equal(t, "testSlider()", name)
+	require.Equal(t, "", arguments)
+}
+
+func TestTestCaseTags(t *testing.T) {
+	data := parameterizedTestData()
+	data.TestNodes = expandArguments(data.TestNodes)
+
+	tags := TestCaseTags(data)
+
+	require.Equal(t, map[string][]string{
+		"BullsEyeTests/NumberTests/isEven(number:) (arguments: 2)": {"numbers", "critical"},
+		"BullsEyeTests/NumberTests/isEven(number:) (arguments: 3)": {"numbers", "critical"},
+		"BullsEyeTests/NumberTests/isZero()":                       {"numbers"},
+	}, tags)
+	require.Empty(t, TestCaseTags(nil))
+}
@@ -0,0 +1,81 @@
+package xcresult
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-utils/errorutil"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3"
+	"github.com/bitrise-io/go-xcode/v2/testresult/xcresult3/model3"
+)
+
+// Processor ...
+type Processor interface {
+	ParseTestResults(xcResultPath string, useOldXCResultExtractionMethod bool) (*model3.TestData, *model3.TestSummary, error)
+	Merge(xcResultPaths []string, outputPath string) error
+}
+
+type processor struct {
+	logger         log.Logger
+	commandFactory command.Factory
+}
+
+// NewProcessor ...
+func NewProcessor(logger log.Logger, commandFactory command.Factory) Processor {
+	return &processor{
+		logger:         logger,
+		commandFactory: commandFactory,
+	}
+}
+
+// ParseTestResults returns the raw test data and the converted test summary of an xcresult bundle.
+// Every argument combination of the parameterized Swift Testing test cases is a separate test case.
+// Nil values are returned without an error if the bundle is not supported (or xcresulttool is not available).
+func (p processor) ParseTestResults(xcResultPath string, useOldXCResultExtractionMethod bool) (*model3.TestData, *model3.TestSummary, error) {
+	converter := xcresult3.NewConverter(useOldXCResultExtractionMethod)
+	if !converter.Detect([]string{xcResultPath}) {
+		return nil, nil, nil
+	}
+
+	results, err := xcresult3.ParseTestResults(xcResultPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse xcresult: %w", err)
+	}
+
+	results.TestNodes = expandArguments(results.TestNodes)
+
+	testSummary, warnings, err := model3.Convert(results)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert xcresult data: %w", err)
+	}
+
+	if len(warnings) > 0 {
+		p.logger.Warnf("xcresult converter warnings:")
+		for _, warning := range warnings {
+			p.logger.Warnf("- %s", warning)
+		}
+	}
+
+	return results, testSummary, nil
+}
+
+// Merge merges the given xcresult bundles into a new bundle at outputPath.
+func (p processor) Merge(xcResultPaths []string, outputPath string) error {
+	args := []string{"xcresulttool", "merge"}
+	args = append(args, xcResultPaths...)
+	args = append(args, "--output-path", outputPath)
+
+	cmd := p.commandFactory.Create("xcrun", args, nil)
+	p.logger.Donef("$ %s", cmd.PrintableCommandArgs())
+
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		if errorutil.IsExitStatusError(err) {
+			return fmt.Errorf("failed to merge xcresult bundles: %s", out)
+		}
+
+		return fmt.Errorf("failed to merge xcresult bundles: %w", err)
+	}
+
+	return nil
+}